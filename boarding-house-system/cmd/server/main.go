@@ -33,6 +33,7 @@ func main() {
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
+		BodyLimit: cfg.MaxBodySize,
 		ErrorHandler: func(ctx fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
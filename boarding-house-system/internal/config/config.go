@@ -24,9 +24,21 @@ type Config struct {
 	JWTSecret     string
 	JWTExpiration time.Duration
 
-	UploadDir     string
-	MaxUploadSize int64
-	AllowedTypes  []string
+	UploadDir         string
+	MaxUploadSize     int64
+	MaxAttachmentSize int64
+	MaxBodySize       int
+	AllowedTypes      []string
+
+	StorageDriver    string
+	StorageURLSecret string
+	SignedURLTTL     time.Duration
+	S3Endpoint       string
+	S3Region         string
+	S3Bucket         string
+	S3AccessKey      string
+	S3SecretKey      string
+	S3UsePathStyle   bool
 
 	SMTPHost     string
 	SMTPPort     int
@@ -34,8 +46,27 @@ type Config struct {
 	SMTPPassword string
 	EmailFrom    string
 
+	NotificationDispatchInterval time.Duration
+	OutboxPollInterval           time.Duration
+	WebhookDeliveryInterval      time.Duration
+	AnnouncementInterval         time.Duration
+	NotificationRetentionDays    int
+	NotificationPurgeInterval    time.Duration
+
+	RealtimeBroker       string
+	RealtimePollInterval time.Duration
+	StreamTokenTTL       time.Duration
+
 	AdminEmail    string
 	AdminPassword string
+
+	AnalyticsCacheTTL time.Duration
+
+	MaintenanceReopenDays int
+	SLACheckInterval      time.Duration
+	PlanCheckInterval     time.Duration
+
+	RecurringExpenseInterval time.Duration
 }
 
 func LoadConfig() *Config {
@@ -61,9 +92,23 @@ func LoadConfig() *Config {
 		JWTExpiration: parseDuration(getEnv("JWT_EXPIRATION", "24h")),
 
 		// File Uploads
-		UploadDir:     getEnv("UPLOAD_DIR", "uploads"),
-		MaxUploadSize: parseInt64(getEnv("MAX_UPLOAD_SIZE", "5242880")), // 5MB
-		AllowedTypes:  parseAllowedTypes(getEnv("ALLOWED_FILE_TYPES", ".pdf,.jpg,.jpeg,.png")),
+		UploadDir:         getEnv("UPLOAD_DIR", "uploads"),
+		MaxUploadSize:     parseInt64(getEnv("MAX_UPLOAD_SIZE", "5242880")),      // 5MB
+		MaxAttachmentSize: parseInt64(getEnv("MAX_ATTACHMENT_SIZE", "26214400")), // 25MB
+		MaxBodySize:       parseInt(getEnv("MAX_BODY_SIZE", "104857600")),        // 100MB
+		AllowedTypes:      parseAllowedTypes(getEnv("ALLOWED_FILE_TYPES", ".pdf,.jpg,.jpeg,.png")),
+
+		// File Storage: "local" keeps files in UploadDir, "s3" in an
+		// S3-compatible bucket such as MinIO
+		StorageDriver:    getEnv("STORAGE_DRIVER", "local"),
+		StorageURLSecret: getEnv("STORAGE_URL_SECRET", ""),
+		SignedURLTTL:     parseDurationOr(getEnv("SIGNED_URL_TTL", "15m"), 15*time.Minute),
+		S3Endpoint:       getEnv("S3_ENDPOINT", ""),
+		S3Region:         getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:         getEnv("S3_BUCKET", ""),
+		S3AccessKey:      getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:      getEnv("S3_SECRET_KEY", ""),
+		S3UsePathStyle:   getEnv("S3_USE_PATH_STYLE", "false") == "true",
 
 		// Email Configuration
 		SMTPHost:     getEnv("SMTP_HOST", "smtp.example.com"),
@@ -72,9 +117,34 @@ func LoadConfig() *Config {
 		SMTPPassword: getEnv("SMTP_PASSWORD", "your_email_password"),
 		EmailFrom:    getEnv("EMAIL_FROM", "support@example.com"),
 
+		// Notification delivery
+		NotificationDispatchInterval: parseDurationOr(getEnv("NOTIFICATION_DISPATCH_INTERVAL", "30s"), 30*time.Second),
+		OutboxPollInterval:           parseDurationOr(getEnv("OUTBOX_POLL_INTERVAL", "2s"), 2*time.Second),
+		WebhookDeliveryInterval:      parseDurationOr(getEnv("WEBHOOK_DELIVERY_INTERVAL", "15s"), 15*time.Second),
+		AnnouncementInterval:         parseDurationOr(getEnv("ANNOUNCEMENT_INTERVAL", "1m"), time.Minute),
+		NotificationRetentionDays:    parseInt(getEnv("NOTIFICATION_RETENTION_DAYS", "90")),
+		NotificationPurgeInterval:    parseDurationOr(getEnv("NOTIFICATION_PURGE_INTERVAL", "6h"), 6*time.Hour),
+
+		// Real-time events: "memory" for one instance, "mysql" to share
+		// events between instances
+		RealtimeBroker:       getEnv("REALTIME_BROKER", "memory"),
+		RealtimePollInterval: parseDurationOr(getEnv("REALTIME_POLL_INTERVAL", "500ms"), 500*time.Millisecond),
+		StreamTokenTTL:       parseDurationOr(getEnv("STREAM_TOKEN_TTL", "1m"), time.Minute),
+
 		// Admin Defaults
 		AdminEmail:    getEnv("ADMIN_EMAIL", "admin@example.com"),
 		AdminPassword: getEnv("ADMIN_INITIAL_PASSWORD", "ChangeMe123!"),
+
+		// Analytics
+		AnalyticsCacheTTL: parseDurationOr(getEnv("ANALYTICS_CACHE_TTL", "5m"), 5*time.Minute),
+
+		// Maintenance
+		MaintenanceReopenDays: parseInt(getEnv("MAINTENANCE_REOPEN_DAYS", "7")),
+		SLACheckInterval:      parseDurationOr(getEnv("SLA_CHECK_INTERVAL", "5m"), 5*time.Minute),
+		PlanCheckInterval:     parseDurationOr(getEnv("PLAN_CHECK_INTERVAL", "1h"), time.Hour),
+
+		// Expenses
+		RecurringExpenseInterval: parseDurationOr(getEnv("RECURRING_EXPENSE_INTERVAL", "1h"), time.Hour),
 	}
 }
 
@@ -104,6 +174,14 @@ func parseDuration(s string) time.Duration {
 	return d
 }
 
+func parseDurationOr(s string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
 func parseAllowedTypes(s string) []string {
 	return strings.Split(s, ",")
 }
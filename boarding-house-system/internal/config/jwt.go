@@ -8,6 +8,11 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// streamTokenAudience marks short-lived tokens that only open the event
+// stream. Browsers' EventSource cannot send an Authorization header, so these
+// travel in the query string, where they may end up in logs.
+const streamTokenAudience = "event-stream"
+
 type Claims struct {
 	UserID int    `json:"user_id"`
 	Role   string `json:"role"`
@@ -29,7 +34,56 @@ func GenerateJWT(userID int, role string, secret string) (string, error) {
 	return token.SignedString([]byte(secret))
 }
 
+// GenerateStreamToken issues a token that VerifyStreamToken accepts and
+// VerifyJWT rejects.
+func GenerateStreamToken(userID int, role string, secret string, ttl time.Duration) (string, error) {
+	claims := Claims{
+		UserID: userID,
+		Role:   role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "boarding-house-system",
+			Audience:  jwt.ClaimStrings{streamTokenAudience},
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
+}
+
 func VerifyJWT(tokenString string, secret string) (*Claims, error) {
+	claims, err := parseJWT(tokenString, secret)
+	if err != nil {
+		return nil, err
+	}
+	if isStreamToken(claims) {
+		return nil, errors.New("stream tokens cannot be used for API requests")
+	}
+	return claims, nil
+}
+
+func VerifyStreamToken(tokenString string, secret string) (*Claims, error) {
+	claims, err := parseJWT(tokenString, secret)
+	if err != nil {
+		return nil, err
+	}
+	if !isStreamToken(claims) {
+		return nil, errors.New("not a stream token")
+	}
+	return claims, nil
+}
+
+func isStreamToken(claims *Claims) bool {
+	for _, audience := range claims.Audience {
+		if audience == streamTokenAudience {
+			return true
+		}
+	}
+	return false
+}
+
+func parseJWT(tokenString string, secret string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Kimox23/boarding-house-app/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type AnalyticsController struct {
+	analyticsService *services.AnalyticsService
+}
+
+func NewAnalyticsController(analyticsService *services.AnalyticsService) *AnalyticsController {
+	return &AnalyticsController{analyticsService: analyticsService}
+}
+
+func analyticsFilter(ctx fiber.Ctx) services.AnalyticsFilter {
+	return services.AnalyticsFilter{
+		HouseID: ctx.Query("house_id"),
+		From:    ctx.Query("from"),
+		To:      ctx.Query("to"),
+	}
+}
+
+func (c *AnalyticsController) Summary(ctx fiber.Ctx) error {
+	summary, err := c.analyticsService.Summary(analyticsFilter(ctx))
+	if err != nil {
+		return analyticsError(ctx, err)
+	}
+	return ctx.JSON(summary)
+}
+
+func (c *AnalyticsController) Occupancy(ctx fiber.Ctx) error {
+	occupancy, err := c.analyticsService.Occupancy(analyticsFilter(ctx))
+	if err != nil {
+		return analyticsError(ctx, err)
+	}
+	return ctx.JSON(occupancy)
+}
+
+func (c *AnalyticsController) Revenue(ctx fiber.Ctx) error {
+	revenue, err := c.analyticsService.Revenue(analyticsFilter(ctx))
+	if err != nil {
+		return analyticsError(ctx, err)
+	}
+	return ctx.JSON(revenue)
+}
+
+func (c *AnalyticsController) Maintenance(ctx fiber.Ctx) error {
+	maintenance, err := c.analyticsService.Maintenance(analyticsFilter(ctx))
+	if err != nil {
+		return analyticsError(ctx, err)
+	}
+	return ctx.JSON(maintenance)
+}
+
+// Forecast projects occupancy and expected rent for the next N months.
+func (c *AnalyticsController) Forecast(ctx fiber.Ctx) error {
+	months := 6
+	if value := ctx.Query("months"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid months"})
+		}
+		months = parsed
+	}
+
+	forecast, err := c.analyticsService.Forecast(ctx.Query("house_id"), months)
+	if err != nil {
+		return analyticsError(ctx, err)
+	}
+	return ctx.JSON(forecast)
+}
+
+func analyticsError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidAnalyticsFilter),
+		errors.Is(err, services.ErrInvalidDateRange),
+		errors.Is(err, services.ErrInvalidForecastHorizon):
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
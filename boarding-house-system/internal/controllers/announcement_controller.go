@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type AnnouncementController struct {
+	announcementService *services.AnnouncementService
+}
+
+func NewAnnouncementController(announcementService *services.AnnouncementService) *AnnouncementController {
+	return &AnnouncementController{announcementService: announcementService}
+}
+
+// CreateAnnouncement broadcasts an announcement now or, with a future
+// publish_at, schedules it.
+func (c *AnnouncementController) CreateAnnouncement(ctx fiber.Ctx) error {
+	var input models.Announcement
+	if err := ctx.Bind().Body(&input); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID, _ := utils.GetUserID(ctx)
+	announcement, err := c.announcementService.CreateAnnouncement(&input, userID)
+	if err != nil {
+		return announcementError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(announcement)
+}
+
+func (c *AnnouncementController) GetAnnouncements(ctx fiber.Ctx) error {
+	announcements, err := c.announcementService.GetAnnouncements()
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(announcements)
+}
+
+func (c *AnnouncementController) GetAnnouncement(ctx fiber.Ctx) error {
+	announcement, err := c.announcementService.GetAnnouncement(ctx.Params("id"))
+	if err != nil {
+		return announcementError(ctx, err)
+	}
+	return ctx.JSON(announcement)
+}
+
+func (c *AnnouncementController) UpdateAnnouncement(ctx fiber.Ctx) error {
+	var input models.Announcement
+	if err := ctx.Bind().Body(&input); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	announcement, err := c.announcementService.UpdateAnnouncement(ctx.Params("id"), &input)
+	if err != nil {
+		return announcementError(ctx, err)
+	}
+	return ctx.JSON(announcement)
+}
+
+func (c *AnnouncementController) DeleteAnnouncement(ctx fiber.Ctx) error {
+	if err := c.announcementService.DeleteAnnouncement(ctx.Params("id")); err != nil {
+		return announcementError(ctx, err)
+	}
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// GetMyAnnouncements lists the current announcements sent to the caller.
+func (c *AnnouncementController) GetMyAnnouncements(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	announcements, err := c.announcementService.GetUserAnnouncements(userID)
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(announcements)
+}
+
+// GetBoard lists the pinned announcements of a house's bulletin board.
+func (c *AnnouncementController) GetBoard(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	announcements, err := c.announcementService.GetBoard(ctx.Params("houseId"), userID, utils.GetUserRole(ctx))
+	if err != nil {
+		return announcementError(ctx, err)
+	}
+	return ctx.JSON(announcements)
+}
+
+// MarkRead records the caller's read receipt.
+func (c *AnnouncementController) MarkRead(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	if err := c.announcementService.MarkRead(ctx.Params("id"), userID); err != nil {
+		return announcementError(ctx, err)
+	}
+	return ctx.SendStatus(http.StatusOK)
+}
+
+func (c *AnnouncementController) GetReceipts(ctx fiber.Ctx) error {
+	receipts, err := c.announcementService.GetReceipts(ctx.Params("id"))
+	if err != nil {
+		return announcementError(ctx, err)
+	}
+	return ctx.JSON(receipts)
+}
+
+func announcementError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Announcement not found"})
+	case errors.Is(err, services.ErrInvalidAnnouncement):
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrAnnouncementNotAllowed):
+		return ctx.Status(http.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type AssetController struct {
+	assetService *services.AssetService
+}
+
+func NewAssetController(assetService *services.AssetService) *AssetController {
+	return &AssetController{assetService: assetService}
+}
+
+func (c *AssetController) CreateAsset(ctx fiber.Ctx) error {
+	var asset models.Asset
+	if err := ctx.Bind().Body(&asset); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.assetService.CreateAsset(&asset); err != nil {
+		return assetError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(asset)
+}
+
+func (c *AssetController) GetAsset(ctx fiber.Ctx) error {
+	asset, err := c.assetService.GetAsset(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Asset not found"})
+	}
+	return ctx.JSON(asset)
+}
+
+// GetAssetsByRoom lists a room's inventory. Disposed assets are included
+// with ?all=true.
+func (c *AssetController) GetAssetsByRoom(ctx fiber.Ctx) error {
+	assets, err := c.assetService.GetAssetsByRoom(ctx.Params("roomId"), ctx.Query("all") == "true")
+	if err != nil {
+		return assetError(ctx, err)
+	}
+	return ctx.JSON(assets)
+}
+
+func (c *AssetController) UpdateAsset(ctx fiber.Ctx) error {
+	var asset models.Asset
+	if err := ctx.Bind().Body(&asset); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.assetService.UpdateAsset(ctx.Params("id"), &asset); err != nil {
+		return assetError(ctx, err)
+	}
+
+	return ctx.JSON(asset)
+}
+
+func (c *AssetController) DisposeAsset(ctx fiber.Ctx) error {
+	if err := c.assetService.DisposeAsset(ctx.Params("id")); err != nil {
+		return assetError(ctx, err)
+	}
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func (c *AssetController) RepairHistory(ctx fiber.Ctx) error {
+	history, err := c.assetService.RepairHistory(ctx.Params("id"))
+	if err != nil {
+		return assetError(ctx, err)
+	}
+	return ctx.JSON(history)
+}
+
+func (c *AssetController) Report(ctx fiber.Ctx) error {
+	report, err := c.assetService.Report(ctx.Params("houseId"))
+	if err != nil {
+		return assetError(ctx, err)
+	}
+	return ctx.JSON(report)
+}
+
+func assetError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Asset not found"})
+	case errors.Is(err, services.ErrInvalidAsset):
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
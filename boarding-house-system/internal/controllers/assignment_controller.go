@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type AssignmentController struct {
+	assignmentService  *services.AssignmentService
+	maintenanceService *services.MaintenanceService
+}
+
+func NewAssignmentController(assignmentService *services.AssignmentService,
+	maintenanceService *services.MaintenanceService) *AssignmentController {
+	return &AssignmentController{
+		assignmentService:  assignmentService,
+		maintenanceService: maintenanceService,
+	}
+}
+
+func (c *AssignmentController) GetRules(ctx fiber.Ctx) error {
+	rules, err := c.assignmentService.GetRules()
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(rules)
+}
+
+func (c *AssignmentController) CreateRule(ctx fiber.Ctx) error {
+	var rule models.AssignmentRule
+	if err := ctx.Bind().Body(&rule); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.assignmentService.CreateRule(&rule); err != nil {
+		return assignmentError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(rule)
+}
+
+func (c *AssignmentController) UpdateRule(ctx fiber.Ctx) error {
+	var rule models.AssignmentRule
+	if err := ctx.Bind().Body(&rule); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.assignmentService.UpdateRule(ctx.Params("ruleId"), &rule); err != nil {
+		return assignmentError(ctx, err)
+	}
+
+	return ctx.JSON(rule)
+}
+
+func (c *AssignmentController) DeleteRule(ctx fiber.Ctx) error {
+	if err := c.assignmentService.DeleteRule(ctx.Params("ruleId")); err != nil {
+		return assignmentError(ctx, err)
+	}
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// GetStaff lists staff members with their profile and open workload.
+func (c *AssignmentController) GetStaff(ctx fiber.Ctx) error {
+	profiles, err := c.assignmentService.GetStaffProfiles()
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(profiles)
+}
+
+func (c *AssignmentController) UpdateStaffProfile(ctx fiber.Ctx) error {
+	var profile models.StaffProfile
+	if err := ctx.Bind().Body(&profile); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	updated, err := c.assignmentService.UpdateStaffProfile(ctx.Params("userId"), &profile)
+	if err != nil {
+		return assignmentError(ctx, err)
+	}
+
+	return ctx.JSON(updated)
+}
+
+// SetShift starts or ends the calling staff member's shift.
+func (c *AssignmentController) SetShift(ctx fiber.Ctx) error {
+	var input struct {
+		OnShift bool `json:"on_shift"`
+	}
+	if err := ctx.Bind().Body(&input); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID, _ := utils.GetUserID(ctx)
+	if err := c.assignmentService.SetOnShift(userID, utils.GetUserRole(ctx), input.OnShift); err != nil {
+		return assignmentError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{"on_shift": input.OnShift})
+}
+
+// MyQueue returns the calling staff member's open work.
+func (c *AssignmentController) MyQueue(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	queue, err := c.assignmentService.MyQueue(userID, utils.GetUserRole(ctx))
+	if err != nil {
+		return assignmentError(ctx, err)
+	}
+	return ctx.JSON(queue)
+}
+
+func (c *AssignmentController) Queue(ctx fiber.Ctx) error {
+	queue, err := c.assignmentService.Queue(ctx.Params("staffId"))
+	if err != nil {
+		return assignmentError(ctx, err)
+	}
+	return ctx.JSON(queue)
+}
+
+// AutoAssign applies the assignment rules to an unassigned request.
+func (c *AssignmentController) AutoAssign(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	request, err := c.maintenanceService.GetRequest(ctx.Params("id"), userID, utils.GetUserRole(ctx))
+	if err != nil {
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Request not found"})
+	}
+	if request.AssignedTo != nil {
+		return ctx.Status(http.StatusConflict).JSON(fiber.Map{"error": "Request is already assigned"})
+	}
+
+	if _, err := c.assignmentService.AutoAssign(request); err != nil {
+		return assignmentError(ctx, err)
+	}
+
+	return ctx.JSON(request)
+}
+
+func assignmentError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Not found"})
+	case errors.Is(err, services.ErrInvalidAssignmentRule),
+		errors.Is(err, services.ErrInvalidStaffProfile):
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrNotStaff):
+		return ctx.Status(http.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrNoEligibleStaff):
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Kimox23/boarding-house-app/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type AuditController struct {
+	auditService *services.AuditService
+}
+
+func NewAuditController(auditService *services.AuditService) *AuditController {
+	return &AuditController{auditService: auditService}
+}
+
+// GetAuditLog lists recorded domain events, newest first, filtered by the
+// subject_type, subject_id and limit query parameters.
+func (c *AuditController) GetAuditLog(ctx fiber.Ctx) error {
+	entries, err := c.auditService.GetAuditLog(ctx.Query("subject_type"), ctx.Query("subject_id"), ctx.Query("limit"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(entries)
+}
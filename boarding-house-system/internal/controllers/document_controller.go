@@ -1,13 +1,15 @@
 package controllers
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/Kimox23/boarding-house-app/internal/models"
 	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/storage"
 	"github.com/Kimox23/boarding-house-app/internal/utils"
 
 	"github.com/gofiber/fiber/v3"
@@ -15,18 +17,24 @@ import (
 
 type DocumentController struct {
 	documentService *services.DocumentService
-	uploadDir       string
+	store           storage.Storage
+	urls            fileURLs
 }
 
-func NewDocumentController(documentService *services.DocumentService, uploadDir string) *DocumentController {
+func NewDocumentController(documentService *services.DocumentService, store storage.Storage,
+	signedURLTTL time.Duration) *DocumentController {
 	return &DocumentController{
 		documentService: documentService,
-		uploadDir:       uploadDir,
+		store:           store,
+		urls:            fileURLs{store: store, ttl: signedURLTTL},
 	}
 }
 
 func (c *DocumentController) UploadDocument(ctx fiber.Ctx) error {
-	tenantID := ctx.Params("tenantId")
+	tenantID, err := strconv.Atoi(ctx.Params("tenantId"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid tenant ID"})
+	}
 	file, err := ctx.FormFile("document")
 	if err != nil {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Document file is required"})
@@ -38,36 +46,51 @@ func (c *DocumentController) UploadDocument(ctx fiber.Ctx) error {
 	}
 
 	// Save the uploaded file
-	filename, err := utils.SaveUploadedFile(ctx, file, c.uploadDir)
+	filename, err := utils.SaveUploadedFile(c.store, file)
 	if err != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save document"})
 	}
 
-	tenantIDInt, err := strconv.Atoi(tenantID)
-	if err != nil {
-		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid tenant ID"})
-	}
-
 	document := &models.Document{
-		TenantID:     tenantIDInt,
+		TenantID:     tenantID,
 		DocumentType: documentType,
 		FilePath:     filename,
 	}
 
-	if err := c.documentService.UploadDocument(document); err != nil {
+	userID, _ := utils.GetUserID(ctx)
+	if err := c.documentService.UploadDocument(document, userID, utils.GetUserRole(ctx)); err != nil {
 		// Clean up the uploaded file if database operation fails
-		os.Remove(filepath.Join(c.uploadDir, filename))
-		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save document info"})
+		c.store.Delete(filename)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Tenant not found"})
+		case errors.Is(err, services.ErrDocumentNotAllowed):
+			return ctx.Status(http.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save document info"})
+		}
 	}
 
+	document.URL = c.urls.sign(document.FilePath)
 	return ctx.Status(http.StatusCreated).JSON(document)
 }
 
+// GetTenantDocuments lists a tenant's documents with download links.
 func (c *DocumentController) GetTenantDocuments(ctx fiber.Ctx) error {
-	tenantId := ctx.Params("tenantId")
-	documents, err := c.documentService.GetTenantDocuments(tenantId)
+	userID, _ := utils.GetUserID(ctx)
+	documents, err := c.documentService.GetTenantDocuments(ctx.Params("tenantId"), userID, utils.GetUserRole(ctx))
 	if err != nil {
-		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Tenant not found"})
+		case errors.Is(err, services.ErrDocumentNotAllowed):
+			return ctx.Status(http.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	for i := range documents {
+		documents[i].URL = c.urls.sign(documents[i].FilePath)
 	}
 	return ctx.JSON(documents)
 }
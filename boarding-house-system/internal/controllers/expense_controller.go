@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/storage"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type ExpenseController struct {
+	expenseService *services.ExpenseService
+	store          storage.Storage
+	urls           fileURLs
+}
+
+func NewExpenseController(expenseService *services.ExpenseService, store storage.Storage,
+	signedURLTTL time.Duration) *ExpenseController {
+	return &ExpenseController{
+		expenseService: expenseService,
+		store:          store,
+		urls:           fileURLs{store: store, ttl: signedURLTTL},
+	}
+}
+
+func (c *ExpenseController) CreateExpense(ctx fiber.Ctx) error {
+	var expense models.Expense
+	if err := ctx.Bind().Body(&expense); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	expense.RecordedBy, _ = utils.GetUserID(ctx)
+
+	if err := c.expenseService.CreateExpense(&expense); err != nil {
+		return expenseError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(expense)
+}
+
+func (c *ExpenseController) GetExpense(ctx fiber.Ctx) error {
+	id := ctx.Params("id")
+	expense, err := c.expenseService.GetExpense(id)
+	if err != nil {
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Expense not found"})
+	}
+	expense.ReceiptURL = c.urls.sign(expense.Receipt)
+	return ctx.JSON(expense)
+}
+
+func (c *ExpenseController) GetExpensesByHouse(ctx fiber.Ctx) error {
+	expenses, err := c.expenseService.GetExpensesByHouse(ctx.Params("houseId"),
+		ctx.Query("from"), ctx.Query("to"), ctx.Query("category"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	for i := range expenses {
+		expenses[i].ReceiptURL = c.urls.sign(expenses[i].Receipt)
+	}
+	return ctx.JSON(expenses)
+}
+
+func (c *ExpenseController) UpdateExpense(ctx fiber.Ctx) error {
+	id := ctx.Params("id")
+	var expense models.Expense
+	if err := ctx.Bind().Body(&expense); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.expenseService.UpdateExpense(id, &expense); err != nil {
+		return expenseError(ctx, err)
+	}
+
+	return ctx.JSON(expense)
+}
+
+func (c *ExpenseController) DeleteExpense(ctx fiber.Ctx) error {
+	id := ctx.Params("id")
+	if err := c.expenseService.DeleteExpense(id); err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// UploadReceipt attaches a scanned receipt to an expense, replacing and
+// removing any earlier one.
+func (c *ExpenseController) UploadReceipt(ctx fiber.Ctx) error {
+	id := ctx.Params("id")
+	file, err := ctx.FormFile("receipt")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Receipt file is required"})
+	}
+
+	if _, err := c.expenseService.GetExpense(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Expense not found"})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	filename, err := utils.SaveUploadedFile(c.store, file)
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save receipt"})
+	}
+
+	previous, err := c.expenseService.SetReceipt(id, filename)
+	if err != nil {
+		// Clean up the uploaded file if database operation fails
+		c.store.Delete(filename)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Expense not found"})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save receipt info"})
+	}
+	if previous != "" && previous != filename {
+		c.store.Delete(previous)
+	}
+
+	return ctx.JSON(fiber.Map{"receipt": filename, "receipt_url": c.urls.sign(filename)})
+}
+
+func (c *ExpenseController) CreateRecurringExpense(ctx fiber.Ctx) error {
+	var recurring models.RecurringExpense
+	if err := ctx.Bind().Body(&recurring); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.expenseService.CreateRecurringExpense(&recurring); err != nil {
+		return expenseError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(recurring)
+}
+
+func (c *ExpenseController) GetRecurringExpensesByHouse(ctx fiber.Ctx) error {
+	houseId := ctx.Params("houseId")
+	schedules, err := c.expenseService.GetRecurringExpensesByHouse(houseId)
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(schedules)
+}
+
+func (c *ExpenseController) DeactivateRecurringExpense(ctx fiber.Ctx) error {
+	id := ctx.Params("id")
+	if err := c.expenseService.DeactivateRecurringExpense(id); err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// PostRecurringExpenses books all recurring expenses that have fallen due.
+func (c *ExpenseController) PostRecurringExpenses(ctx fiber.Ctx) error {
+	posted, err := c.expenseService.PostRecurringExpenses(time.Now())
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(fiber.Map{"posted": posted})
+}
+
+func expenseError(ctx fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrInvalidExpenseAmount) || errors.Is(err, services.ErrInvalidDayOfMonth) {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/storage"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type FileController struct {
+	store        storage.Storage
+	signedURLTTL time.Duration
+}
+
+func NewFileController(store storage.Storage, signedURLTTL time.Duration) *FileController {
+	return &FileController{store: store, signedURLTTL: signedURLTTL}
+}
+
+// Serve streams a file from a signed URL. It is only routed for backends
+// whose signed URLs point back at this server.
+func (c *FileController) Serve(ctx fiber.Ctx) error {
+	key, err := url.PathUnescape(ctx.Params("*"))
+	if err != nil {
+		return fileError(ctx, storage.ErrInvalidKey)
+	}
+	if verifier, ok := c.store.(storage.URLVerifier); ok {
+		if err := verifier.Verify(key, ctx.Query("expires"), ctx.Query("signature")); err != nil {
+			return fileError(ctx, err)
+		}
+	}
+
+	file, object, err := c.store.Get(key)
+	if err != nil {
+		return fileError(ctx, err)
+	}
+
+	// Anything that is not a known image or PDF, such as an uploaded HTML or
+	// SVG file, is only ever downloaded
+	contentType := object.ContentType
+	ctx.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	if !utils.IsInlineContentType(contentType) {
+		contentType = "application/octet-stream"
+		ctx.Set(fiber.HeaderContentDisposition, `attachment; filename="`+path.Base(key)+`"`)
+	}
+	ctx.Set(fiber.HeaderContentType, contentType)
+	ctx.Set(fiber.HeaderCacheControl, "private, max-age="+strconv.Itoa(int(c.signedURLTTL.Seconds())))
+	return ctx.SendStream(file, int(object.Size))
+}
+
+// fileURLs fills in short-lived download links for the stored files in a
+// response. Controllers call it only after the service has checked that the
+// caller may see the resource the files belong to; there is no endpoint
+// that signs an arbitrary key.
+type fileURLs struct {
+	store storage.Storage
+	ttl   time.Duration
+}
+
+// sign returns a download link for key, or "" when the link cannot be made;
+// the rest of the response is still useful without it.
+func (f fileURLs) sign(key string) string {
+	if key == "" {
+		return ""
+	}
+	signedURL, err := f.store.SignedURL(key, f.ttl)
+	if err != nil {
+		log.Printf("failed to sign URL for %s: %v", key, err)
+		return ""
+	}
+	return signedURL
+}
+
+func fileError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "File not found"})
+	case errors.Is(err, storage.ErrInvalidKey):
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, storage.ErrInvalidSignature):
+		return ctx.Status(http.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
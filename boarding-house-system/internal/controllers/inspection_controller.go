@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/storage"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type InspectionController struct {
+	inspectionService *services.InspectionService
+	store             storage.Storage
+	urls              fileURLs
+	maxPhotoSize      int64
+}
+
+func NewInspectionController(inspectionService *services.InspectionService, store storage.Storage,
+	maxPhotoSize int64, signedURLTTL time.Duration) *InspectionController {
+	return &InspectionController{
+		inspectionService: inspectionService,
+		store:             store,
+		urls:              fileURLs{store: store, ttl: signedURLTTL},
+		maxPhotoSize:      maxPhotoSize,
+	}
+}
+
+func (c *InspectionController) GetChecklist(ctx fiber.Ctx) error {
+	items, err := c.inspectionService.GetChecklist(ctx.Params("roomType"))
+	if err != nil {
+		return inspectionError(ctx, err)
+	}
+	return ctx.JSON(items)
+}
+
+func (c *InspectionController) CreateChecklistItem(ctx fiber.Ctx) error {
+	var item models.ChecklistItem
+	if err := ctx.Bind().Body(&item); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.inspectionService.CreateChecklistItem(&item); err != nil {
+		return inspectionError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(item)
+}
+
+func (c *InspectionController) UpdateChecklistItem(ctx fiber.Ctx) error {
+	var item models.ChecklistItem
+	if err := ctx.Bind().Body(&item); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.inspectionService.UpdateChecklistItem(ctx.Params("itemId"), &item); err != nil {
+		return inspectionError(ctx, err)
+	}
+
+	return ctx.JSON(item)
+}
+
+func (c *InspectionController) DeactivateChecklistItem(ctx fiber.Ctx) error {
+	if err := c.inspectionService.DeactivateChecklistItem(ctx.Params("itemId")); err != nil {
+		return inspectionError(ctx, err)
+	}
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func (c *InspectionController) StartInspection(ctx fiber.Ctx) error {
+	var inspection models.Inspection
+	if err := ctx.Bind().Body(&inspection); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	inspectorID, _ := utils.GetUserID(ctx)
+	inspection.InspectedBy = inspectorID
+	if err := c.inspectionService.StartInspection(&inspection); err != nil {
+		return inspectionError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(inspection)
+}
+
+func (c *InspectionController) GetInspection(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	inspection, err := c.inspectionService.GetInspection(ctx.Params("id"), userID, utils.GetUserRole(ctx))
+	if err != nil {
+		return inspectionError(ctx, err)
+	}
+	c.signInspection(inspection)
+	return ctx.JSON(inspection)
+}
+
+func (c *InspectionController) GetInspectionsByTenant(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	inspections, err := c.inspectionService.GetInspectionsByTenant(ctx.Params("tenantId"), userID, utils.GetUserRole(ctx))
+	if err != nil {
+		return inspectionError(ctx, err)
+	}
+	for i := range inspections {
+		c.signInspection(&inspections[i])
+	}
+	return ctx.JSON(inspections)
+}
+
+func (c *InspectionController) AddItem(ctx fiber.Ctx) error {
+	var item models.InspectionItem
+	if err := ctx.Bind().Body(&item); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.inspectionService.AddItem(ctx.Params("id"), &item); err != nil {
+		return inspectionError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(item)
+}
+
+func (c *InspectionController) UpdateItem(ctx fiber.Ctx) error {
+	var item models.InspectionItem
+	if err := ctx.Bind().Body(&item); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.inspectionService.UpdateItem(ctx.Params("id"), ctx.Params("itemId"), &item); err != nil {
+		return inspectionError(ctx, err)
+	}
+
+	return ctx.SendStatus(http.StatusOK)
+}
+
+// UploadPhotos stores one or more "photos" images as evidence for an item.
+func (c *InspectionController) UploadPhotos(ctx fiber.Ctx) error {
+	var files []*multipart.FileHeader
+	if form, err := ctx.MultipartForm(); err == nil {
+		files = form.File["photos"]
+	}
+	if len(files) == 0 {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "At least one photo is required"})
+	}
+	for _, file := range files {
+		if mediaType, ok := utils.MediaTypeForFile(file.Filename); !ok || mediaType != "image" {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Photos must be images"})
+		}
+		if file.Size > c.maxPhotoSize {
+			return ctx.Status(http.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "Photo is too large"})
+		}
+	}
+
+	photos := make([]models.InspectionPhoto, 0, len(files))
+	for _, file := range files {
+		filename, err := utils.SaveUploadedFile(c.store, file)
+		if err != nil {
+			c.removePhotos(photos)
+			return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save photo"})
+		}
+		photos = append(photos, models.InspectionPhoto{FilePath: filename, OriginalName: file.Filename})
+	}
+
+	if err := c.inspectionService.AddPhotos(ctx.Params("id"), ctx.Params("itemId"), photos); err != nil {
+		// Clean up the uploaded files if database operation fails
+		c.removePhotos(photos)
+		return inspectionError(ctx, err)
+	}
+
+	c.signPhotos(photos)
+	return ctx.Status(http.StatusCreated).JSON(photos)
+}
+
+func (c *InspectionController) CompleteInspection(ctx fiber.Ctx) error {
+	inspection, err := c.inspectionService.CompleteInspection(ctx.Params("id"))
+	if err != nil {
+		return inspectionError(ctx, err)
+	}
+	c.signInspection(inspection)
+	return ctx.JSON(inspection)
+}
+
+// Acknowledge lets the tenant sign off on an inspection, or dispute it with
+// a comment by sending "agree": false.
+func (c *InspectionController) Acknowledge(ctx fiber.Ctx) error {
+	var input struct {
+		Agree   bool   `json:"agree"`
+		Comment string `json:"comment"`
+	}
+	if err := ctx.Bind().Body(&input); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID, _ := utils.GetUserID(ctx)
+	inspection, err := c.inspectionService.Acknowledge(ctx.Params("id"), userID, input.Agree, input.Comment)
+	if err != nil {
+		return inspectionError(ctx, err)
+	}
+	c.signInspection(inspection)
+	return ctx.JSON(inspection)
+}
+
+func (c *InspectionController) Diff(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	diff, err := c.inspectionService.Diff(ctx.Params("tenantId"), userID, utils.GetUserRole(ctx))
+	if err != nil {
+		return inspectionError(ctx, err)
+	}
+	for i := range diff.Items {
+		c.signPhotos(diff.Items[i].MoveInPhotos)
+		c.signPhotos(diff.Items[i].MoveOutPhotos)
+	}
+	return ctx.JSON(diff)
+}
+
+// signInspection adds download links to the photos of an inspection's items.
+func (c *InspectionController) signInspection(inspection *models.Inspection) {
+	for i := range inspection.Items {
+		c.signPhotos(inspection.Items[i].Photos)
+	}
+}
+
+func (c *InspectionController) signPhotos(photos []models.InspectionPhoto) {
+	for i := range photos {
+		photos[i].URL = c.urls.sign(photos[i].FilePath)
+	}
+}
+
+func (c *InspectionController) removePhotos(photos []models.InspectionPhoto) {
+	for _, photo := range photos {
+		c.store.Delete(photo.FilePath)
+	}
+}
+
+func inspectionError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Not found"})
+	case errors.Is(err, services.ErrInvalidChecklistItem),
+		errors.Is(err, services.ErrInvalidInspectionType),
+		errors.Is(err, services.ErrInvalidInspectionItem),
+		errors.Is(err, services.ErrTenantHasNoRoom),
+		errors.Is(err, services.ErrDisputeNeedsComment):
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrInspectionForbidden):
+		return ctx.Status(http.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrInspectionExists),
+		errors.Is(err, services.ErrInspectionLocked),
+		errors.Is(err, services.ErrInspectionIncomplete),
+		errors.Is(err, services.ErrInspectionNotCompleted),
+		errors.Is(err, services.ErrInspectionsMissing),
+		errors.Is(err, repositories.ErrInspectionStatusChanged):
+		return ctx.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Kimox23/boarding-house-app/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type InvoiceController struct {
+	invoiceService *services.InvoiceService
+}
+
+func NewInvoiceController(invoiceService *services.InvoiceService) *InvoiceController {
+	return &InvoiceController{invoiceService: invoiceService}
+}
+
+// GenerateMonthlyInvoices bills all active tenants for a month.
+func (c *InvoiceController) GenerateMonthlyInvoices(ctx fiber.Ctx) error {
+	var input struct {
+		Month  string `json:"month"`
+		DueDay int    `json:"due_day"`
+	}
+	if err := ctx.Bind().Body(&input); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if input.DueDay == 0 {
+		input.DueDay = 5
+	}
+
+	created, err := c.invoiceService.GenerateMonthlyInvoices(input.Month, input.DueDay)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(fiber.Map{"created": created})
+}
+
+func (c *InvoiceController) GetInvoicesByTenant(ctx fiber.Ctx) error {
+	tenantId := ctx.Params("tenantId")
+	invoices, err := c.invoiceService.GetInvoicesByTenant(tenantId)
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(invoices)
+}
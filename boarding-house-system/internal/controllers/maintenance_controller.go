@@ -1,20 +1,38 @@
 package controllers
 
 import (
+	"database/sql"
+	"errors"
+	"mime/multipart"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
 	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/storage"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
 
 	"github.com/gofiber/fiber/v3"
 )
 
 type MaintenanceController struct {
 	maintenanceService *services.MaintenanceService
+	store              storage.Storage
+	urls               fileURLs
+	maxAttachmentSize  int64
 }
 
-func NewMaintenanceController(maintenanceService *services.MaintenanceService) *MaintenanceController {
-	return &MaintenanceController{maintenanceService: maintenanceService}
+func NewMaintenanceController(maintenanceService *services.MaintenanceService, store storage.Storage,
+	maxAttachmentSize int64, signedURLTTL time.Duration) *MaintenanceController {
+	return &MaintenanceController{
+		maintenanceService: maintenanceService,
+		store:              store,
+		urls:               fileURLs{store: store, ttl: signedURLTTL},
+		maxAttachmentSize:  maxAttachmentSize,
+	}
 }
 
 func (c *MaintenanceController) CreateRequest(ctx fiber.Ctx) error {
@@ -23,8 +41,12 @@ func (c *MaintenanceController) CreateRequest(ctx fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
 
-	if err := c.maintenanceService.CreateRequest(&request); err != nil {
-		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	userID, ok := utils.GetUserID(ctx)
+	if !ok {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	if err := c.maintenanceService.CreateRequest(&request, userID, utils.GetUserRole(ctx)); err != nil {
+		return maintenanceError(ctx, err)
 	}
 
 	return ctx.Status(http.StatusCreated).JSON(request)
@@ -32,18 +54,20 @@ func (c *MaintenanceController) CreateRequest(ctx fiber.Ctx) error {
 
 func (c *MaintenanceController) GetRequest(ctx fiber.Ctx) error {
 	id := ctx.Params("id")
-	request, err := c.maintenanceService.GetRequest(id)
+	userID, _ := utils.GetUserID(ctx)
+	request, err := c.maintenanceService.GetRequest(id, userID, utils.GetUserRole(ctx))
 	if err != nil {
-		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Request not found"})
+		return maintenanceError(ctx, err)
 	}
 	return ctx.JSON(request)
 }
 
 func (c *MaintenanceController) GetRequestsByRoom(ctx fiber.Ctx) error {
 	roomId := ctx.Params("roomId")
-	requests, err := c.maintenanceService.GetRequestsByRoom(roomId)
+	userID, _ := utils.GetUserID(ctx)
+	requests, err := c.maintenanceService.GetRequestsByRoom(roomId, userID, utils.GetUserRole(ctx))
 	if err != nil {
-		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return maintenanceError(ctx, err)
 	}
 	return ctx.JSON(requests)
 }
@@ -55,8 +79,9 @@ func (c *MaintenanceController) UpdateRequest(ctx fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
 
-	if err := c.maintenanceService.UpdateRequest(id, &request); err != nil {
-		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	actorID, _ := utils.GetUserID(ctx)
+	if err := c.maintenanceService.UpdateRequest(id, &request, actorID); err != nil {
+		return maintenanceError(ctx, err)
 	}
 
 	return ctx.JSON(request)
@@ -67,16 +92,30 @@ func (c *MaintenanceController) UpdateRequestStatus(ctx fiber.Ctx) error {
 	var statusUpdate struct {
 		Status     string `json:"status"`
 		AssignedTo *int   `json:"assigned_to"`
+		Note       string `json:"note"`
 	}
 	if err := ctx.Bind().Body(&statusUpdate); err != nil {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
 
-	if err := c.maintenanceService.UpdateRequestStatus(id, statusUpdate.Status, statusUpdate.AssignedTo); err != nil {
-		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	actorID, _ := utils.GetUserID(ctx)
+	request, err := c.maintenanceService.UpdateRequestStatus(id, statusUpdate.Status,
+		statusUpdate.AssignedTo, actorID, statusUpdate.Note)
+	if err != nil {
+		return maintenanceError(ctx, err)
 	}
 
-	return ctx.SendStatus(http.StatusOK)
+	return ctx.JSON(request)
+}
+
+// GetTimeline returns the history of status changes and edits for a request.
+func (c *MaintenanceController) GetTimeline(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	events, err := c.maintenanceService.GetTimeline(ctx.Params("id"), userID, utils.GetUserRole(ctx))
+	if err != nil {
+		return maintenanceError(ctx, err)
+	}
+	return ctx.JSON(events)
 }
 
 func (c *MaintenanceController) DeleteRequest(ctx fiber.Ctx) error {
@@ -86,3 +125,118 @@ func (c *MaintenanceController) DeleteRequest(ctx fiber.Ctx) error {
 	}
 	return ctx.SendStatus(http.StatusNoContent)
 }
+
+// AddComment posts a comment as multipart form data with a "body", an
+// optional "parent_id" to reply in a thread, "is_internal" for staff notes
+// and any number of image or video files under "attachments".
+func (c *MaintenanceController) AddComment(ctx fiber.Ctx) error {
+	authorID, ok := utils.GetUserID(ctx)
+	if !ok {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	comment := models.MaintenanceComment{
+		AuthorID:   authorID,
+		Body:       strings.TrimSpace(ctx.FormValue("body")),
+		IsInternal: ctx.FormValue("is_internal") == "true",
+	}
+	if parent := ctx.FormValue("parent_id"); parent != "" {
+		parentID, err := strconv.Atoi(parent)
+		if err != nil {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid parent ID"})
+		}
+		comment.ParentID = &parentID
+	}
+
+	var files []*multipart.FileHeader
+	if form, err := ctx.MultipartForm(); err == nil {
+		files = form.File["attachments"]
+	}
+	for _, file := range files {
+		mediaType, ok := utils.MediaTypeForFile(file.Filename)
+		if !ok {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Attachments must be images or videos"})
+		}
+		if file.Size > c.maxAttachmentSize {
+			return ctx.Status(http.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "Attachment is too large"})
+		}
+		comment.Attachments = append(comment.Attachments, models.MaintenanceAttachment{
+			OriginalName: file.Filename,
+			MediaType:    mediaType,
+			FileSize:     file.Size,
+		})
+	}
+
+	for i, file := range files {
+		filename, err := utils.SaveUploadedFile(c.store, file)
+		if err != nil {
+			c.removeAttachments(comment.Attachments[:i])
+			return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save attachment"})
+		}
+		comment.Attachments[i].FilePath = filename
+	}
+
+	if err := c.maintenanceService.AddComment(ctx.Params("id"), &comment, utils.GetUserRole(ctx)); err != nil {
+		// Clean up the uploaded files if the comment was not stored
+		c.removeAttachments(comment.Attachments)
+		return maintenanceError(ctx, err)
+	}
+
+	c.signAttachments(comment.Attachments)
+	return ctx.Status(http.StatusCreated).JSON(comment)
+}
+
+func (c *MaintenanceController) GetComments(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	comments, err := c.maintenanceService.GetComments(ctx.Params("id"), userID, utils.GetUserRole(ctx))
+	if err != nil {
+		return maintenanceError(ctx, err)
+	}
+	c.signComments(comments)
+	return ctx.JSON(comments)
+}
+
+// signComments adds download links to the attachments of comments and
+// their replies.
+func (c *MaintenanceController) signComments(comments []models.MaintenanceComment) {
+	for i := range comments {
+		c.signAttachments(comments[i].Attachments)
+		c.signComments(comments[i].Replies)
+	}
+}
+
+func (c *MaintenanceController) signAttachments(attachments []models.MaintenanceAttachment) {
+	for i := range attachments {
+		attachments[i].URL = c.urls.sign(attachments[i].FilePath)
+	}
+}
+
+func (c *MaintenanceController) removeAttachments(attachments []models.MaintenanceAttachment) {
+	for _, attachment := range attachments {
+		if attachment.FilePath != "" {
+			c.store.Delete(attachment.FilePath)
+		}
+	}
+}
+
+func maintenanceError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Request not found"})
+	case errors.Is(err, services.ErrEmptyComment),
+		errors.Is(err, services.ErrAssetNotInRoom),
+		errors.Is(err, repositories.ErrInvalidCommentParent):
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrInternalNoteForbidden),
+		errors.Is(err, services.ErrMaintenanceNotAllowed):
+		return ctx.Status(http.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidStatusTransition),
+		errors.Is(err, services.ErrQuoteApprovalRequired),
+		errors.Is(err, services.ErrReopenWindowExpired),
+		errors.Is(err, repositories.ErrMaintenanceStatusChanged),
+		errors.Is(err, repositories.ErrCostFromInvoices):
+		return ctx.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type MaintenancePlanController struct {
+	planService *services.MaintenancePlanService
+}
+
+func NewMaintenancePlanController(planService *services.MaintenancePlanService) *MaintenancePlanController {
+	return &MaintenancePlanController{planService: planService}
+}
+
+func (c *MaintenancePlanController) CreatePlan(ctx fiber.Ctx) error {
+	var plan models.MaintenancePlan
+	if err := ctx.Bind().Body(&plan); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID, _ := utils.GetUserID(ctx)
+	plan.CreatedBy = userID
+	if err := c.planService.CreatePlan(&plan); err != nil {
+		return planError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(plan)
+}
+
+func (c *MaintenancePlanController) GetPlan(ctx fiber.Ctx) error {
+	plan, err := c.planService.GetPlan(ctx.Params("planId"))
+	if err != nil {
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Maintenance plan not found"})
+	}
+	return ctx.JSON(plan)
+}
+
+// GetPlans lists plans, optionally for one house. Inactive plans are only
+// included with ?all=true.
+func (c *MaintenancePlanController) GetPlans(ctx fiber.Ctx) error {
+	plans, err := c.planService.GetPlans(ctx.Query("house_id"), ctx.Query("all") != "true")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(plans)
+}
+
+func (c *MaintenancePlanController) UpdatePlan(ctx fiber.Ctx) error {
+	var plan models.MaintenancePlan
+	if err := ctx.Bind().Body(&plan); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.planService.UpdatePlan(ctx.Params("planId"), &plan); err != nil {
+		return planError(ctx, err)
+	}
+
+	return ctx.JSON(plan)
+}
+
+func (c *MaintenancePlanController) DeactivatePlan(ctx fiber.Ctx) error {
+	if err := c.planService.DeactivatePlan(ctx.Params("planId")); err != nil {
+		return planError(ctx, err)
+	}
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// Generate runs the scheduler immediately instead of waiting for the next
+// background check.
+func (c *MaintenancePlanController) Generate(ctx fiber.Ctx) error {
+	created, err := c.planService.GenerateDue(time.Now())
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(fiber.Map{"requests_created": created})
+}
+
+func (c *MaintenancePlanController) Calendar(ctx fiber.Ctx) error {
+	occurrences, err := c.planService.Calendar(analyticsFilter(ctx))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(occurrences)
+}
+
+func (c *MaintenancePlanController) PlanHistory(ctx fiber.Ctx) error {
+	runs, err := c.planService.PlanHistory(ctx.Params("planId"))
+	if err != nil {
+		return planError(ctx, err)
+	}
+	return ctx.JSON(runs)
+}
+
+func (c *MaintenancePlanController) RoomHistory(ctx fiber.Ctx) error {
+	runs, err := c.planService.RoomHistory(ctx.Params("roomId"))
+	if err != nil {
+		return planError(ctx, err)
+	}
+	return ctx.JSON(runs)
+}
+
+func (c *MaintenancePlanController) AssetHistory(ctx fiber.Ctx) error {
+	runs, err := c.planService.AssetHistory(ctx.Params("assetId"))
+	if err != nil {
+		return planError(ctx, err)
+	}
+	return ctx.JSON(runs)
+}
+
+func planError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Maintenance plan not found"})
+	case errors.Is(err, services.ErrInvalidMaintenancePlan),
+		errors.Is(err, services.ErrInvalidPlanAsset):
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/storage"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type MessageController struct {
+	messageService    *services.MessageService
+	store             storage.Storage
+	urls              fileURLs
+	maxAttachmentSize int64
+	allowedTypes      []string
+}
+
+func NewMessageController(messageService *services.MessageService, store storage.Storage,
+	maxAttachmentSize int64, allowedTypes []string, signedURLTTL time.Duration) *MessageController {
+	return &MessageController{
+		messageService:    messageService,
+		store:             store,
+		urls:              fileURLs{store: store, ttl: signedURLTTL},
+		maxAttachmentSize: maxAttachmentSize,
+		allowedTypes:      allowedTypes,
+	}
+}
+
+// StartConversation opens a conversation as multipart form data with a
+// "subject", a "body", any number of files under "attachments" and, when
+// staff write first, the "tenant_user_id" to write to.
+func (c *MessageController) StartConversation(ctx fiber.Ctx) error {
+	userID, ok := utils.GetUserID(ctx)
+	if !ok {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	conversation := models.Conversation{Subject: ctx.FormValue("subject")}
+	if tenant := ctx.FormValue("tenant_user_id"); tenant != "" {
+		tenantUserID, err := strconv.Atoi(tenant)
+		if err != nil {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid tenant user ID"})
+		}
+		conversation.TenantUserID = tenantUserID
+	}
+
+	message, err := c.readMessage(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if message == nil {
+		return nil
+	}
+
+	if err := c.messageService.StartConversation(&conversation, message, utils.GetUserRole(ctx)); err != nil {
+		c.removeAttachments(message.Attachments)
+		return messageError(ctx, err)
+	}
+
+	c.signMessages(conversation.Messages)
+	return ctx.Status(http.StatusCreated).JSON(conversation)
+}
+
+// GetConversations lists the caller's conversations. Staff see the
+// conversations of the houses they work in or, with house_id, one house's
+// inbox; admins see every house.
+func (c *MessageController) GetConversations(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	conversations, err := c.messageService.GetConversations(userID, utils.GetUserRole(ctx),
+		ctx.Query("house_id"), ctx.Query("status"))
+	if err != nil {
+		return messageError(ctx, err)
+	}
+	return ctx.JSON(conversations)
+}
+
+// GetConversation returns a conversation with its messages and marks it
+// read for the caller's side.
+func (c *MessageController) GetConversation(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	conversation, err := c.messageService.GetConversation(ctx.Params("id"), userID, utils.GetUserRole(ctx))
+	if err != nil {
+		return messageError(ctx, err)
+	}
+	c.signMessages(conversation.Messages)
+	return ctx.JSON(conversation)
+}
+
+// SendMessage replies as multipart form data with a "body" and any number
+// of files under "attachments".
+func (c *MessageController) SendMessage(ctx fiber.Ctx) error {
+	userID, ok := utils.GetUserID(ctx)
+	if !ok {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	message, err := c.readMessage(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if message == nil {
+		return nil
+	}
+
+	if err := c.messageService.SendMessage(ctx.Params("id"), message, utils.GetUserRole(ctx)); err != nil {
+		c.removeAttachments(message.Attachments)
+		return messageError(ctx, err)
+	}
+
+	c.signMessages([]models.Message{*message})
+	return ctx.Status(http.StatusCreated).JSON(message)
+}
+
+func (c *MessageController) MarkRead(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	if err := c.messageService.MarkRead(ctx.Params("id"), userID, utils.GetUserRole(ctx)); err != nil {
+		return messageError(ctx, err)
+	}
+	return ctx.SendStatus(http.StatusOK)
+}
+
+// UpdateStatus closes or reopens a conversation.
+func (c *MessageController) UpdateStatus(ctx fiber.Ctx) error {
+	var input struct {
+		Status string `json:"status"`
+	}
+	if err := ctx.Bind().Body(&input); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID, _ := utils.GetUserID(ctx)
+	if err := c.messageService.UpdateStatus(ctx.Params("id"), userID, utils.GetUserRole(ctx), input.Status); err != nil {
+		return messageError(ctx, err)
+	}
+	return ctx.SendStatus(http.StatusOK)
+}
+
+// GetUnreadCount returns how many messages the caller's side has not read,
+// optionally for one house.
+func (c *MessageController) GetUnreadCount(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	count, err := c.messageService.UnreadCount(userID, utils.GetUserRole(ctx), ctx.Query("house_id"))
+	if err != nil {
+		return messageError(ctx, err)
+	}
+	return ctx.JSON(fiber.Map{"unread": count})
+}
+
+// readMessage builds a message from the form and saves its attachments. It
+// returns a nil message when it has already sent an error response.
+func (c *MessageController) readMessage(ctx fiber.Ctx, senderID int) (*models.Message, error) {
+	message := &models.Message{
+		SenderID: senderID,
+		Body:     strings.TrimSpace(ctx.FormValue("body")),
+	}
+
+	var files []*multipart.FileHeader
+	if form, err := ctx.MultipartForm(); err == nil {
+		files = form.File["attachments"]
+	}
+	for _, file := range files {
+		if !slices.Contains(c.allowedTypes, strings.ToLower(filepath.Ext(file.Filename))) {
+			return nil, ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Attachment type is not allowed"})
+		}
+		if file.Size > c.maxAttachmentSize {
+			return nil, ctx.Status(http.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "Attachment is too large"})
+		}
+		message.Attachments = append(message.Attachments, models.MessageAttachment{
+			OriginalName: file.Filename,
+			FileSize:     file.Size,
+		})
+	}
+
+	for i, file := range files {
+		filename, err := utils.SaveUploadedFile(c.store, file)
+		if err != nil {
+			c.removeAttachments(message.Attachments[:i])
+			return nil, ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save attachment"})
+		}
+		message.Attachments[i].FilePath = filename
+	}
+	return message, nil
+}
+
+// signMessages adds download links to the messages' attachments.
+func (c *MessageController) signMessages(messages []models.Message) {
+	for _, message := range messages {
+		for i := range message.Attachments {
+			message.Attachments[i].URL = c.urls.sign(message.Attachments[i].FilePath)
+		}
+	}
+}
+
+func (c *MessageController) removeAttachments(attachments []models.MessageAttachment) {
+	for _, attachment := range attachments {
+		if attachment.FilePath != "" {
+			c.store.Delete(attachment.FilePath)
+		}
+	}
+}
+
+func messageError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Conversation not found"})
+	case errors.Is(err, services.ErrEmptyMessage),
+		errors.Is(err, services.ErrInvalidSubject),
+		errors.Is(err, services.ErrInvalidConversationStatus):
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrMessageNotAllowed):
+		return ctx.Status(http.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrNoTenancy):
+		return ctx.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
@@ -1,30 +1,55 @@
 package controllers
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 
 	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
 	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
 
 	"github.com/gofiber/fiber/v3"
 )
 
 type NotificationController struct {
 	notificationService *services.NotificationService
+	dispatcher          *services.NotificationDispatcher
+	templateService     *services.NotificationTemplateService
 }
 
-func NewNotificationController(notificationService *services.NotificationService) *NotificationController {
-	return &NotificationController{notificationService: notificationService}
+func NewNotificationController(notificationService *services.NotificationService,
+	dispatcher *services.NotificationDispatcher, templateService *services.NotificationTemplateService) *NotificationController {
+	return &NotificationController{
+		notificationService: notificationService,
+		dispatcher:          dispatcher,
+		templateService:     templateService,
+	}
 }
 
+// CreateNotification sends a notification with free text or, when a
+// template is named, rendered from the template in the user's language.
 func (c *NotificationController) CreateNotification(ctx fiber.Ctx) error {
-	var notification models.Notification
-	if err := ctx.Bind().Body(&notification); err != nil {
+	var input struct {
+		models.Notification
+		Template  string                 `json:"template"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := ctx.Bind().Body(&input); err != nil {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
 
+	if input.Template != "" {
+		if err := c.templateService.Send(input.UserID, input.Template, input.Variables); err != nil {
+			return templateError(ctx, err)
+		}
+		return ctx.SendStatus(http.StatusCreated)
+	}
+
+	notification := input.Notification
 	if err := c.notificationService.CreateNotification(&notification); err != nil {
-		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return inboxError(ctx, err)
 	}
 
 	return ctx.Status(http.StatusCreated).JSON(notification)
@@ -32,25 +57,213 @@ func (c *NotificationController) CreateNotification(ctx fiber.Ctx) error {
 
 func (c *NotificationController) GetUserNotifications(ctx fiber.Ctx) error {
 	userId := ctx.Params("userId")
-	notifications, err := c.notificationService.GetUserNotifications(userId)
+	callerID, _ := utils.GetUserID(ctx)
+	notifications, err := c.notificationService.GetUserNotifications(userId, callerID, utils.GetUserRole(ctx))
 	if err != nil {
-		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return inboxError(ctx, err)
 	}
 	return ctx.JSON(notifications)
 }
 
-func (c *NotificationController) MarkAsRead(ctx fiber.Ctx) error {
-	id := ctx.Params("id")
-	if err := c.notificationService.MarkAsRead(id); err != nil {
+// GetMyNotifications returns a page of the caller's inbox, newest first,
+// optionally only unread notifications (unread_only=true) or one category.
+func (c *NotificationController) GetMyNotifications(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	pagination := utils.GetPagination(ctx)
+	filter := repositories.InboxFilter{
+		UnreadOnly: ctx.Query("unread_only") == "true",
+		Category:   ctx.Query("category"),
+	}
+	notifications, total, err := c.notificationService.GetInbox(userID, filter, pagination)
+	if err != nil {
+		return inboxError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"data": notifications,
+		"meta": fiber.Map{
+			"page":        pagination.Page,
+			"page_size":   pagination.PageSize,
+			"total":       total,
+			"total_pages": (total + pagination.PageSize - 1) / pagination.PageSize,
+		},
+	})
+}
+
+// GetUnreadCount returns the caller's unread total and the count per
+// category.
+func (c *NotificationController) GetUnreadCount(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	total, byCategory, err := c.notificationService.UnreadCount(userID)
+	if err != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
+	return ctx.JSON(fiber.Map{"unread": total, "by_category": byCategory})
+}
+
+// MarkAllRead marks the caller's notifications read, or only those of the
+// category query parameter.
+func (c *NotificationController) MarkAllRead(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	updated, err := c.notificationService.MarkAllRead(userID, ctx.Query("category"))
+	if err != nil {
+		return inboxError(ctx, err)
+	}
+	return ctx.JSON(fiber.Map{"updated": updated})
+}
+
+// DeleteNotifications deletes the listed notifications of the caller.
+func (c *NotificationController) DeleteNotifications(ctx fiber.Ctx) error {
+	var input struct {
+		IDs []int `json:"ids"`
+	}
+	if err := ctx.Bind().Body(&input); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID, _ := utils.GetUserID(ctx)
+	deleted, err := c.notificationService.DeleteNotifications(userID, input.IDs)
+	if err != nil {
+		return inboxError(ctx, err)
+	}
+	return ctx.JSON(fiber.Map{"deleted": deleted})
+}
+
+func (c *NotificationController) MarkAsRead(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	if err := c.notificationService.MarkAsRead(ctx.Params("id"), userID); err != nil {
+		return inboxError(ctx, err)
+	}
 	return ctx.SendStatus(http.StatusOK)
 }
 
 func (c *NotificationController) DeleteNotification(ctx fiber.Ctx) error {
-	id := ctx.Params("id")
-	if err := c.notificationService.DeleteNotification(id); err != nil {
+	userID, _ := utils.GetUserID(ctx)
+	if err := c.notificationService.DeleteNotification(ctx.Params("id"), userID); err != nil {
+		return inboxError(ctx, err)
+	}
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// GetPreferences returns the caller's notification channel preferences.
+func (c *NotificationController) GetPreferences(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	prefs, err := c.dispatcher.GetPreferences(userID)
+	if err != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
+	return ctx.JSON(prefs)
+}
+
+func (c *NotificationController) UpdatePreferences(ctx fiber.Ctx) error {
+	var prefs models.NotificationPreferences
+	if err := ctx.Bind().Body(&prefs); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID, _ := utils.GetUserID(ctx)
+	if err := c.dispatcher.UpdatePreferences(userID, &prefs); err != nil {
+		if errors.Is(err, services.ErrInvalidQuietHours) || errors.Is(err, services.ErrInvalidLocale) {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.JSON(prefs)
+}
+
+// GetDeliveries returns the delivery status of a notification per channel.
+func (c *NotificationController) GetDeliveries(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	deliveries, err := c.dispatcher.GetDeliveries(ctx.Params("id"), userID, utils.GetUserRole(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Notification not found"})
+		case errors.Is(err, services.ErrNotificationNotAllowed):
+			return ctx.Status(http.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	return ctx.JSON(deliveries)
+}
+
+// GetTemplates lists the built-in and stored template variants.
+func (c *NotificationController) GetTemplates(ctx fiber.Ctx) error {
+	templates, err := c.templateService.GetTemplates()
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(templates)
+}
+
+// SaveTemplate stores a customised or translated variant of a template.
+func (c *NotificationController) SaveTemplate(ctx fiber.Ctx) error {
+	var tmpl models.NotificationTemplate
+	if err := ctx.Bind().Body(&tmpl); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID, _ := utils.GetUserID(ctx)
+	if err := c.templateService.SaveTemplate(ctx.Params("name"), ctx.Params("locale"), &tmpl, userID); err != nil {
+		return templateError(ctx, err)
+	}
+
+	return ctx.JSON(tmpl)
+}
+
+func (c *NotificationController) DeleteTemplate(ctx fiber.Ctx) error {
+	if err := c.templateService.DeleteTemplate(ctx.Params("name"), ctx.Params("locale")); err != nil {
+		return templateError(ctx, err)
+	}
 	return ctx.SendStatus(http.StatusNoContent)
 }
+
+// PreviewTemplate renders a template for a locale with sample variables
+// without sending it.
+func (c *NotificationController) PreviewTemplate(ctx fiber.Ctx) error {
+	var input struct {
+		Locale    string                 `json:"locale"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := ctx.Bind().Body(&input); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	preview, err := c.templateService.Render(ctx.Params("name"), input.Locale, input.Variables)
+	if err != nil {
+		return templateError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{"title": preview.Title, "message": preview.Message, "link": preview.Link})
+}
+
+func inboxError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Notification not found"})
+	case errors.Is(err, services.ErrNotificationNotAllowed):
+		return ctx.Status(http.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidCategory),
+		errors.Is(err, services.ErrInvalidNotificationIDs):
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
+
+func templateError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Not found"})
+	case errors.Is(err, services.ErrUnknownTemplate):
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidTemplate),
+		errors.Is(err, services.ErrInvalidLocale),
+		errors.Is(err, services.ErrTemplateVariable):
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
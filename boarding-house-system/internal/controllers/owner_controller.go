@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type OwnerController struct {
+	ownerService *services.OwnerService
+}
+
+func NewOwnerController(ownerService *services.OwnerService) *OwnerController {
+	return &OwnerController{ownerService: ownerService}
+}
+
+func (c *OwnerController) CreateOwner(ctx fiber.Ctx) error {
+	var owner models.Owner
+	if err := ctx.Bind().Body(&owner); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.ownerService.CreateOwner(&owner); err != nil {
+		return ownerError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(owner)
+}
+
+func (c *OwnerController) GetOwner(ctx fiber.Ctx) error {
+	id := ctx.Params("id")
+	owner, err := c.ownerService.GetOwner(id)
+	if err != nil {
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Owner not found"})
+	}
+	return ctx.JSON(owner)
+}
+
+func (c *OwnerController) GetAllOwners(ctx fiber.Ctx) error {
+	owners, err := c.ownerService.GetAllOwners()
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(owners)
+}
+
+func (c *OwnerController) UpdateOwner(ctx fiber.Ctx) error {
+	id := ctx.Params("id")
+	var owner models.Owner
+	if err := ctx.Bind().Body(&owner); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.ownerService.UpdateOwner(id, &owner); err != nil {
+		return ownerError(ctx, err)
+	}
+
+	return ctx.JSON(owner)
+}
+
+func (c *OwnerController) AssignHouse(ctx fiber.Ctx) error {
+	id := ctx.Params("id")
+	var input struct {
+		HouseID int `json:"house_id"`
+	}
+	if err := ctx.Bind().Body(&input); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.ownerService.AssignHouse(id, input.HouseID); err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.SendStatus(http.StatusOK)
+}
+
+func (c *OwnerController) UnassignHouse(ctx fiber.Ctx) error {
+	if err := c.ownerService.UnassignHouse(ctx.Params("id"), ctx.Params("houseId")); err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// GenerateStatement builds (or rebuilds) the owner's statement for a month.
+func (c *OwnerController) GenerateStatement(ctx fiber.Ctx) error {
+	id := ctx.Params("id")
+	var input struct {
+		Month string `json:"month"`
+	}
+	if err := ctx.Bind().Body(&input); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	statement, err := c.ownerService.GenerateStatement(id, input.Month)
+	if err != nil {
+		return ownerError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(statement)
+}
+
+func (c *OwnerController) GetStatementsByOwner(ctx fiber.Ctx) error {
+	id := ctx.Params("id")
+	statements, err := c.ownerService.GetStatementsByOwner(id)
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(statements)
+}
+
+// GetStatement returns a statement as JSON, or as PDF with format=pdf.
+func (c *OwnerController) GetStatement(ctx fiber.Ctx) error {
+	statement, err := c.ownerService.GetStatement(ctx.Params("statementId"))
+	if err != nil {
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Statement not found"})
+	}
+
+	if ctx.Query("format") == "pdf" {
+		owner, err := c.ownerService.GetOwner(strconv.Itoa(statement.OwnerID))
+		if err != nil {
+			return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		filename := "owner-statement-" + strconv.Itoa(owner.ID) + "-" + statement.PeriodMonth.Format("2006-01") + ".pdf"
+		return sendAttachment(ctx, filename, "application/pdf", c.ownerService.StatementPDF(owner, statement))
+	}
+	return ctx.JSON(statement)
+}
+
+func (c *OwnerController) RecordPayout(ctx fiber.Ctx) error {
+	var payout models.OwnerPayout
+	if err := ctx.Bind().Body(&payout); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	payout.RecordedBy, _ = utils.GetUserID(ctx)
+
+	if err := c.ownerService.RecordPayout(ctx.Params("statementId"), &payout); err != nil {
+		return ownerError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(payout)
+}
+
+func ownerError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Not found"})
+	case errors.Is(err, services.ErrInvalidManagementFee),
+		errors.Is(err, services.ErrInvalidPayoutAmount):
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrPayoutExceedsBalance),
+		errors.Is(err, repositories.ErrStatementHasPayouts):
+		return ctx.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
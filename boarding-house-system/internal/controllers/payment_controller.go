@@ -1,10 +1,14 @@
 package controllers
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 
 	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
 	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
 
 	"github.com/gofiber/fiber/v3"
 )
@@ -22,6 +26,7 @@ func (c *PaymentController) CreatePayment(ctx fiber.Ctx) error {
 	if err := ctx.Bind().Body(&payment); err != nil {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
+	payment.RecordedBy, _ = utils.GetUserID(ctx)
 
 	if err := c.paymentService.CreatePayment(&payment); err != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
@@ -48,24 +53,58 @@ func (c *PaymentController) GetPaymentsByTenant(ctx fiber.Ctx) error {
 	return ctx.JSON(payments)
 }
 
-func (c *PaymentController) UpdatePayment(ctx fiber.Ctx) error {
+// VoidPayment cancels a payment by recording a linked reversing entry.
+func (c *PaymentController) VoidPayment(ctx fiber.Ctx) error {
 	id := ctx.Params("id")
-	var payment models.Payment
-	if err := ctx.Bind().Body(&payment); err != nil {
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	if err := ctx.Bind().Body(&input); err != nil {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
 
-	if err := c.paymentService.UpdatePayment(id, &payment); err != nil {
-		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	userID, _ := utils.GetUserID(ctx)
+	reversal, err := c.paymentService.VoidPayment(id, input.Reason, userID)
+	if err != nil {
+		return reversalError(ctx, err)
 	}
 
-	return ctx.JSON(payment)
+	return ctx.Status(http.StatusCreated).JSON(reversal)
 }
 
-func (c *PaymentController) DeletePayment(ctx fiber.Ctx) error {
+// RefundPayment refunds part or all of a payment as a linked reversing entry.
+func (c *PaymentController) RefundPayment(ctx fiber.Ctx) error {
 	id := ctx.Params("id")
-	if err := c.paymentService.DeletePayment(id); err != nil {
+	var input struct {
+		Amount float64 `json:"amount"`
+		Reason string  `json:"reason"`
+	}
+	if err := ctx.Bind().Body(&input); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID, _ := utils.GetUserID(ctx)
+	reversal, err := c.paymentService.RefundPayment(id, input.Amount, input.Reason, userID)
+	if err != nil {
+		return reversalError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(reversal)
+}
+
+func reversalError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Payment not found"})
+	case errors.Is(err, services.ErrReversalReasonRequired),
+		errors.Is(err, services.ErrInvalidRefundAmount):
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, repositories.ErrPaymentIsReversal),
+		errors.Is(err, repositories.ErrPaymentAlreadyVoided),
+		errors.Is(err, repositories.ErrPaymentHasRefunds),
+		errors.Is(err, repositories.ErrRefundExceedsBalance):
+		return ctx.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
-	return ctx.SendStatus(http.StatusNoContent)
 }
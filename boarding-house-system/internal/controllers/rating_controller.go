@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type RatingController struct {
+	ratingService *services.RatingService
+}
+
+func NewRatingController(ratingService *services.RatingService) *RatingController {
+	return &RatingController{ratingService: ratingService}
+}
+
+// Rate lets the reporter of a completed request rate the fix and, if they
+// are not satisfied, reopen it.
+func (c *RatingController) Rate(ctx fiber.Ctx) error {
+	var input struct {
+		Score    int    `json:"score"`
+		Feedback string `json:"feedback"`
+		Reopen   bool   `json:"reopen"`
+	}
+	if err := ctx.Bind().Body(&input); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID, _ := utils.GetUserID(ctx)
+	rating := &models.MaintenanceRating{Score: input.Score, Feedback: input.Feedback}
+	request, err := c.ratingService.Rate(ctx.Params("id"), userID, rating, input.Reopen)
+	if err != nil {
+		return ratingError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(fiber.Map{"rating": rating, "request": request})
+}
+
+func (c *RatingController) GetRatings(ctx fiber.Ctx) error {
+	userID, _ := utils.GetUserID(ctx)
+	ratings, err := c.ratingService.GetRatings(ctx.Params("id"), userID, utils.GetUserRole(ctx))
+	if err != nil {
+		return ratingError(ctx, err)
+	}
+	return ctx.JSON(ratings)
+}
+
+// Report returns average scores and reopen rates by house, issue type and
+// assignee.
+func (c *RatingController) Report(ctx fiber.Ctx) error {
+	report, err := c.ratingService.Report(analyticsFilter(ctx))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(report)
+}
+
+func ratingError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Request not found"})
+	case errors.Is(err, services.ErrInvalidRatingScore):
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrRatingNotAllowed):
+		return ctx.Status(http.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrRequestNotComplete),
+		errors.Is(err, services.ErrInvalidStatusTransition),
+		errors.Is(err, services.ErrReopenWindowExpired),
+		errors.Is(err, repositories.ErrAlreadyRated),
+		errors.Is(err, repositories.ErrMaintenanceStatusChanged):
+		return ctx.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
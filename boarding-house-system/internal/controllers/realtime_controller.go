@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/config"
+	"github.com/Kimox23/boarding-house-app/internal/realtime"
+	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// streamHeartbeat keeps idle connections open through proxies and detects
+// clients that went away.
+const streamHeartbeat = 25 * time.Second
+
+type RealtimeController struct {
+	realtimeService *services.RealtimeService
+	cfg             *config.Config
+}
+
+func NewRealtimeController(realtimeService *services.RealtimeService, cfg *config.Config) *RealtimeController {
+	return &RealtimeController{realtimeService: realtimeService, cfg: cfg}
+}
+
+// StreamToken issues a short-lived token for opening the event stream with
+// ?token=, since EventSource cannot send an Authorization header.
+func (c *RealtimeController) StreamToken(ctx fiber.Ctx) error {
+	userID, ok := utils.GetUserID(ctx)
+	if !ok {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	token, err := config.GenerateStreamToken(userID, utils.GetUserRole(ctx), c.cfg.JWTSecret, c.cfg.StreamTokenTTL)
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate token"})
+	}
+	return ctx.JSON(fiber.Map{
+		"token":      token,
+		"expires_at": time.Now().Add(c.cfg.StreamTokenTTL),
+	})
+}
+
+// Stream pushes the caller's notifications, unread count and maintenance
+// status updates as Server-Sent Events.
+func (c *RealtimeController) Stream(ctx fiber.Ctx) error {
+	userID, ok := utils.GetUserID(ctx)
+	if !ok {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	sub, unread, err := c.realtimeService.Subscribe(userID)
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ctx.Set("Content-Type", "text/event-stream")
+	ctx.Set("Cache-Control", "no-cache")
+	ctx.Set("Connection", "keep-alive")
+	ctx.Set("X-Accel-Buffering", "no")
+
+	return ctx.SendStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		fmt.Fprintf(w, "event: %s\ndata: {\"unread\":%d}\n\n", realtime.EventUnreadCount, unread)
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case event, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, event.Data)
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+}
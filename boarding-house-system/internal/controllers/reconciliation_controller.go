@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type ReconciliationController struct {
+	reconciliationService *services.ReconciliationService
+}
+
+func NewReconciliationController(reconciliationService *services.ReconciliationService) *ReconciliationController {
+	return &ReconciliationController{reconciliationService: reconciliationService}
+}
+
+// ImportStatement uploads a CSV or OFX bank statement and returns the
+// suggested tenant match for each line.
+func (c *ReconciliationController) ImportStatement(ctx fiber.Ctx) error {
+	fileHeader, err := ctx.FormFile("statement")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Statement file is required"})
+	}
+
+	mapping := utils.CSVColumnMapping{
+		Date:        ctx.FormValue("date_column", "date"),
+		Amount:      ctx.FormValue("amount_column", "amount"),
+		Reference:   ctx.FormValue("reference_column", "reference"),
+		Description: ctx.FormValue("description_column", "description"),
+		DateFormat:  ctx.FormValue("date_format", "2006-01-02"),
+		HasHeader:   ctx.FormValue("has_header", "true") == "true",
+	}
+	if delimiter := ctx.FormValue("delimiter", ""); delimiter != "" {
+		mapping.Delimiter, _ = utf8.DecodeRuneInString(delimiter)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read statement"})
+	}
+	defer file.Close()
+
+	userID, _ := utils.GetUserID(ctx)
+	statement, err := c.reconciliationService.ImportStatement(fileHeader.Filename,
+		ctx.FormValue("format", "csv"), file, mapping, userID)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(statement)
+}
+
+func (c *ReconciliationController) GetImport(ctx fiber.Ctx) error {
+	id := ctx.Params("id")
+	statement, err := c.reconciliationService.GetImport(id)
+	if err != nil {
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Statement import not found"})
+	}
+	return ctx.JSON(statement)
+}
+
+func (c *ReconciliationController) IgnoreLine(ctx fiber.Ctx) error {
+	if err := c.reconciliationService.IgnoreLine(ctx.Params("id"), ctx.Params("lineId")); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Statement line not found"})
+		case errors.Is(err, repositories.ErrLineAlreadyReconciled):
+			return ctx.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.SendStatus(http.StatusOK)
+}
+
+// ConfirmMatches creates payments in bulk for the selected statement lines.
+func (c *ReconciliationController) ConfirmMatches(ctx fiber.Ctx) error {
+	id := ctx.Params("id")
+	var input struct {
+		Matches []services.LineConfirmation `json:"matches"`
+	}
+	if err := ctx.Bind().Body(&input); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID, _ := utils.GetUserID(ctx)
+	payments, err := c.reconciliationService.ConfirmMatches(id, input.Matches, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, services.ErrNoMatchesToConfirm),
+			errors.Is(err, services.ErrLineNotMatched),
+			errors.Is(err, repositories.ErrLineNotCredit):
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, repositories.ErrLineAlreadyReconciled):
+			return ctx.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(payments)
+}
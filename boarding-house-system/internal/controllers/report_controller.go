@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Kimox23/boarding-house-app/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type ReportController struct {
+	reportService *services.ReportService
+}
+
+func NewReportController(reportService *services.ReportService) *ReportController {
+	return &ReportController{reportService: reportService}
+}
+
+// AgingReport returns outstanding balances by age as JSON, CSV or PDF
+// depending on the format query parameter.
+func (c *ReportController) AgingReport(ctx fiber.Ctx) error {
+	report, err := c.reportService.AgingReport(ctx.Query("as_of"), ctx.Query("house_id"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	filename := "aging-" + report.AsOf.Format("2006-01-02")
+	switch ctx.Query("format", "json") {
+	case "csv":
+		data, err := c.reportService.AgingReportCSV(report)
+		if err != nil {
+			return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		return sendAttachment(ctx, filename+".csv", "text/csv", data)
+	case "pdf":
+		return sendAttachment(ctx, filename+".pdf", "application/pdf", c.reportService.AgingReportPDF(report))
+	default:
+		return ctx.JSON(report)
+	}
+}
+
+func sendAttachment(ctx fiber.Ctx, filename, contentType string, data []byte) error {
+	ctx.Set(fiber.HeaderContentType, contentType)
+	ctx.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	return ctx.Send(data)
+}
+
+// ProfitAndLoss returns monthly income, expenses and net result per house as
+// JSON or PDF.
+func (c *ReportController) ProfitAndLoss(ctx fiber.Ctx) error {
+	reports, err := c.reportService.ProfitAndLoss(ctx.Query("house_id"), ctx.Query("from"), ctx.Query("to"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if ctx.Query("format") == "pdf" {
+		return sendAttachment(ctx, "profit-and-loss.pdf", "application/pdf", c.reportService.ProfitAndLossPDF(reports))
+	}
+	return ctx.JSON(reports)
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type ReservationController struct {
+	reservationService *services.ReservationService
+}
+
+func NewReservationController(reservationService *services.ReservationService) *ReservationController {
+	return &ReservationController{reservationService: reservationService}
+}
+
+func (c *ReservationController) CreateReservation(ctx fiber.Ctx) error {
+	var reservation models.Reservation
+	if err := ctx.Bind().Body(&reservation); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	reservation.CreatedBy, _ = utils.GetUserID(ctx)
+
+	if err := c.reservationService.CreateReservation(&reservation); err != nil {
+		return reservationError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(reservation)
+}
+
+func (c *ReservationController) GetReservation(ctx fiber.Ctx) error {
+	reservation, err := c.reservationService.GetReservation(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Reservation not found"})
+	}
+	return ctx.JSON(reservation)
+}
+
+func (c *ReservationController) GetReservationsByHouse(ctx fiber.Ctx) error {
+	reservations, err := c.reservationService.GetReservationsByHouse(ctx.Params("houseId"), ctx.Query("status"))
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(reservations)
+}
+
+func (c *ReservationController) ConfirmReservation(ctx fiber.Ctx) error {
+	reservation, err := c.reservationService.ConfirmReservation(ctx.Params("id"))
+	if err != nil {
+		return reservationError(ctx, err)
+	}
+	return ctx.JSON(reservation)
+}
+
+func (c *ReservationController) CancelReservation(ctx fiber.Ctx) error {
+	reservation, err := c.reservationService.CancelReservation(ctx.Params("id"))
+	if err != nil {
+		return reservationError(ctx, err)
+	}
+	return ctx.JSON(reservation)
+}
+
+func (c *ReservationController) ConvertReservation(ctx fiber.Ctx) error {
+	reservation, err := c.reservationService.ConvertReservation(ctx.Params("id"))
+	if err != nil {
+		return reservationError(ctx, err)
+	}
+	return ctx.JSON(reservation)
+}
+
+func reservationError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Reservation not found"})
+	case errors.Is(err, services.ErrInvalidReservation),
+		errors.Is(err, services.ErrInvalidReservationDates):
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrReservationTransition):
+		return ctx.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
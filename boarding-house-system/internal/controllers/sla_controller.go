@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/services"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type SLAController struct {
+	slaService *services.SLAService
+}
+
+func NewSLAController(slaService *services.SLAService) *SLAController {
+	return &SLAController{slaService: slaService}
+}
+
+// GetPolicies returns the response and resolution targets for a house,
+// including defaults for priorities without an override.
+func (c *SLAController) GetPolicies(ctx fiber.Ctx) error {
+	policies, err := c.slaService.GetPolicies(ctx.Params("houseId"))
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(policies)
+}
+
+func (c *SLAController) SetPolicy(ctx fiber.Ctx) error {
+	var policy models.SLAPolicy
+	if err := ctx.Bind().Body(&policy); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.slaService.SetPolicy(ctx.Params("houseId"), &policy); err != nil {
+		if errors.Is(err, services.ErrInvalidSLAPolicy) {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.JSON(policy)
+}
+
+func (c *SLAController) ResetPolicy(ctx fiber.Ctx) error {
+	if err := c.slaService.ResetPolicy(ctx.Params("houseId"), ctx.Params("priority")); err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// Metrics reports SLA compliance per house and per assignee.
+func (c *SLAController) Metrics(ctx fiber.Ctx) error {
+	report, err := c.slaService.Metrics(analyticsFilter(ctx))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(report)
+}
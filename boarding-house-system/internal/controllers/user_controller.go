@@ -1,21 +1,34 @@
 package controllers
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/Kimox23/boarding-house-app/internal/models"
 	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/storage"
 	"github.com/Kimox23/boarding-house-app/internal/utils"
 
 	"github.com/gofiber/fiber/v3"
 )
 
 type UserController struct {
-	userService *services.UserService
+	userService    *services.UserService
+	store          storage.Storage
+	urls           fileURLs
+	maxPictureSize int64
 }
 
-func NewUserController(userService *services.UserService) *UserController {
-	return &UserController{userService: userService}
+func NewUserController(userService *services.UserService, store storage.Storage, maxPictureSize int64,
+	signedURLTTL time.Duration) *UserController {
+	return &UserController{
+		userService:    userService,
+		store:          store,
+		urls:           fileURLs{store: store, ttl: signedURLTTL},
+		maxPictureSize: maxPictureSize,
+	}
 }
 
 // CreateUser handles user registration
@@ -106,5 +119,46 @@ func (c *UserController) GetProfile(ctx fiber.Ctx) error {
 	if err != nil {
 		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Profile not found"})
 	}
+	profile.ProfilePictureURL = c.urls.sign(profile.ProfilePicture)
 	return ctx.JSON(profile)
 }
+
+// UploadProfilePicture replaces a profile's picture with the "picture"
+// image and removes the old one.
+func (c *UserController) UploadProfilePicture(ctx fiber.Ctx) error {
+	file, err := ctx.FormFile("picture")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Picture file is required"})
+	}
+	if mediaType, ok := utils.MediaTypeForFile(file.Filename); !ok || mediaType != "image" {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Profile picture must be an image"})
+	}
+	if file.Size > c.maxPictureSize {
+		return ctx.Status(http.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "Picture is too large"})
+	}
+
+	filename, err := utils.SaveUploadedFile(c.store, file)
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save picture"})
+	}
+
+	callerID, _ := utils.GetUserID(ctx)
+	previous, err := c.userService.SetProfilePicture(ctx.Params("userId"), callerID, utils.GetUserRole(ctx), filename)
+	if err != nil {
+		// Clean up the uploaded file if database operation fails
+		c.store.Delete(filename)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Profile not found"})
+		case errors.Is(err, services.ErrProfileNotAllowed):
+			return ctx.Status(http.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	if previous != "" && previous != filename {
+		c.store.Delete(previous)
+	}
+
+	return ctx.JSON(fiber.Map{"profile_picture": filename, "profile_picture_url": c.urls.sign(filename)})
+}
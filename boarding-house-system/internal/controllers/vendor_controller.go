@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/storage"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type VendorController struct {
+	vendorService *services.VendorService
+	store         storage.Storage
+	urls          fileURLs
+}
+
+func NewVendorController(vendorService *services.VendorService, store storage.Storage,
+	signedURLTTL time.Duration) *VendorController {
+	return &VendorController{
+		vendorService: vendorService,
+		store:         store,
+		urls:          fileURLs{store: store, ttl: signedURLTTL},
+	}
+}
+
+func (c *VendorController) CreateVendor(ctx fiber.Ctx) error {
+	var vendor models.Vendor
+	if err := ctx.Bind().Body(&vendor); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.vendorService.CreateVendor(&vendor); err != nil {
+		return vendorError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(vendor)
+}
+
+func (c *VendorController) GetVendor(ctx fiber.Ctx) error {
+	vendor, err := c.vendorService.GetVendor(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Vendor not found"})
+	}
+	for i := range vendor.Documents {
+		vendor.Documents[i].URL = c.urls.sign(vendor.Documents[i].FilePath)
+	}
+	return ctx.JSON(vendor)
+}
+
+func (c *VendorController) GetVendors(ctx fiber.Ctx) error {
+	vendors, err := c.vendorService.GetVendors(ctx.Query("trade"))
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(vendors)
+}
+
+func (c *VendorController) UpdateVendor(ctx fiber.Ctx) error {
+	var vendor models.Vendor
+	if err := ctx.Bind().Body(&vendor); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.vendorService.UpdateVendor(ctx.Params("id"), &vendor); err != nil {
+		return vendorError(ctx, err)
+	}
+
+	return ctx.JSON(vendor)
+}
+
+// UploadDocument stores an insurance certificate, licence or other document
+// for a vendor, with an optional "expires_on" date (YYYY-MM-DD).
+func (c *VendorController) UploadDocument(ctx fiber.Ctx) error {
+	file, err := ctx.FormFile("document")
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Document file is required"})
+	}
+
+	document := models.VendorDocument{DocumentType: ctx.FormValue("document_type")}
+	if expires := ctx.FormValue("expires_on"); expires != "" {
+		date, err := time.ParseInLocation("2006-01-02", expires, time.Local)
+		if err != nil {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid expiry date"})
+		}
+		document.ExpiresOn = &date
+	}
+
+	filename, err := utils.SaveUploadedFile(c.store, file)
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save document"})
+	}
+	document.FilePath = filename
+
+	if err := c.vendorService.AddDocument(ctx.Params("id"), &document); err != nil {
+		// Clean up the uploaded file if database operation fails
+		c.store.Delete(filename)
+		return vendorError(ctx, err)
+	}
+
+	document.URL = c.urls.sign(document.FilePath)
+	return ctx.Status(http.StatusCreated).JSON(document)
+}
+
+func (c *VendorController) AssignVendor(ctx fiber.Ctx) error {
+	var input struct {
+		VendorID *int `json:"vendor_id"`
+	}
+	if err := ctx.Bind().Body(&input); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	actorID, _ := utils.GetUserID(ctx)
+	if err := c.vendorService.AssignVendor(ctx.Params("requestId"), input.VendorID, actorID); err != nil {
+		return vendorError(ctx, err)
+	}
+
+	return ctx.SendStatus(http.StatusOK)
+}
+
+func (c *VendorController) SubmitQuote(ctx fiber.Ctx) error {
+	var quote models.VendorQuote
+	if err := ctx.Bind().Body(&quote); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.vendorService.SubmitQuote(ctx.Params("requestId"), &quote); err != nil {
+		return vendorError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(quote)
+}
+
+func (c *VendorController) GetQuotes(ctx fiber.Ctx) error {
+	quotes, err := c.vendorService.GetQuotes(ctx.Params("requestId"))
+	if err != nil {
+		return vendorError(ctx, err)
+	}
+	return ctx.JSON(quotes)
+}
+
+func (c *VendorController) ApproveQuote(ctx fiber.Ctx) error {
+	actorID, _ := utils.GetUserID(ctx)
+	quote, err := c.vendorService.ApproveQuote(ctx.Params("quoteId"), actorID)
+	if err != nil {
+		return vendorError(ctx, err)
+	}
+	return ctx.JSON(quote)
+}
+
+func (c *VendorController) RejectQuote(ctx fiber.Ctx) error {
+	actorID, _ := utils.GetUserID(ctx)
+	quote, err := c.vendorService.RejectQuote(ctx.Params("quoteId"), actorID)
+	if err != nil {
+		return vendorError(ctx, err)
+	}
+	return ctx.JSON(quote)
+}
+
+func (c *VendorController) RecordInvoice(ctx fiber.Ctx) error {
+	var invoice models.VendorInvoice
+	if err := ctx.Bind().Body(&invoice); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.vendorService.RecordInvoice(ctx.Params("requestId"), &invoice); err != nil {
+		return vendorError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(invoice)
+}
+
+func (c *VendorController) GetInvoices(ctx fiber.Ctx) error {
+	invoices, err := c.vendorService.GetInvoices(ctx.Params("requestId"))
+	if err != nil {
+		return vendorError(ctx, err)
+	}
+	return ctx.JSON(invoices)
+}
+
+func (c *VendorController) MarkInvoicePaid(ctx fiber.Ctx) error {
+	if err := c.vendorService.MarkInvoicePaid(ctx.Params("invoiceId")); err != nil {
+		return vendorError(ctx, err)
+	}
+	return ctx.SendStatus(http.StatusOK)
+}
+
+// Performance summarises every vendor, or one vendor when an ID is given.
+func (c *VendorController) Performance(ctx fiber.Ctx) error {
+	performance, err := c.vendorService.Performance(ctx.Params("id"), analyticsFilter(ctx))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(performance)
+}
+
+func vendorError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Not found"})
+	case errors.Is(err, services.ErrInvalidVendor),
+		errors.Is(err, services.ErrInvalidVendorDocument),
+		errors.Is(err, services.ErrInvalidQuoteAmount),
+		errors.Is(err, services.ErrInvalidVendorInvoice),
+		errors.Is(err, services.ErrQuoteNotForRequest),
+		errors.Is(err, services.ErrNoVendorAssigned):
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrVendorInactive),
+		errors.Is(err, services.ErrVendorComplianceExpired),
+		errors.Is(err, repositories.ErrQuoteAlreadyDecided),
+		errors.Is(err, repositories.ErrInvoiceAlreadyPaid):
+		return ctx.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type WebhookController struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookController(webhookService *services.WebhookService) *WebhookController {
+	return &WebhookController{webhookService: webhookService}
+}
+
+// CreateWebhook registers an endpoint. The response carries the signing
+// secret, which is not shown again.
+func (c *WebhookController) CreateWebhook(ctx fiber.Ctx) error {
+	var webhook models.Webhook
+	if err := ctx.Bind().Body(&webhook); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID, _ := utils.GetUserID(ctx)
+	if err := c.webhookService.CreateWebhook(&webhook, userID); err != nil {
+		return webhookError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(webhook)
+}
+
+func (c *WebhookController) GetWebhooks(ctx fiber.Ctx) error {
+	webhooks, err := c.webhookService.GetWebhooks()
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.JSON(webhooks)
+}
+
+func (c *WebhookController) GetWebhook(ctx fiber.Ctx) error {
+	webhook, err := c.webhookService.GetWebhook(ctx.Params("id"))
+	if err != nil {
+		return webhookError(ctx, err)
+	}
+	return ctx.JSON(webhook)
+}
+
+func (c *WebhookController) UpdateWebhook(ctx fiber.Ctx) error {
+	var webhook models.Webhook
+	if err := ctx.Bind().Body(&webhook); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := c.webhookService.UpdateWebhook(ctx.Params("id"), &webhook); err != nil {
+		return webhookError(ctx, err)
+	}
+
+	updated, err := c.webhookService.GetWebhook(ctx.Params("id"))
+	if err != nil {
+		return webhookError(ctx, err)
+	}
+	return ctx.JSON(updated)
+}
+
+func (c *WebhookController) DeleteWebhook(ctx fiber.Ctx) error {
+	if err := c.webhookService.DeleteWebhook(ctx.Params("id")); err != nil {
+		return webhookError(ctx, err)
+	}
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// GetDeliveries lists a webhook's deliveries with their response codes,
+// newest first, up to the limit query parameter.
+func (c *WebhookController) GetDeliveries(ctx fiber.Ctx) error {
+	deliveries, err := c.webhookService.GetDeliveries(ctx.Params("id"), ctx.Query("limit"))
+	if err != nil {
+		return webhookError(ctx, err)
+	}
+	return ctx.JSON(deliveries)
+}
+
+// Redeliver queues an earlier delivery to be sent again.
+func (c *WebhookController) Redeliver(ctx fiber.Ctx) error {
+	delivery, err := c.webhookService.Redeliver(ctx.Params("id"), ctx.Params("deliveryId"))
+	if err != nil {
+		return webhookError(ctx, err)
+	}
+	return ctx.Status(http.StatusAccepted).JSON(delivery)
+}
+
+func webhookError(ctx fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Webhook not found"})
+	case errors.Is(err, services.ErrInvalidWebhookURL),
+		errors.Is(err, services.ErrInvalidEventType):
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
@@ -0,0 +1,42 @@
+package events
+
+import "sync"
+
+// Handler reacts to one event. Returning an error makes the relay retry
+// the event for this subscriber later, so handlers must be idempotent.
+type Handler func(Envelope) error
+
+// Subscriber is a named handler. The name identifies the subscriber in the
+// outbox so that each one is retried independently of the others.
+type Subscriber struct {
+	Name    string
+	Handler Handler
+}
+
+// Bus routes events to their subscribers.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[string][]Subscriber
+}
+
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[string][]Subscriber)}
+}
+
+// Subscribe registers a handler for an event type, or for every event type
+// with All. Subscriber names must be unique per event type.
+func (b *Bus) Subscribe(eventType, name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[eventType] = append(b.subscribers[eventType], Subscriber{Name: name, Handler: handler})
+}
+
+// Subscribers returns the subscribers of an event type, including those
+// subscribed to every type.
+func (b *Bus) Subscribers(eventType string) []Subscriber {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	subs := make([]Subscriber, 0, len(b.subscribers[eventType])+len(b.subscribers[All]))
+	subs = append(subs, b.subscribers[eventType]...)
+	return append(subs, b.subscribers[All]...)
+}
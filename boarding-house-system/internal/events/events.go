@@ -0,0 +1,132 @@
+// Package events defines the domain events services publish and the bus
+// subscribers use to react to them. Events are written to the outbox table
+// in the same transaction as the change they describe and delivered to
+// subscribers asynchronously, so they survive a crash after the commit.
+package events
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types. Subscribers may also subscribe to All.
+const (
+	All                          = "*"
+	TypePaymentRecorded          = "payment.recorded"
+	TypePaymentReversed          = "payment.reversed"
+	TypeDocumentVerified         = "document.verified"
+	TypeMaintenanceStatusChanged = "maintenance.status_changed"
+	TypeTenantCheckedIn          = "tenant.checked_in"
+	TypeAnnouncementPublished    = "announcement.published"
+)
+
+// Types lists every event type that is published.
+var Types = []string{
+	TypePaymentRecorded,
+	TypePaymentReversed,
+	TypeDocumentVerified,
+	TypeMaintenanceStatusChanged,
+	TypeTenantCheckedIn,
+	TypeAnnouncementPublished,
+}
+
+// Event is a domain event. Subject names the entity it is about, such as
+// ("payment", 42).
+type Event interface {
+	EventType() string
+	Subject() (string, int)
+}
+
+type PaymentRecorded struct {
+	PaymentID       int       `json:"payment_id"`
+	TenantID        int       `json:"tenant_id"`
+	Amount          float64   `json:"amount"`
+	PaymentMethod   string    `json:"payment_method"`
+	PaymentForMonth time.Time `json:"payment_for_month"`
+	ReceiptNumber   string    `json:"receipt_number"`
+	RecordedBy      int       `json:"recorded_by"`
+}
+
+func (PaymentRecorded) EventType() string        { return TypePaymentRecorded }
+func (e PaymentRecorded) Subject() (string, int) { return "payment", e.PaymentID }
+
+// PaymentReversed is published for a void or refund entry. PaymentID is the
+// reversal entry and Amount the positive amount taken back.
+type PaymentReversed struct {
+	PaymentID     int     `json:"payment_id"`
+	ReversalOf    int     `json:"reversal_of"`
+	TenantID      int     `json:"tenant_id"`
+	EntryType     string  `json:"entry_type"`
+	Amount        float64 `json:"amount"`
+	ReceiptNumber string  `json:"receipt_number"`
+	Reason        string  `json:"reason"`
+	RecordedBy    int     `json:"recorded_by"`
+}
+
+func (PaymentReversed) EventType() string        { return TypePaymentReversed }
+func (e PaymentReversed) Subject() (string, int) { return "payment", e.PaymentID }
+
+type DocumentVerified struct {
+	DocumentID   int    `json:"document_id"`
+	TenantID     int    `json:"tenant_id"`
+	DocumentType string `json:"document_type"`
+	Verified     bool   `json:"verified"`
+	Notes        string `json:"notes"`
+	VerifiedBy   int    `json:"verified_by"`
+}
+
+func (DocumentVerified) EventType() string        { return TypeDocumentVerified }
+func (e DocumentVerified) Subject() (string, int) { return "document", e.DocumentID }
+
+type MaintenanceStatusChanged struct {
+	RequestID  int    `json:"request_id"`
+	RoomID     int    `json:"room_id"`
+	ReportedBy int    `json:"reported_by"`
+	AssignedTo *int   `json:"assigned_to"`
+	IssueType  string `json:"issue_type"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	ActorID    int    `json:"actor_id"`
+	Note       string `json:"note,omitempty"`
+}
+
+func (MaintenanceStatusChanged) EventType() string        { return TypeMaintenanceStatusChanged }
+func (e MaintenanceStatusChanged) Subject() (string, int) { return "maintenance_request", e.RequestID }
+
+type TenantCheckedIn struct {
+	TenantID     int        `json:"tenant_id"`
+	UserID       int        `json:"user_id"`
+	RoomID       int        `json:"room_id"`
+	MoveInDate   time.Time  `json:"move_in_date"`
+	LeaseEndDate *time.Time `json:"lease_end_date"`
+}
+
+func (TenantCheckedIn) EventType() string        { return TypeTenantCheckedIn }
+func (e TenantCheckedIn) Subject() (string, int) { return "tenant", e.TenantID }
+
+type AnnouncementPublished struct {
+	AnnouncementID int        `json:"announcement_id"`
+	Title          string     `json:"title"`
+	Audience       string     `json:"audience"`
+	HouseID        *int       `json:"house_id"`
+	Recipients     int        `json:"recipients"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+}
+
+func (AnnouncementPublished) EventType() string        { return TypeAnnouncementPublished }
+func (e AnnouncementPublished) Subject() (string, int) { return "announcement", e.AnnouncementID }
+
+// Envelope is an event as stored in the outbox and handed to subscribers.
+type Envelope struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	SubjectType string          `json:"subject_type"`
+	SubjectID   int             `json:"subject_id"`
+	Payload     json.RawMessage `json:"payload"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+}
+
+// Decode unmarshals the payload into the typed event.
+func (e Envelope) Decode(event Event) error {
+	return json.Unmarshal(e.Payload, event)
+}
@@ -49,6 +49,33 @@ func AuthRequired(cfg *config.Config) fiber.Handler {
 	}
 }
 
+// StreamAuth authenticates the event stream with a stream token in the
+// "token" query parameter, for browsers' EventSource, or like AuthRequired
+// with the Authorization header.
+func StreamAuth(cfg *config.Config) fiber.Handler {
+	authRequired := AuthRequired(cfg)
+	return func(ctx fiber.Ctx) error {
+		tokenString := ctx.Query("token")
+		if tokenString == "" {
+			return authRequired(ctx)
+		}
+
+		claims, err := config.VerifyStreamToken(tokenString, cfg.JWTSecret)
+		if err != nil {
+			log.Printf("Stream token verification failed: %v", err)
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   "Invalid token",
+				"details": err.Error(),
+			})
+		}
+
+		ctx.Locals("userID", claims.UserID)
+		ctx.Locals("userRole", claims.Role)
+
+		return ctx.Next()
+	}
+}
+
 func RoleRequired(requiredRole string, cfg *config.Config) fiber.Handler {
 	return func(ctx fiber.Ctx) error {
 		userRole, ok := ctx.Locals("userRole").(string)
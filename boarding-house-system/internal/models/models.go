@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type User struct {
 	ID        int       `json:"id"`
@@ -27,6 +30,7 @@ type UserProfile struct {
 	EmergencyContactName  string `json:"emergency_contact_name"`
 	EmergencyContactPhone string `json:"emergency_contact_phone"`
 	ProfilePicture        string `json:"profile_picture"`
+	ProfilePictureURL     string `json:"profile_picture_url,omitempty"`
 }
 
 type BoardingHouse struct {
@@ -61,6 +65,7 @@ type Tenant struct {
 	RoomID           int        `json:"room_id"`
 	MoveInDate       time.Time  `json:"move_in_date"`
 	MoveOutDate      *time.Time `json:"move_out_date"`
+	LeaseEndDate     *time.Time `json:"lease_end_date"`
 	DepositAmount    float64    `json:"deposit_amount"`
 	DepositPaid      bool       `json:"deposit_paid"`
 	ContractDocument string     `json:"contract_document"`
@@ -78,6 +83,11 @@ type Payment struct {
 	Status          string    `json:"status"`
 	Notes           string    `json:"notes"`
 	RecordedBy      int       `json:"recorded_by"`
+	EntryType       string    `json:"entry_type"`
+	ReversalOf      *int      `json:"reversal_of"`
+	Reason          string    `json:"reason"`
+	CreatedAt       time.Time `json:"created_at"`
+	Reversals       []Payment `json:"reversals,omitempty"`
 }
 
 type MaintenanceRequest struct {
@@ -89,11 +99,364 @@ type MaintenanceRequest struct {
 	Priority      string     `json:"priority"`
 	Status        string     `json:"status"`
 	ReportedDate  time.Time  `json:"reported_date"`
+	StartedDate   *time.Time `json:"started_date"`
 	CompletedDate *time.Time `json:"completed_date"`
+	CancelledDate *time.Time `json:"cancelled_date"`
 	AssignedTo    *int       `json:"assigned_to"`
+	VendorID      *int       `json:"vendor_id"`
+	AssetID       *int       `json:"asset_id"`
 	Cost          *float64   `json:"cost"`
 }
 
+type MaintenanceEvent struct {
+	ID         int       `json:"id"`
+	RequestID  int       `json:"request_id"`
+	EventType  string    `json:"event_type"`
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status,omitempty"`
+	ActorID    *int      `json:"actor_id"`
+	Note       string    `json:"note,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type MaintenanceComment struct {
+	ID          int                     `json:"id"`
+	RequestID   int                     `json:"request_id"`
+	ParentID    *int                    `json:"parent_id"`
+	AuthorID    int                     `json:"author_id"`
+	AuthorName  string                  `json:"author_name"`
+	AuthorRole  string                  `json:"author_role"`
+	Body        string                  `json:"body"`
+	IsInternal  bool                    `json:"is_internal"`
+	CreatedAt   time.Time               `json:"created_at"`
+	Attachments []MaintenanceAttachment `json:"attachments"`
+	Replies     []MaintenanceComment    `json:"replies"`
+}
+
+// MaintenanceAttachment is a file on a comment. URL is a short-lived
+// download link, filled in for callers who may see the comment.
+type MaintenanceAttachment struct {
+	ID           int       `json:"id"`
+	CommentID    int       `json:"comment_id"`
+	FilePath     string    `json:"file_path"`
+	URL          string    `json:"url,omitempty"`
+	OriginalName string    `json:"original_name"`
+	MediaType    string    `json:"media_type"`
+	FileSize     int64     `json:"file_size"`
+	UploadedAt   time.Time `json:"uploaded_at"`
+}
+
+type MaintenanceRating struct {
+	ID            int       `json:"id"`
+	RequestID     int       `json:"request_id"`
+	RatedBy       int       `json:"rated_by"`
+	Score         int       `json:"score"`
+	Feedback      string    `json:"feedback"`
+	Reopened      bool      `json:"reopened"`
+	CompletedDate time.Time `json:"completed_date"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type SatisfactionScore struct {
+	Key          string  `json:"key"`
+	Name         string  `json:"name"`
+	Ratings      int     `json:"ratings"`
+	AverageScore float64 `json:"average_score"`
+	Reopened     int     `json:"reopened"`
+	ReopenRate   float64 `json:"reopen_rate"`
+}
+
+type SatisfactionReport struct {
+	From        string              `json:"from"`
+	To          string              `json:"to"`
+	Overall     SatisfactionScore   `json:"overall"`
+	ByHouse     []SatisfactionScore `json:"by_house"`
+	ByIssueType []SatisfactionScore `json:"by_issue_type"`
+	ByAssignee  []SatisfactionScore `json:"by_assignee"`
+}
+
+type SLAPolicy struct {
+	HouseID           int    `json:"house_id"`
+	Priority          string `json:"priority"`
+	ResponseMinutes   int    `json:"response_minutes"`
+	ResolutionMinutes int    `json:"resolution_minutes"`
+	IsDefault         bool   `json:"is_default"`
+}
+
+type SLAMetrics struct {
+	ID                     int     `json:"id"`
+	Name                   string  `json:"name"`
+	Requests               int     `json:"requests"`
+	ResponseMet            int     `json:"response_met"`
+	ResponseBreached       int     `json:"response_breached"`
+	ResolutionMet          int     `json:"resolution_met"`
+	ResolutionBreached     int     `json:"resolution_breached"`
+	ResponseCompliance     float64 `json:"response_compliance"`
+	ResolutionCompliance   float64 `json:"resolution_compliance"`
+	AverageResponseHours   float64 `json:"average_response_hours"`
+	AverageResolutionHours float64 `json:"average_resolution_hours"`
+}
+
+type SLAReport struct {
+	From       string       `json:"from"`
+	To         string       `json:"to"`
+	ByHouse    []SLAMetrics `json:"by_house"`
+	ByAssignee []SLAMetrics `json:"by_assignee"`
+}
+
+type StaffProfile struct {
+	UserID          int       `json:"user_id"`
+	Username        string    `json:"username"`
+	HouseID         *int      `json:"house_id"`
+	Skills          []string  `json:"skills"`
+	OnShift         bool      `json:"on_shift"`
+	MaxOpenRequests int       `json:"max_open_requests"`
+	OpenRequests    int       `json:"open_requests"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type AssignmentRule struct {
+	ID            int       `json:"id"`
+	Name          string    `json:"name"`
+	HouseID       *int      `json:"house_id"`
+	IssueType     string    `json:"issue_type"`
+	RequiredSkill string    `json:"required_skill"`
+	OnShiftOnly   bool      `json:"on_shift_only"`
+	RuleOrder     int       `json:"rule_order"`
+	IsActive      bool      `json:"is_active"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type QueueItem struct {
+	Request       MaintenanceRequest `json:"request"`
+	HouseID       int                `json:"house_id"`
+	ResponseDue   time.Time          `json:"response_due"`
+	ResolutionDue time.Time          `json:"resolution_due"`
+	NextDeadline  time.Time          `json:"next_deadline"`
+	Overdue       bool               `json:"overdue"`
+}
+
+type Vendor struct {
+	ID          int              `json:"id"`
+	Name        string           `json:"name"`
+	Trade       string           `json:"trade"`
+	ContactName string           `json:"contact_name"`
+	Email       string           `json:"email"`
+	Phone       string           `json:"phone"`
+	HourlyRate  float64          `json:"hourly_rate"`
+	CalloutFee  float64          `json:"callout_fee"`
+	Notes       string           `json:"notes"`
+	IsActive    bool             `json:"is_active"`
+	CreatedAt   time.Time        `json:"created_at"`
+	Documents   []VendorDocument `json:"documents,omitempty"`
+}
+
+type VendorDocument struct {
+	ID           int        `json:"id"`
+	VendorID     int        `json:"vendor_id"`
+	DocumentType string     `json:"document_type"`
+	FilePath     string     `json:"file_path"`
+	URL          string     `json:"url,omitempty"`
+	ExpiresOn    *time.Time `json:"expires_on"`
+	UploadedAt   time.Time  `json:"uploaded_at"`
+}
+
+type VendorQuote struct {
+	ID          int        `json:"id"`
+	RequestID   int        `json:"request_id"`
+	VendorID    int        `json:"vendor_id"`
+	Amount      float64    `json:"amount"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	SubmittedAt time.Time  `json:"submitted_at"`
+	DecidedBy   *int       `json:"decided_by"`
+	DecidedAt   *time.Time `json:"decided_at"`
+}
+
+type VendorInvoice struct {
+	ID            int        `json:"id"`
+	RequestID     int        `json:"request_id"`
+	VendorID      int        `json:"vendor_id"`
+	QuoteID       *int       `json:"quote_id"`
+	InvoiceNumber string     `json:"invoice_number"`
+	Amount        float64    `json:"amount"`
+	InvoiceDate   time.Time  `json:"invoice_date"`
+	FilePath      string     `json:"file_path"`
+	Status        string     `json:"status"`
+	PaidAt        *time.Time `json:"paid_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type VendorPerformance struct {
+	VendorID               int     `json:"vendor_id"`
+	VendorName             string  `json:"vendor_name"`
+	Trade                  string  `json:"trade"`
+	JobsAssigned           int     `json:"jobs_assigned"`
+	JobsCompleted          int     `json:"jobs_completed"`
+	AverageResolutionHours float64 `json:"average_resolution_hours"`
+	QuotesSubmitted        int     `json:"quotes_submitted"`
+	QuotesApproved         int     `json:"quotes_approved"`
+	TotalQuoted            float64 `json:"total_quoted"`
+	TotalInvoiced          float64 `json:"total_invoiced"`
+	InvoiceVariance        float64 `json:"invoice_variance"`
+}
+
+type MaintenancePlan struct {
+	ID             int       `json:"id"`
+	HouseID        int       `json:"house_id"`
+	RoomID         *int      `json:"room_id"`
+	AssetID        *int      `json:"asset_id"`
+	RoomType       string    `json:"room_type"`
+	Title          string    `json:"title"`
+	Description    string    `json:"description"`
+	IssueType      string    `json:"issue_type"`
+	Priority       string    `json:"priority"`
+	IntervalMonths int       `json:"interval_months"`
+	LeadDays       int       `json:"lead_days"`
+	NextDueDate    time.Time `json:"next_due_date"`
+	AssignedTo     *int      `json:"assigned_to"`
+	IsActive       bool      `json:"is_active"`
+	CreatedBy      int       `json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type PlanOccurrence struct {
+	PlanID     int       `json:"plan_id"`
+	Title      string    `json:"title"`
+	HouseID    int       `json:"house_id"`
+	DueDate    time.Time `json:"due_date"`
+	Generated  bool      `json:"generated"`
+	RequestIDs []int     `json:"request_ids"`
+}
+
+type PlanRun struct {
+	ID            int        `json:"id"`
+	PlanID        int        `json:"plan_id"`
+	PlanTitle     string     `json:"plan_title"`
+	RoomID        int        `json:"room_id"`
+	RoomNumber    string     `json:"room_number"`
+	AssetID       *int       `json:"asset_id"`
+	DueDate       time.Time  `json:"due_date"`
+	RequestID     int        `json:"request_id"`
+	Status        string     `json:"status"`
+	CompletedDate *time.Time `json:"completed_date"`
+	GeneratedAt   time.Time  `json:"generated_at"`
+}
+
+type Asset struct {
+	ID                 int        `json:"id"`
+	RoomID             int        `json:"room_id"`
+	RoomNumber         string     `json:"room_number"`
+	Category           string     `json:"category"`
+	Name               string     `json:"name"`
+	SerialNumber       string     `json:"serial_number"`
+	Condition          string     `json:"condition"`
+	PurchaseDate       *time.Time `json:"purchase_date"`
+	PurchaseCost       *float64   `json:"purchase_cost"`
+	ExpectedLifeMonths *int       `json:"expected_life_months"`
+	Notes              string     `json:"notes"`
+	IsActive           bool       `json:"is_active"`
+	DisposedDate       *time.Time `json:"disposed_date"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+type AssetRepairSummary struct {
+	Asset          Asset      `json:"asset"`
+	RepairCount    int        `json:"repair_count"`
+	RepairCost     float64    `json:"repair_cost"`
+	LastRepairDate *time.Time `json:"last_repair_date"`
+}
+
+type AssetRepairHistory struct {
+	Asset       Asset                `json:"asset"`
+	RepairCount int                  `json:"repair_count"`
+	RepairCost  float64              `json:"repair_cost"`
+	Requests    []MaintenanceRequest `json:"requests"`
+}
+
+type ReplacementCandidate struct {
+	AssetRepairSummary
+	AgeMonths int      `json:"age_months"`
+	Reasons   []string `json:"reasons"`
+}
+
+type AssetReport struct {
+	HouseID     int                    `json:"house_id"`
+	AssetCount  int                    `json:"asset_count"`
+	TotalValue  float64                `json:"total_value"`
+	RepairCost  float64                `json:"repair_cost"`
+	ByCondition map[string]int         `json:"by_condition"`
+	Assets      []AssetRepairSummary   `json:"assets"`
+	Candidates  []ReplacementCandidate `json:"replacement_candidates"`
+}
+
+type ChecklistItem struct {
+	ID        int    `json:"id"`
+	RoomType  string `json:"room_type"`
+	Area      string `json:"area"`
+	Label     string `json:"label"`
+	SortOrder int    `json:"sort_order"`
+	IsActive  bool   `json:"is_active"`
+}
+
+type Inspection struct {
+	ID             int              `json:"id"`
+	TenantID       int              `json:"tenant_id"`
+	RoomID         int              `json:"room_id"`
+	InspectionType string           `json:"inspection_type"`
+	Status         string           `json:"status"`
+	InspectedBy    int              `json:"inspected_by"`
+	Notes          string           `json:"notes"`
+	CreatedAt      time.Time        `json:"created_at"`
+	CompletedAt    *time.Time       `json:"completed_at"`
+	AcknowledgedAt *time.Time       `json:"acknowledged_at"`
+	TenantComment  string           `json:"tenant_comment"`
+	Items          []InspectionItem `json:"items,omitempty"`
+}
+
+type InspectionItem struct {
+	ID              int               `json:"id"`
+	InspectionID    int               `json:"inspection_id"`
+	ChecklistItemID *int              `json:"checklist_item_id"`
+	Area            string            `json:"area"`
+	Label           string            `json:"label"`
+	Condition       string            `json:"condition"`
+	Notes           string            `json:"notes"`
+	EstimatedCost   *float64          `json:"estimated_cost"`
+	Photos          []InspectionPhoto `json:"photos"`
+}
+
+type InspectionPhoto struct {
+	ID               int       `json:"id"`
+	InspectionItemID int       `json:"inspection_item_id"`
+	FilePath         string    `json:"file_path"`
+	URL              string    `json:"url,omitempty"`
+	OriginalName     string    `json:"original_name"`
+	UploadedAt       time.Time `json:"uploaded_at"`
+}
+
+type InspectionItemDiff struct {
+	Area             string            `json:"area"`
+	Label            string            `json:"label"`
+	MoveInCondition  string            `json:"move_in_condition"`
+	MoveOutCondition string            `json:"move_out_condition"`
+	Deteriorated     bool              `json:"deteriorated"`
+	MoveOutNotes     string            `json:"move_out_notes"`
+	EstimatedCost    float64           `json:"estimated_cost"`
+	MoveInPhotos     []InspectionPhoto `json:"move_in_photos"`
+	MoveOutPhotos    []InspectionPhoto `json:"move_out_photos"`
+}
+
+type InspectionDiff struct {
+	TenantID           int                  `json:"tenant_id"`
+	MoveInID           int                  `json:"move_in_id"`
+	MoveOutID          int                  `json:"move_out_id"`
+	Items              []InspectionItemDiff `json:"items"`
+	DepositAmount      float64              `json:"deposit_amount"`
+	SuggestedDeduction float64              `json:"suggested_deduction"`
+	RefundableDeposit  float64              `json:"refundable_deposit"`
+}
+
 type Notification struct {
 	ID        int       `json:"id"`
 	UserID    int       `json:"user_id"`
@@ -102,6 +465,170 @@ type Notification struct {
 	IsRead    bool      `json:"is_read"`
 	CreatedAt time.Time `json:"created_at"`
 	Link      string    `json:"link"`
+	Category  string    `json:"category"`
+}
+
+// NotificationPreferences controls which channels besides the in-app inbox
+// a user is notified through and the language notifications are written in.
+// Quiet hours are HH:MM in server time; email and SMS due inside them wait
+// until they end.
+type NotificationPreferences struct {
+	UserID          int       `json:"user_id"`
+	EmailEnabled    bool      `json:"email_enabled"`
+	SMSEnabled      bool      `json:"sms_enabled"`
+	Locale          string    `json:"locale"`
+	QuietHoursStart string    `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   string    `json:"quiet_hours_end,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at,omitempty"`
+}
+
+// NotificationDelivery tracks one notification on one channel.
+type NotificationDelivery struct {
+	ID             int        `json:"id"`
+	NotificationID int        `json:"notification_id"`
+	Channel        string     `json:"channel"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// NotificationTemplate is one language variant of a named notification.
+// Title, message and link are Go text/template strings rendered with the
+// template's variables, e.g. {{.amount}}. Built-in variants have no ID.
+type NotificationTemplate struct {
+	ID        int       `json:"id,omitempty"`
+	Name      string    `json:"name"`
+	Locale    string    `json:"locale"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	Link      string    `json:"link"`
+	Variables []string  `json:"variables,omitempty"`
+	BuiltIn   bool      `json:"built_in"`
+	UpdatedBy *int      `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// AuditEntry is a domain event recorded in the audit log.
+type AuditEntry struct {
+	ID          int64           `json:"id"`
+	EventID     int64           `json:"event_id"`
+	EventType   string          `json:"event_type"`
+	SubjectType string          `json:"subject_type"`
+	SubjectID   int             `json:"subject_id"`
+	Payload     json.RawMessage `json:"payload"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	RecordedAt  time.Time       `json:"recorded_at"`
+}
+
+// Webhook is an integrator endpoint that receives signed event payloads.
+// EventTypes may contain "*" for every event. The secret is only returned
+// when the webhook is created.
+type Webhook struct {
+	ID                  int       `json:"id"`
+	URL                 string    `json:"url"`
+	Secret              string    `json:"secret,omitempty"`
+	EventTypes          []string  `json:"event_types"`
+	Description         string    `json:"description"`
+	IsActive            bool      `json:"is_active"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	DisabledReason      string    `json:"disabled_reason,omitempty"`
+	CreatedBy           *int      `json:"created_by"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery is one event sent, or queued to be sent, to a webhook.
+type WebhookDelivery struct {
+	ID            int64           `json:"id"`
+	WebhookID     int             `json:"webhook_id"`
+	EventID       int64           `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	Redelivery    int             `json:"redelivery"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	ResponseCode  *int            `json:"response_code"`
+	ResponseBody  string          `json:"response_body,omitempty"`
+	LastError     string          `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at,omitempty"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// Announcement is a message broadcast to an audience: the tenants of a
+// house ("house"), the tenants of some rooms ("rooms"), all staff ("staff")
+// or every user ("everyone"). It is published at PublishAt and hidden after
+// ExpiresAt. Recipients and ReadCount are only filled in for admins, ReadAt
+// only for recipients.
+type Announcement struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	Audience    string     `json:"audience"`
+	HouseID     *int       `json:"house_id"`
+	RoomIDs     []int      `json:"room_ids,omitempty"`
+	IsPinned    bool       `json:"is_pinned"`
+	PublishAt   time.Time  `json:"publish_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedBy   *int       `json:"created_by"`
+	Recipients  int        `json:"recipients,omitempty"`
+	ReadCount   int        `json:"read_count,omitempty"`
+	ReadAt      *time.Time `json:"read_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// AnnouncementReceipt records whether a recipient has read an announcement.
+type AnnouncementReceipt struct {
+	UserID   int        `json:"user_id"`
+	Username string     `json:"username"`
+	ReadAt   *time.Time `json:"read_at"`
+}
+
+// Conversation is a message thread between a tenant and the staff of their
+// house. Any staff member can reply. UnreadCount counts the messages the
+// viewing side has not read.
+type Conversation struct {
+	ID            int       `json:"id"`
+	HouseID       int       `json:"house_id"`
+	HouseName     string    `json:"house_name"`
+	TenantUserID  int       `json:"tenant_user_id"`
+	TenantName    string    `json:"tenant_name"`
+	Subject       string    `json:"subject"`
+	Status        string    `json:"status"`
+	StartedBy     int       `json:"started_by"`
+	LastMessageAt time.Time `json:"last_message_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UnreadCount   int       `json:"unread_count"`
+	Messages      []Message `json:"messages,omitempty"`
+}
+
+// Message is one message in a conversation. ReadAt is when the other side
+// first read it.
+type Message struct {
+	ID             int                 `json:"id"`
+	ConversationID int                 `json:"conversation_id"`
+	SenderID       int                 `json:"sender_id"`
+	SenderName     string              `json:"sender_name"`
+	SenderRole     string              `json:"sender_role"`
+	Body           string              `json:"body"`
+	ReadAt         *time.Time          `json:"read_at"`
+	CreatedAt      time.Time           `json:"created_at"`
+	Attachments    []MessageAttachment `json:"attachments"`
+}
+
+type MessageAttachment struct {
+	ID           int       `json:"id"`
+	MessageID    int       `json:"message_id"`
+	FilePath     string    `json:"file_path"`
+	URL          string    `json:"url,omitempty"`
+	OriginalName string    `json:"original_name"`
+	FileSize     int64     `json:"file_size"`
+	UploadedAt   time.Time `json:"uploaded_at"`
 }
 
 type Document struct {
@@ -109,8 +636,245 @@ type Document struct {
 	TenantID     int       `json:"tenant_id"`
 	DocumentType string    `json:"document_type"`
 	FilePath     string    `json:"file_path"`
+	URL          string    `json:"url,omitempty"`
 	UploadDate   time.Time `json:"upload_date"`
 	Verified     bool      `json:"verified"`
 	VerifiedBy   *int      `json:"verified_by"`
 	Notes        string    `json:"notes"`
 }
+
+type BankStatementImport struct {
+	ID         int                 `json:"id"`
+	FileName   string              `json:"file_name"`
+	Format     string              `json:"format"`
+	UploadedBy int                 `json:"uploaded_by"`
+	UploadedAt time.Time           `json:"uploaded_at"`
+	Lines      []BankStatementLine `json:"lines,omitempty"`
+}
+
+type BankStatementLine struct {
+	ID              int       `json:"id"`
+	ImportID        int       `json:"import_id"`
+	TransactionDate time.Time `json:"transaction_date"`
+	Amount          float64   `json:"amount"`
+	Reference       string    `json:"reference"`
+	Description     string    `json:"description"`
+	MatchedTenantID *int      `json:"matched_tenant_id"`
+	Confidence      float64   `json:"confidence"`
+	Status          string    `json:"status"`
+	PaymentID       *int      `json:"payment_id"`
+}
+
+type Invoice struct {
+	ID          int       `json:"id"`
+	TenantID    int       `json:"tenant_id"`
+	PeriodMonth time.Time `json:"period_month"`
+	Amount      float64   `json:"amount"`
+	DueDate     time.Time `json:"due_date"`
+	IssuedAt    time.Time `json:"issued_at"`
+}
+
+type AgingBuckets struct {
+	Current    float64 `json:"current"`
+	Days1To30  float64 `json:"days_1_30"`
+	Days31To60 float64 `json:"days_31_60"`
+	Days61To90 float64 `json:"days_61_90"`
+	Over90     float64 `json:"over_90"`
+	Total      float64 `json:"total"`
+}
+
+type TenantAging struct {
+	TenantID int          `json:"tenant_id"`
+	Username string       `json:"username"`
+	Balances AgingBuckets `json:"balances"`
+}
+
+type RoomAging struct {
+	RoomID     int           `json:"room_id"`
+	RoomNumber string        `json:"room_number"`
+	Tenants    []TenantAging `json:"tenants"`
+	Totals     AgingBuckets  `json:"totals"`
+}
+
+type HouseAging struct {
+	HouseID   int          `json:"house_id"`
+	HouseName string       `json:"house_name"`
+	Rooms     []RoomAging  `json:"rooms"`
+	Totals    AgingBuckets `json:"totals"`
+}
+
+type AgingReport struct {
+	AsOf   time.Time    `json:"as_of"`
+	Houses []HouseAging `json:"houses"`
+	Totals AgingBuckets `json:"totals"`
+}
+
+type Expense struct {
+	ID          int       `json:"id"`
+	HouseID     int       `json:"house_id"`
+	Category    string    `json:"category"`
+	Vendor      string    `json:"vendor"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"`
+	ExpenseDate time.Time `json:"expense_date"`
+	Receipt     string    `json:"receipt"`
+	ReceiptURL  string    `json:"receipt_url,omitempty"`
+	RecurringID *int      `json:"recurring_id"`
+	RecordedBy  int       `json:"recorded_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type RecurringExpense struct {
+	ID          int        `json:"id"`
+	HouseID     int        `json:"house_id"`
+	Category    string     `json:"category"`
+	Vendor      string     `json:"vendor"`
+	Description string     `json:"description"`
+	Amount      float64    `json:"amount"`
+	DayOfMonth  int        `json:"day_of_month"`
+	StartDate   time.Time  `json:"start_date"`
+	EndDate     *time.Time `json:"end_date"`
+	LastPosted  *time.Time `json:"last_posted"`
+	IsActive    bool       `json:"is_active"`
+}
+
+type ProfitAndLossMonth struct {
+	Month           string             `json:"month"`
+	RentIncome      float64            `json:"rent_income"`
+	Expenses        map[string]float64 `json:"expenses"`
+	MaintenanceCost float64            `json:"maintenance_cost"`
+	TotalExpenses   float64            `json:"total_expenses"`
+	NetIncome       float64            `json:"net_income"`
+}
+
+type ProfitAndLossReport struct {
+	HouseID   int                  `json:"house_id"`
+	HouseName string               `json:"house_name"`
+	From      string               `json:"from"`
+	To        string               `json:"to"`
+	Months    []ProfitAndLossMonth `json:"months"`
+	Totals    ProfitAndLossMonth   `json:"totals"`
+}
+
+type Owner struct {
+	ID                   int       `json:"id"`
+	Name                 string    `json:"name"`
+	Email                string    `json:"email"`
+	Phone                string    `json:"phone"`
+	ManagementFeePercent float64   `json:"management_fee_percent"`
+	HouseIDs             []int     `json:"house_ids"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+type OwnerStatementLine struct {
+	HouseID         int     `json:"house_id"`
+	HouseName       string  `json:"house_name"`
+	Income          float64 `json:"income"`
+	Expenses        float64 `json:"expenses"`
+	MaintenanceCost float64 `json:"maintenance_cost"`
+}
+
+type OwnerPayout struct {
+	ID          int       `json:"id"`
+	StatementID int       `json:"statement_id"`
+	Amount      float64   `json:"amount"`
+	Method      string    `json:"method"`
+	Reference   string    `json:"reference"`
+	PaidAt      time.Time `json:"paid_at"`
+	RecordedBy  int       `json:"recorded_by"`
+}
+
+type OwnerStatement struct {
+	ID              int                  `json:"id"`
+	OwnerID         int                  `json:"owner_id"`
+	PeriodMonth     time.Time            `json:"period_month"`
+	Income          float64              `json:"income"`
+	Expenses        float64              `json:"expenses"`
+	MaintenanceCost float64              `json:"maintenance_cost"`
+	ManagementFee   float64              `json:"management_fee"`
+	NetPayout       float64              `json:"net_payout"`
+	GeneratedAt     time.Time            `json:"generated_at"`
+	Houses          []OwnerStatementLine `json:"houses"`
+	Payouts         []OwnerPayout        `json:"payouts"`
+}
+
+type OccupancyPoint struct {
+	Month        string  `json:"month"`
+	BedDays      int     `json:"bed_days"`
+	OccupiedDays int     `json:"occupied_days"`
+	VacancyDays  int     `json:"vacancy_days"`
+	Rate         float64 `json:"rate"`
+}
+
+type HouseOccupancy struct {
+	HouseID          int              `json:"house_id"`
+	HouseName        string           `json:"house_name"`
+	Months           []OccupancyPoint `json:"months"`
+	VacancyDays      int              `json:"vacancy_days"`
+	AverageStayDays  float64          `json:"average_stay_days"`
+	CompletedStays   int              `json:"completed_stays"`
+	AverageOccupancy float64          `json:"average_occupancy"`
+}
+
+type HouseRevenue struct {
+	HouseID        int     `json:"house_id"`
+	HouseName      string  `json:"house_name"`
+	Billed         float64 `json:"billed"`
+	Collected      float64 `json:"collected"`
+	CollectionRate float64 `json:"collection_rate"`
+}
+
+type HouseMaintenanceStats struct {
+	HouseID                int            `json:"house_id"`
+	HouseName              string         `json:"house_name"`
+	Opened                 int            `json:"opened"`
+	Completed              int            `json:"completed"`
+	ByPriority             map[string]int `json:"by_priority"`
+	AverageResolutionHours float64        `json:"average_resolution_hours"`
+}
+
+type AnalyticsSummary struct {
+	From        string                  `json:"from"`
+	To          string                  `json:"to"`
+	Occupancy   []HouseOccupancy        `json:"occupancy"`
+	Revenue     []HouseRevenue          `json:"revenue"`
+	Maintenance []HouseMaintenanceStats `json:"maintenance"`
+}
+
+type Reservation struct {
+	ID              int        `json:"id"`
+	RoomID          int        `json:"room_id"`
+	GuestName       string     `json:"guest_name"`
+	GuestEmail      string     `json:"guest_email"`
+	GuestPhone      string     `json:"guest_phone"`
+	ExpectedMoveIn  time.Time  `json:"expected_move_in"`
+	ExpectedMoveOut *time.Time `json:"expected_move_out"`
+	MonthlyRate     float64    `json:"monthly_rate"`
+	Status          string     `json:"status"`
+	CreatedBy       int        `json:"created_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+type ForecastMonth struct {
+	Month            string  `json:"month"`
+	Capacity         int     `json:"capacity"`
+	ExpectedOccupied float64 `json:"expected_occupied"`
+	OccupancyRate    float64 `json:"occupancy_rate"`
+	ExpectedRent     float64 `json:"expected_rent"`
+}
+
+type UpcomingVacancy struct {
+	RoomID     int       `json:"room_id"`
+	RoomNumber string    `json:"room_number"`
+	TenantID   int       `json:"tenant_id"`
+	Date       time.Time `json:"date"`
+	Reason     string    `json:"reason"`
+}
+
+type HouseForecast struct {
+	HouseID           int               `json:"house_id"`
+	HouseName         string            `json:"house_name"`
+	RenewalRate       float64           `json:"renewal_rate"`
+	Months            []ForecastMonth   `json:"months"`
+	UpcomingVacancies []UpcomingVacancy `json:"upcoming_vacancies"`
+}
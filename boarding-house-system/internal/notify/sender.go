@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+	"sync"
+)
+
+// EmailSender delivers email notifications.
+type EmailSender interface {
+	SendEmail(to, subject, body string) error
+}
+
+// SMSProvider delivers text messages. Production gateways implement it;
+// FakeSMSProvider is used locally and in tests.
+type SMSProvider interface {
+	SendSMS(to, body string) error
+}
+
+// SMTPSender sends plain-text email through an SMTP server.
+type SMTPSender struct {
+	host     string
+	port     int
+	user     string
+	password string
+	from     string
+}
+
+func NewSMTPSender(host string, port int, user, password, from string) *SMTPSender {
+	return &SMTPSender{host: host, port: port, user: user, password: password, from: from}
+}
+
+func (s *SMTPSender) SendEmail(to, subject, body string) error {
+	var auth smtp.Auth
+	if s.user != "" {
+		auth = smtp.PlainAuth("", s.user, s.password, s.host)
+	}
+
+	msg := strings.Join([]string{
+		"From: " + s.from,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	addr := fmt.Sprintf("%s:%d", s.host, s.port)
+	return smtp.SendMail(addr, auth, s.from, []string{to}, []byte(msg))
+}
+
+// SMS is a message recorded by FakeSMSProvider.
+type SMS struct {
+	To   string
+	Body string
+}
+
+// FakeSMSProvider logs messages instead of sending them and keeps them for
+// inspection.
+type FakeSMSProvider struct {
+	mu   sync.Mutex
+	sent []SMS
+}
+
+func NewFakeSMSProvider() *FakeSMSProvider {
+	return &FakeSMSProvider{}
+}
+
+func (p *FakeSMSProvider) SendSMS(to, body string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sent = append(p.sent, SMS{To: to, Body: body})
+	log.Printf("SMS to %s: %s", to, body)
+	return nil
+}
+
+// Sent returns the messages sent so far.
+func (p *FakeSMSProvider) Sent() []SMS {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]SMS(nil), p.sent...)
+}
@@ -0,0 +1,75 @@
+package realtime
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/Kimox23/boarding-house-app/internal/config"
+)
+
+// Event is a message pushed to one user's connected clients.
+type Event struct {
+	Type   string          `json:"type"`
+	UserID int             `json:"user_id"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Broker carries events between server instances so that a client connected
+// to one instance receives events published on another. Implementations must
+// deliver every published event to every subscriber, including subscribers
+// in the publishing instance.
+type Broker interface {
+	Publish(event Event) error
+	Subscribe(handler func(Event)) (unsubscribe func(), err error)
+}
+
+// MemoryBroker delivers events within a single process. It is the default
+// for single-instance deployments and for tests; deployments with several
+// instances use MySQLBroker.
+type MemoryBroker struct {
+	mu       sync.RWMutex
+	nextID   int
+	handlers map[int]func(Event)
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{handlers: make(map[int]func(Event))}
+}
+
+func (b *MemoryBroker) Publish(event Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, handler := range b.handlers {
+		handler(event)
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(handler func(Event)) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+	return func() {
+		b.mu.Lock()
+		delete(b.handlers, id)
+		b.mu.Unlock()
+	}, nil
+}
+
+// NewBroker returns the broker selected by cfg.RealtimeBroker: "memory" for
+// a single instance, "mysql" to share events between instances through the
+// database.
+func NewBroker(cfg *config.Config, db *sql.DB) (Broker, error) {
+	switch cfg.RealtimeBroker {
+	case "", "memory":
+		return NewMemoryBroker(), nil
+	case "mysql":
+		return NewMySQLBroker(db, cfg.RealtimePollInterval)
+	default:
+		return nil, fmt.Errorf("unknown realtime broker %q", cfg.RealtimeBroker)
+	}
+}
@@ -0,0 +1,122 @@
+package realtime
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+)
+
+// Event types pushed to clients.
+const (
+	EventNotification      = "notification"
+	EventUnreadCount       = "unread_count"
+	EventMaintenanceStatus = "maintenance_status"
+)
+
+// clientBuffer is how many events a client may fall behind before it is
+// disconnected; it will reconnect and reload its state.
+const clientBuffer = 32
+
+// Subscription is one connected client's stream of events.
+type Subscription struct {
+	userID int
+	events chan Event
+	hub    *Hub
+	once   sync.Once
+}
+
+// Events is closed when the subscription is closed, including when the hub
+// drops a client that stopped reading.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Subscription) Close() {
+	s.hub.remove(s)
+}
+
+// Hub fans events out from the broker to the clients connected to this
+// instance.
+type Hub struct {
+	broker      Broker
+	unsubscribe func()
+	mu          sync.Mutex
+	clients     map[int]map[*Subscription]struct{}
+}
+
+func NewHub(broker Broker) (*Hub, error) {
+	hub := &Hub{broker: broker, clients: make(map[int]map[*Subscription]struct{})}
+	unsubscribe, err := broker.Subscribe(hub.deliver)
+	if err != nil {
+		return nil, err
+	}
+	hub.unsubscribe = unsubscribe
+	return hub, nil
+}
+
+// Publish sends an event to every client of userID on every instance.
+// Failures are logged; real-time delivery is best effort and clients can
+// always fall back to polling.
+func (h *Hub) Publish(userID int, eventType string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("realtime: failed to encode %s event: %v", eventType, err)
+		return
+	}
+	if err := h.broker.Publish(Event{Type: eventType, UserID: userID, Data: payload}); err != nil {
+		log.Printf("realtime: failed to publish %s event for user %d: %v", eventType, userID, err)
+	}
+}
+
+func (h *Hub) Subscribe(userID int) *Subscription {
+	sub := &Subscription{userID: userID, events: make(chan Event, clientBuffer), hub: h}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.clients[userID] == nil {
+		h.clients[userID] = make(map[*Subscription]struct{})
+	}
+	h.clients[userID][sub] = struct{}{}
+	return sub
+}
+
+// Close detaches the hub from the broker and disconnects its clients.
+func (h *Hub) Close() {
+	h.unsubscribe()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for userID, subs := range h.clients {
+		for sub := range subs {
+			sub.once.Do(func() { close(sub.events) })
+		}
+		delete(h.clients, userID)
+	}
+}
+
+func (h *Hub) deliver(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.clients[event.UserID] {
+		select {
+		case sub.events <- event:
+		default:
+			log.Printf("realtime: dropping slow client of user %d", event.UserID)
+			h.removeLocked(sub)
+		}
+	}
+}
+
+func (h *Hub) remove(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(sub)
+}
+
+func (h *Hub) removeLocked(sub *Subscription) {
+	if subs := h.clients[sub.userID]; subs != nil {
+		delete(subs, sub)
+		if len(subs) == 0 {
+			delete(h.clients, sub.userID)
+		}
+	}
+	sub.once.Do(func() { close(sub.events) })
+}
@@ -0,0 +1,96 @@
+package realtime
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestHub(t *testing.T, broker Broker) *Hub {
+	t.Helper()
+	hub, err := NewHub(broker)
+	if err != nil {
+		t.Fatalf("NewHub: %v", err)
+	}
+	t.Cleanup(hub.Close)
+	return hub
+}
+
+func receive(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	select {
+	case event, ok := <-sub.Events():
+		if !ok {
+			t.Fatal("subscription closed before an event arrived")
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return Event{}
+}
+
+func TestHubFansOutToEverySubscriber(t *testing.T) {
+	broker := NewMemoryBroker()
+	// Two hubs on one broker stand in for two instances
+	first := newTestHub(t, broker)
+	second := newTestHub(t, broker)
+
+	subs := []*Subscription{first.Subscribe(1), first.Subscribe(1), second.Subscribe(1)}
+	other := first.Subscribe(2)
+
+	first.Publish(1, EventUnreadCount, map[string]int{"unread": 3})
+
+	for i, sub := range subs {
+		event := receive(t, sub)
+		if event.Type != EventUnreadCount || event.UserID != 1 {
+			t.Errorf("subscriber %d got %+v", i, event)
+		}
+		if string(event.Data) != `{"unread":3}` {
+			t.Errorf("subscriber %d got data %s", i, event.Data)
+		}
+	}
+
+	select {
+	case event := <-other.Events():
+		t.Errorf("user 2 received an event for user 1: %+v", event)
+	default:
+	}
+}
+
+func TestHubDropsSlowClient(t *testing.T) {
+	hub := newTestHub(t, NewMemoryBroker())
+	slow := hub.Subscribe(1)
+	fast := hub.Subscribe(1)
+
+	for i := 0; i <= clientBuffer; i++ {
+		hub.Publish(1, EventNotification, i)
+		receive(t, fast)
+	}
+
+	// The slow client's buffer filled up, so it was closed after the events
+	// it had already received
+	for i := 0; i < clientBuffer; i++ {
+		receive(t, slow)
+	}
+	if _, ok := <-slow.Events(); ok {
+		t.Fatal("slow client was not disconnected")
+	}
+
+	hub.Publish(1, EventNotification, "after")
+	if event := receive(t, fast); string(event.Data) != `"after"` {
+		t.Errorf("fast client got %s", event.Data)
+	}
+	// Closing a dropped subscription again is harmless
+	slow.Close()
+}
+
+func TestSubscriptionCloseStopsDelivery(t *testing.T) {
+	hub := newTestHub(t, NewMemoryBroker())
+	sub := hub.Subscribe(1)
+	sub.Close()
+
+	hub.Publish(1, EventNotification, "ignored")
+	if _, ok := <-sub.Events(); ok {
+		t.Fatal("closed subscription received an event")
+	}
+}
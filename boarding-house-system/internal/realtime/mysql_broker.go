@@ -0,0 +1,162 @@
+package realtime
+
+import (
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+)
+
+// Events are kept in realtime_events for eventRetention, long enough for
+// every instance to have polled them.
+const eventRetention = 10 * time.Minute
+
+// MySQLBroker carries events between instances through the realtime_events
+// table. Each instance polls for rows it has not delivered yet.
+//
+// AUTO_INCREMENT ids are handed out before the insert commits, so a poll can
+// see a later id before an earlier one. Instead of a cursor, each poll reads
+// a trailing window of recent rows and skips the ids it has already
+// delivered.
+type MySQLBroker struct {
+	db       *sql.DB
+	interval time.Duration
+	lookback time.Duration
+
+	mu       sync.RWMutex
+	nextID   int
+	handlers map[int]func(Event)
+
+	// seen holds the ids delivered recently and when they were first read.
+	// Only the polling goroutine touches it.
+	seen map[int64]time.Time
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewMySQLBroker starts polling every interval. Events published before it
+// started are not delivered.
+func NewMySQLBroker(db *sql.DB, interval time.Duration) (*MySQLBroker, error) {
+	lookback := 10 * interval
+	if lookback < 5*time.Second {
+		lookback = 5 * time.Second
+	}
+	b := &MySQLBroker{
+		db:       db,
+		interval: interval,
+		lookback: lookback,
+		handlers: make(map[int]func(Event)),
+		seen:     make(map[int64]time.Time),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	// Mark what is already in the window as delivered
+	if _, err := b.poll(false); err != nil {
+		return nil, err
+	}
+	go b.run()
+	return b, nil
+}
+
+func (b *MySQLBroker) Publish(event Event) error {
+	_, err := b.db.Exec(`INSERT INTO realtime_events (user_id, event_type, payload) VALUES (?, ?, ?)`,
+		event.UserID, event.Type, []byte(event.Data))
+	return err
+}
+
+func (b *MySQLBroker) Subscribe(handler func(Event)) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+	return func() {
+		b.mu.Lock()
+		delete(b.handlers, id)
+		b.mu.Unlock()
+	}, nil
+}
+
+// Close stops polling.
+func (b *MySQLBroker) Close() {
+	close(b.stop)
+	<-b.done
+}
+
+func (b *MySQLBroker) run() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	lastPurge := time.Now()
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := b.poll(true); err != nil {
+			log.Printf("realtime: polling events failed: %v", err)
+		}
+		if time.Since(lastPurge) >= time.Minute {
+			if _, err := b.db.Exec(`DELETE FROM realtime_events WHERE created_at < NOW(3) - INTERVAL ? SECOND`,
+				int(eventRetention.Seconds())); err != nil {
+				log.Printf("realtime: purging events failed: %v", err)
+			}
+			lastPurge = time.Now()
+		}
+	}
+}
+
+// poll reads the events in the lookback window and, when deliver is set,
+// hands the ones not seen before to the subscribers. It returns how many
+// were new.
+func (b *MySQLBroker) poll(deliver bool) (int, error) {
+	rows, err := b.db.Query(`SELECT event_id, user_id, event_type, payload FROM realtime_events
+	          WHERE created_at >= NOW(3) - INTERVAL ? MICROSECOND
+	          ORDER BY event_id`, b.lookback.Microseconds())
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var fresh []Event
+	for rows.Next() {
+		var id int64
+		var event Event
+		var payload []byte
+		if err := rows.Scan(&id, &event.UserID, &event.Type, &payload); err != nil {
+			return 0, err
+		}
+		if _, ok := b.seen[id]; ok {
+			continue
+		}
+		b.seen[id] = time.Now()
+		event.Data = payload
+		fresh = append(fresh, event)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	// Forget ids that have left the window
+	for id, seenAt := range b.seen {
+		if time.Since(seenAt) > 2*b.lookback {
+			delete(b.seen, id)
+		}
+	}
+
+	if deliver {
+		b.mu.RLock()
+		defer b.mu.RUnlock()
+		for _, event := range fresh {
+			for _, handler := range b.handlers {
+				handler(event)
+			}
+		}
+	}
+	return len(fresh), nil
+}
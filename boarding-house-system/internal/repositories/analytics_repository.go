@@ -0,0 +1,304 @@
+package repositories
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// MonthRange is a calendar month expressed as a half-open interval.
+type MonthRange struct {
+	Key   string
+	Start time.Time
+	End   time.Time
+}
+
+// StayStats summarises completed tenancies for a house.
+type StayStats struct {
+	CompletedStays  int
+	AverageStayDays float64
+}
+
+// MaintenanceCounts aggregates maintenance requests for a house.
+type MaintenanceCounts struct {
+	Opened                 int
+	Completed              int
+	ByPriority             map[string]int
+	AverageResolutionHours float64
+}
+
+type AnalyticsRepository struct {
+	db *sql.DB
+}
+
+func NewAnalyticsRepository(db *sql.DB) *AnalyticsRepository {
+	return &AnalyticsRepository{db: db}
+}
+
+// GetBedCapacity returns the total number of beds per house.
+func (r *AnalyticsRepository) GetBedCapacity(houseId int) (map[int]int, error) {
+	query := `SELECT house_id, SUM(capacity) FROM rooms
+	          WHERE ? = 0 OR house_id = ?
+	          GROUP BY house_id`
+
+	rows, err := r.db.Query(query, houseId, houseId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	capacity := make(map[int]int)
+	for rows.Next() {
+		var house, beds int
+		if err := rows.Scan(&house, &beds); err != nil {
+			return nil, err
+		}
+		capacity[house] = beds
+	}
+	return capacity, rows.Err()
+}
+
+// GetOccupiedDays returns, per house and month, the number of tenant-nights
+// spent in the house. All months are computed in a single query by joining
+// tenancies against a derived table of month boundaries.
+func (r *AnalyticsRepository) GetOccupiedDays(houseId int, months []MonthRange) (map[int]map[string]int, error) {
+	result := make(map[int]map[string]int)
+	if len(months) == 0 {
+		return result, nil
+	}
+
+	parts := make([]string, len(months))
+	args := make([]interface{}, 0, len(months)*3+2)
+	for i, m := range months {
+		parts[i] = "SELECT ? AS month_key, ? AS month_start, ? AS month_end"
+		args = append(args, m.Key, m.Start, m.End)
+	}
+	args = append(args, houseId, houseId)
+
+	query := `SELECT r.house_id, m.month_key,
+	          SUM(DATEDIFF(LEAST(COALESCE(t.move_out_date, m.month_end), m.month_end),
+	                       GREATEST(t.move_in_date, m.month_start)))
+	          FROM (` + strings.Join(parts, " UNION ALL ") + `) m
+	          JOIN tenants t ON t.move_in_date < m.month_end
+	               AND (t.move_out_date IS NULL OR t.move_out_date > m.month_start)
+	          JOIN rooms r ON r.room_id = t.room_id
+	          WHERE t.status IN ('active', 'inactive')
+	          AND (? = 0 OR r.house_id = ?)
+	          GROUP BY r.house_id, m.month_key`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var house, days int
+		var month string
+		if err := rows.Scan(&house, &month, &days); err != nil {
+			return nil, err
+		}
+		if result[house] == nil {
+			result[house] = make(map[string]int)
+		}
+		result[house][month] = days
+	}
+	return result, rows.Err()
+}
+
+// GetStayStats returns the average length of tenancies that ended in [from, to).
+func (r *AnalyticsRepository) GetStayStats(houseId int, from, to time.Time) (map[int]StayStats, error) {
+	query := `SELECT r.house_id, COUNT(*), AVG(DATEDIFF(t.move_out_date, t.move_in_date))
+	          FROM tenants t
+	          JOIN rooms r ON r.room_id = t.room_id
+	          WHERE t.move_out_date >= ? AND t.move_out_date < ?
+	          AND (? = 0 OR r.house_id = ?)
+	          GROUP BY r.house_id`
+
+	rows, err := r.db.Query(query, from, to, houseId, houseId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[int]StayStats)
+	for rows.Next() {
+		var house int
+		var s StayStats
+		if err := rows.Scan(&house, &s.CompletedStays, &s.AverageStayDays); err != nil {
+			return nil, err
+		}
+		stats[house] = s
+	}
+	return stats, rows.Err()
+}
+
+// GetBilledByHouse sums rent invoiced for periods in [from, to).
+func (r *AnalyticsRepository) GetBilledByHouse(houseId int, from, to time.Time) (map[int]float64, error) {
+	query := `SELECT r.house_id, SUM(i.amount)
+	          FROM invoices i
+	          JOIN tenants t ON t.tenant_id = i.tenant_id
+	          JOIN rooms r ON r.room_id = t.room_id
+	          WHERE i.period_month >= ? AND i.period_month < ?
+	          AND (? = 0 OR r.house_id = ?)
+	          GROUP BY r.house_id`
+
+	return r.houseTotals(query, from, to, houseId, houseId)
+}
+
+// GetCollectedByHouse sums net rent received in [from, to).
+func (r *AnalyticsRepository) GetCollectedByHouse(houseId int, from, to time.Time) (map[int]float64, error) {
+	query := `SELECT r.house_id, SUM(p.amount)
+	          FROM payments p
+	          JOIN tenants t ON t.tenant_id = p.tenant_id
+	          JOIN rooms r ON r.room_id = t.room_id
+	          WHERE p.payment_date >= ? AND p.payment_date < ?
+	          AND p.status IN ('paid', 'partial', 'voided', 'refunded')
+	          AND (? = 0 OR r.house_id = ?)
+	          GROUP BY r.house_id`
+
+	return r.houseTotals(query, from, to, houseId, houseId)
+}
+
+// GetMaintenanceCounts returns request volume by priority for requests
+// reported in [from, to), and resolution time for requests completed then.
+func (r *AnalyticsRepository) GetMaintenanceCounts(houseId int, from, to time.Time) (map[int]*MaintenanceCounts, error) {
+	counts := make(map[int]*MaintenanceCounts)
+	get := func(house int) *MaintenanceCounts {
+		if counts[house] == nil {
+			counts[house] = &MaintenanceCounts{ByPriority: make(map[string]int)}
+		}
+		return counts[house]
+	}
+
+	rows, err := r.db.Query(`SELECT r.house_id, m.priority, COUNT(*)
+	          FROM maintenance_requests m
+	          JOIN rooms r ON r.room_id = m.room_id
+	          WHERE m.reported_date >= ? AND m.reported_date < ?
+	          AND (? = 0 OR r.house_id = ?)
+	          GROUP BY r.house_id, m.priority`, from, to, houseId, houseId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var house, count int
+		var priority string
+		if err := rows.Scan(&house, &priority, &count); err != nil {
+			return nil, err
+		}
+		c := get(house)
+		c.ByPriority[priority] = count
+		c.Opened += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	resolved, err := r.db.Query(`SELECT r.house_id, COUNT(*),
+	          AVG(TIMESTAMPDIFF(MINUTE, m.reported_date, m.completed_date)) / 60
+	          FROM maintenance_requests m
+	          JOIN rooms r ON r.room_id = m.room_id
+	          WHERE m.status = 'completed'
+	          AND m.completed_date >= ? AND m.completed_date < ?
+	          AND (? = 0 OR r.house_id = ?)
+	          GROUP BY r.house_id`, from, to, houseId, houseId)
+	if err != nil {
+		return nil, err
+	}
+	defer resolved.Close()
+
+	for resolved.Next() {
+		var house, completed int
+		var hours float64
+		if err := resolved.Scan(&house, &completed, &hours); err != nil {
+			return nil, err
+		}
+		c := get(house)
+		c.Completed = completed
+		c.AverageResolutionHours = hours
+	}
+	return counts, resolved.Err()
+}
+
+func (r *AnalyticsRepository) houseTotals(query string, args ...interface{}) (map[int]float64, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make(map[int]float64)
+	for rows.Next() {
+		var house int
+		var total float64
+		if err := rows.Scan(&house, &total); err != nil {
+			return nil, err
+		}
+		totals[house] = total
+	}
+	return totals, rows.Err()
+}
+
+// Tenancy is a current tenant's stay as used by the occupancy forecast.
+type Tenancy struct {
+	TenantID      int
+	HouseID       int
+	RoomID        int
+	RoomNumber    string
+	PricePerMonth float64
+	MoveOutDate   *time.Time
+	LeaseEndDate  *time.Time
+}
+
+// GetCurrentTenancies returns active tenants with their room and any known
+// move-out or lease end date.
+func (r *AnalyticsRepository) GetCurrentTenancies(houseId int) ([]Tenancy, error) {
+	query := `SELECT t.tenant_id, r.house_id, r.room_id, r.room_number, r.price_per_month,
+	          t.move_out_date, t.lease_end_date
+	          FROM tenants t
+	          JOIN rooms r ON r.room_id = t.room_id
+	          WHERE t.status = 'active'
+	          AND (? = 0 OR r.house_id = ?)`
+
+	rows, err := r.db.Query(query, houseId, houseId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tenancies []Tenancy
+	for rows.Next() {
+		var t Tenancy
+		var moveOut, leaseEnd sql.NullTime
+		err := rows.Scan(&t.TenantID, &t.HouseID, &t.RoomID, &t.RoomNumber, &t.PricePerMonth,
+			&moveOut, &leaseEnd)
+		if err != nil {
+			return nil, err
+		}
+		if moveOut.Valid {
+			t.MoveOutDate = &moveOut.Time
+		}
+		if leaseEnd.Valid {
+			t.LeaseEndDate = &leaseEnd.Time
+		}
+		tenancies = append(tenancies, t)
+	}
+	return tenancies, rows.Err()
+}
+
+// GetRenewalRates returns, per house, the share of leases ending in
+// [from, to) where the tenant stayed past the lease end date.
+func (r *AnalyticsRepository) GetRenewalRates(houseId int, from, to time.Time) (map[int]float64, error) {
+	query := `SELECT r.house_id,
+	          SUM(CASE WHEN t.move_out_date IS NULL OR t.move_out_date > t.lease_end_date
+	              THEN 1 ELSE 0 END) / COUNT(*)
+	          FROM tenants t
+	          JOIN rooms r ON r.room_id = t.room_id
+	          WHERE t.lease_end_date >= ? AND t.lease_end_date < ?
+	          AND (? = 0 OR r.house_id = ?)
+	          GROUP BY r.house_id`
+
+	return r.houseTotals(query, from, to, houseId, houseId)
+}
@@ -0,0 +1,448 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/events"
+	"github.com/Kimox23/boarding-house-app/internal/models"
+)
+
+type AnnouncementRepository struct {
+	db *sql.DB
+}
+
+func NewAnnouncementRepository(db *sql.DB) *AnnouncementRepository {
+	return &AnnouncementRepository{db: db}
+}
+
+const announcementColumns = `a.announcement_id, a.title, a.body, a.audience, a.house_id, a.is_pinned,
+	a.publish_at, a.expires_at, a.published_at, a.created_by, a.created_at, a.updated_at`
+
+// announcementVisible limits a query to published announcements that have
+// not expired at the time bound to its parameter.
+const announcementVisible = `a.published_at IS NOT NULL AND (a.expires_at IS NULL OR a.expires_at > ?)`
+
+func (r *AnnouncementRepository) CreateAnnouncement(announcement *models.Announcement) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO announcements
+	          (title, body, audience, house_id, is_pinned, publish_at, expires_at, created_by)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		announcement.Title, announcement.Body, announcement.Audience, announcement.HouseID,
+		announcement.IsPinned, announcement.PublishAt, announcement.ExpiresAt, announcement.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if err := insertAnnouncementRooms(tx, int(id), announcement.RoomIDs); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	announcement.ID = int(id)
+	announcement.CreatedAt = time.Now()
+	announcement.UpdatedAt = announcement.CreatedAt
+	return nil
+}
+
+// GetAnnouncements returns every announcement, newest first, with how many
+// recipients it has and how many of them read it.
+func (r *AnnouncementRepository) GetAnnouncements() ([]models.Announcement, error) {
+	rows, err := r.db.Query(`SELECT ` + announcementColumns + `,
+	          (SELECT COUNT(*) FROM announcement_recipients ar WHERE ar.announcement_id = a.announcement_id),
+	          (SELECT COUNT(read_at) FROM announcement_recipients ar WHERE ar.announcement_id = a.announcement_id)
+	          FROM announcements a
+	          ORDER BY a.publish_at DESC, a.announcement_id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	announcements := []models.Announcement{}
+	for rows.Next() {
+		var announcement models.Announcement
+		if err := scanAnnouncement(rows, &announcement, &announcement.Recipients, &announcement.ReadCount); err != nil {
+			return nil, err
+		}
+		announcements = append(announcements, announcement)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range announcements {
+		if announcements[i].Audience != "rooms" {
+			continue
+		}
+		if announcements[i].RoomIDs, err = r.getRoomIDs(announcements[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return announcements, nil
+}
+
+func (r *AnnouncementRepository) GetAnnouncement(id int) (*models.Announcement, error) {
+	announcement := &models.Announcement{}
+	row := r.db.QueryRow(`SELECT `+announcementColumns+`,
+	          (SELECT COUNT(*) FROM announcement_recipients ar WHERE ar.announcement_id = a.announcement_id),
+	          (SELECT COUNT(read_at) FROM announcement_recipients ar WHERE ar.announcement_id = a.announcement_id)
+	          FROM announcements a
+	          WHERE a.announcement_id = ?`, id)
+	if err := scanAnnouncement(row, announcement, &announcement.Recipients, &announcement.ReadCount); err != nil {
+		return nil, err
+	}
+
+	roomIDs, err := r.getRoomIDs(id)
+	if err != nil {
+		return nil, err
+	}
+	announcement.RoomIDs = roomIDs
+	return announcement, nil
+}
+
+// UpdateAnnouncement saves an announcement. Its rooms are only replaced
+// while it is unpublished, since the recipients are fixed on publishing.
+func (r *AnnouncementRepository) UpdateAnnouncement(announcement *models.Announcement) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE announcements
+	          SET title = ?, body = ?, audience = ?, house_id = ?, is_pinned = ?, publish_at = ?, expires_at = ?
+	          WHERE announcement_id = ?`,
+		announcement.Title, announcement.Body, announcement.Audience, announcement.HouseID,
+		announcement.IsPinned, announcement.PublishAt, announcement.ExpiresAt, announcement.ID); err != nil {
+		return err
+	}
+	if announcement.PublishedAt == nil {
+		if _, err := tx.Exec(`DELETE FROM announcement_rooms WHERE announcement_id = ?`, announcement.ID); err != nil {
+			return err
+		}
+		if err := insertAnnouncementRooms(tx, announcement.ID, announcement.RoomIDs); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *AnnouncementRepository) DeleteAnnouncement(id int) error {
+	result, err := r.db.Exec(`DELETE FROM announcements WHERE announcement_id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+// GetDueAnnouncements returns the unpublished announcements scheduled at or
+// before asOf.
+func (r *AnnouncementRepository) GetDueAnnouncements(asOf time.Time) ([]int, error) {
+	rows, err := r.db.Query(`SELECT announcement_id FROM announcements
+	          WHERE published_at IS NULL AND publish_at <= ?
+	          ORDER BY publish_at`, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Publish fixes an announcement's recipients from its audience and records
+// an AnnouncementPublished event. It returns false when the announcement is
+// not due or another instance already published it.
+func (r *AnnouncementRepository) Publish(id int, asOf time.Time) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE announcements SET published_at = ?
+	          WHERE announcement_id = ? AND published_at IS NULL AND publish_at <= ?`, asOf, id, asOf)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	result, err = tx.Exec(`INSERT IGNORE INTO announcement_recipients (announcement_id, user_id)
+	          SELECT a.announcement_id, u.user_id
+	          FROM announcements a
+	          JOIN users u ON u.is_active = TRUE
+	          WHERE a.announcement_id = ? AND (
+	              a.audience = 'everyone'
+	              OR (a.audience = 'staff' AND u.role IN ('admin', 'manager', 'staff'))
+	              OR (a.audience = 'house' AND EXISTS (
+	                  SELECT 1 FROM tenants t JOIN rooms r ON r.room_id = t.room_id
+	                  WHERE t.user_id = u.user_id AND t.status = 'active' AND r.house_id = a.house_id))
+	              OR (a.audience = 'rooms' AND EXISTS (
+	                  SELECT 1 FROM tenants t JOIN announcement_rooms ar ON ar.room_id = t.room_id
+	                  WHERE t.user_id = u.user_id AND t.status = 'active'
+	                  AND ar.announcement_id = a.announcement_id))
+	          )`, id)
+	if err != nil {
+		return false, err
+	}
+	recipients, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	event := events.AnnouncementPublished{AnnouncementID: id, Recipients: int(recipients)}
+	var houseID sql.NullInt64
+	var expiresAt sql.NullTime
+	if err := tx.QueryRow(`SELECT title, audience, house_id, expires_at FROM announcements
+	          WHERE announcement_id = ?`, id).
+		Scan(&event.Title, &event.Audience, &houseID, &expiresAt); err != nil {
+		return false, err
+	}
+	if houseID.Valid {
+		id := int(houseID.Int64)
+		event.HouseID = &id
+	}
+	if expiresAt.Valid {
+		event.ExpiresAt = &expiresAt.Time
+	}
+	if err := insertOutboxEvent(tx, event); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetUnnotifiedRecipients returns the recipients of an announcement who have
+// not yet been sent a notification about it.
+func (r *AnnouncementRepository) GetUnnotifiedRecipients(id int) ([]int, error) {
+	rows, err := r.db.Query(`SELECT user_id FROM announcement_recipients
+	          WHERE announcement_id = ? AND notification_id IS NULL`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+func (r *AnnouncementRepository) SetNotification(id, userId, notificationId int) error {
+	_, err := r.db.Exec(`UPDATE announcement_recipients SET notification_id = ?
+	          WHERE announcement_id = ? AND user_id = ?`, notificationId, id, userId)
+	return err
+}
+
+// GetUserAnnouncements returns the visible announcements a user received,
+// pinned first, with when the user read them.
+func (r *AnnouncementRepository) GetUserAnnouncements(userId int, asOf time.Time) ([]models.Announcement, error) {
+	rows, err := r.db.Query(`SELECT `+announcementColumns+`, ar.read_at
+	          FROM announcements a
+	          JOIN announcement_recipients ar ON ar.announcement_id = a.announcement_id
+	          WHERE ar.user_id = ? AND `+announcementVisible+`
+	          ORDER BY a.is_pinned DESC, a.published_at DESC`, userId, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	announcements := []models.Announcement{}
+	for rows.Next() {
+		var announcement models.Announcement
+		var readAt sql.NullTime
+		if err := scanAnnouncement(rows, &announcement, &readAt); err != nil {
+			return nil, err
+		}
+		if readAt.Valid {
+			announcement.ReadAt = &readAt.Time
+		}
+		announcements = append(announcements, announcement)
+	}
+	return announcements, rows.Err()
+}
+
+// GetBoard returns the visible pinned announcements addressed to a house:
+// those for everyone, for the house and for any of its rooms. When tenantId
+// is set, room announcements are limited to the rooms that user rents.
+func (r *AnnouncementRepository) GetBoard(houseId, tenantId int, asOf time.Time) ([]models.Announcement, error) {
+	rows, err := r.db.Query(`SELECT `+announcementColumns+`
+	          FROM announcements a
+	          WHERE a.is_pinned = TRUE AND `+announcementVisible+` AND (
+	              a.audience = 'everyone'
+	              OR (a.audience = 'house' AND a.house_id = ?)
+	              OR (a.audience = 'rooms' AND EXISTS (
+	                  SELECT 1 FROM announcement_rooms ar JOIN rooms r ON r.room_id = ar.room_id
+	                  WHERE ar.announcement_id = a.announcement_id AND r.house_id = ?
+	                  AND (? = 0 OR EXISTS (
+	                      SELECT 1 FROM tenants t
+	                      WHERE t.room_id = ar.room_id AND t.user_id = ? AND t.status = 'active'))))
+	          )
+	          ORDER BY a.published_at DESC`, asOf, houseId, houseId, tenantId, tenantId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	announcements := []models.Announcement{}
+	for rows.Next() {
+		var announcement models.Announcement
+		if err := scanAnnouncement(rows, &announcement); err != nil {
+			return nil, err
+		}
+		announcements = append(announcements, announcement)
+	}
+	return announcements, rows.Err()
+}
+
+// IsTenantOfHouse reports whether a user is an active tenant of a house.
+func (r *AnnouncementRepository) IsTenantOfHouse(userId, houseId int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (
+	          SELECT 1 FROM tenants t JOIN rooms r ON r.room_id = t.room_id
+	          WHERE t.user_id = ? AND t.status = 'active' AND r.house_id = ?)`, userId, houseId).Scan(&exists)
+	return exists, err
+}
+
+// MarkRead records that a recipient read an announcement. Reading it again
+// keeps the first read time. It returns sql.ErrNoRows when the user is not
+// a recipient.
+func (r *AnnouncementRepository) MarkRead(id, userId int) error {
+	result, err := r.db.Exec(`UPDATE announcement_recipients SET read_at = CURRENT_TIMESTAMP
+	          WHERE announcement_id = ? AND user_id = ? AND read_at IS NULL`, id, userId)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 1 {
+		return nil
+	}
+
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM announcement_recipients
+	          WHERE announcement_id = ? AND user_id = ?)`, id, userId).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetReceipts returns every recipient of an announcement and when they read
+// it, unread first.
+func (r *AnnouncementRepository) GetReceipts(id int) ([]models.AnnouncementReceipt, error) {
+	rows, err := r.db.Query(`SELECT ar.user_id, u.username, ar.read_at
+	          FROM announcement_recipients ar
+	          JOIN users u ON u.user_id = ar.user_id
+	          WHERE ar.announcement_id = ?
+	          ORDER BY ar.read_at IS NOT NULL, ar.read_at, u.username`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	receipts := []models.AnnouncementReceipt{}
+	for rows.Next() {
+		var receipt models.AnnouncementReceipt
+		var readAt sql.NullTime
+		if err := rows.Scan(&receipt.UserID, &receipt.Username, &readAt); err != nil {
+			return nil, err
+		}
+		if readAt.Valid {
+			receipt.ReadAt = &readAt.Time
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, rows.Err()
+}
+
+func (r *AnnouncementRepository) getRoomIDs(id int) ([]int, error) {
+	rows, err := r.db.Query(`SELECT room_id FROM announcement_rooms
+	          WHERE announcement_id = ? ORDER BY room_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roomIDs []int
+	for rows.Next() {
+		var roomID int
+		if err := rows.Scan(&roomID); err != nil {
+			return nil, err
+		}
+		roomIDs = append(roomIDs, roomID)
+	}
+	return roomIDs, rows.Err()
+}
+
+func insertAnnouncementRooms(tx *sql.Tx, id int, roomIDs []int) error {
+	for _, roomID := range roomIDs {
+		if _, err := tx.Exec(`INSERT IGNORE INTO announcement_rooms (announcement_id, room_id)
+		          VALUES (?, ?)`, id, roomID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanAnnouncement(row rowScanner, announcement *models.Announcement, extra ...interface{}) error {
+	var houseID, createdBy sql.NullInt64
+	var expiresAt, publishedAt sql.NullTime
+	dest := []interface{}{&announcement.ID, &announcement.Title, &announcement.Body, &announcement.Audience,
+		&houseID, &announcement.IsPinned, &announcement.PublishAt, &expiresAt, &publishedAt, &createdBy,
+		&announcement.CreatedAt, &announcement.UpdatedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	if houseID.Valid {
+		id := int(houseID.Int64)
+		announcement.HouseID = &id
+	}
+	if expiresAt.Valid {
+		announcement.ExpiresAt = &expiresAt.Time
+	}
+	if publishedAt.Valid {
+		announcement.PublishedAt = &publishedAt.Time
+	}
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		announcement.CreatedBy = &id
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+)
+
+const assetColumns = `a.asset_id, a.room_id, r.room_number, a.category, a.name,
+	          COALESCE(a.serial_number, ''), a.asset_condition, a.purchase_date, a.purchase_cost,
+	          a.expected_life_months, COALESCE(a.notes, ''), a.is_active, a.disposed_date, a.created_at`
+
+const assetTables = ` FROM assets a JOIN rooms r ON r.room_id = a.room_id`
+
+type AssetRepository struct {
+	db *sql.DB
+}
+
+func NewAssetRepository(db *sql.DB) *AssetRepository {
+	return &AssetRepository{db: db}
+}
+
+func (r *AssetRepository) CreateAsset(asset *models.Asset) error {
+	query := `INSERT INTO assets
+	          (room_id, category, name, serial_number, asset_condition, purchase_date,
+	           purchase_cost, expected_life_months, notes)
+	          VALUES (?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, asset.RoomID, asset.Category, asset.Name, asset.SerialNumber,
+		asset.Condition, asset.PurchaseDate, asset.PurchaseCost, asset.ExpectedLifeMonths, asset.Notes)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	asset.ID = int(id)
+	asset.IsActive = true
+	asset.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *AssetRepository) GetAsset(id int) (*models.Asset, error) {
+	query := `SELECT ` + assetColumns + assetTables + ` WHERE a.asset_id = ?`
+
+	asset := &models.Asset{}
+	if err := scanAsset(r.db.QueryRow(query, id), asset); err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
+
+// GetAssetsByRoom lists a room's assets. Disposed assets are only included
+// when includeDisposed is set.
+func (r *AssetRepository) GetAssetsByRoom(roomId int, includeDisposed bool) ([]models.Asset, error) {
+	query := `SELECT ` + assetColumns + assetTables + `
+	          WHERE a.room_id = ? AND (? = TRUE OR a.is_active = TRUE)
+	          ORDER BY a.category, a.name`
+
+	rows, err := r.db.Query(query, roomId, includeDisposed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assets := []models.Asset{}
+	for rows.Next() {
+		var asset models.Asset
+		if err := scanAsset(rows, &asset); err != nil {
+			return nil, err
+		}
+		assets = append(assets, asset)
+	}
+	return assets, rows.Err()
+}
+
+// UpdateAsset changes an asset's details. Moving it to another room is
+// allowed; disposal goes through DisposeAsset.
+func (r *AssetRepository) UpdateAsset(id int, asset *models.Asset) error {
+	query := `UPDATE assets SET
+	          room_id = ?, category = ?, name = ?, serial_number = NULLIF(?, ''),
+	          asset_condition = ?, purchase_date = ?, purchase_cost = ?,
+	          expected_life_months = ?, notes = ?
+	          WHERE asset_id = ?`
+
+	_, err := r.db.Exec(query, asset.RoomID, asset.Category, asset.Name, asset.SerialNumber,
+		asset.Condition, asset.PurchaseDate, asset.PurchaseCost, asset.ExpectedLifeMonths,
+		asset.Notes, id)
+	return err
+}
+
+// DisposeAsset retires an asset. Its repair history is kept.
+func (r *AssetRepository) DisposeAsset(id int) error {
+	_, err := r.db.Exec(`UPDATE assets SET is_active = FALSE, disposed_date = NOW()
+	          WHERE asset_id = ? AND is_active = TRUE`, id)
+	return err
+}
+
+// GetRepairs returns the maintenance requests linked to an asset, newest
+// first.
+func (r *AssetRepository) GetRepairs(assetId int) ([]models.MaintenanceRequest, error) {
+	query := `SELECT ` + maintenanceColumns + `
+	          FROM maintenance_requests WHERE asset_id = ?
+	          ORDER BY reported_date DESC`
+
+	rows, err := r.db.Query(query, assetId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []models.MaintenanceRequest{}
+	for rows.Next() {
+		var request models.MaintenanceRequest
+		if err := scanMaintenanceRequest(rows, &request); err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+	return requests, rows.Err()
+}
+
+// GetRepairSummaries returns every active asset in a house with the number
+// and cost of its non-cancelled maintenance requests.
+func (r *AssetRepository) GetRepairSummaries(houseId int) ([]models.AssetRepairSummary, error) {
+	query := `SELECT ` + assetColumns + `, COALESCE(m.repairs, 0), COALESCE(m.repair_cost, 0),
+	          m.last_repair` + assetTables + `
+	          LEFT JOIN (
+	              SELECT asset_id, COUNT(*) AS repairs, SUM(COALESCE(cost, 0)) AS repair_cost,
+	                     MAX(reported_date) AS last_repair
+	              FROM maintenance_requests
+	              WHERE asset_id IS NOT NULL AND status <> 'cancelled'
+	              GROUP BY asset_id
+	          ) m ON m.asset_id = a.asset_id
+	          WHERE r.house_id = ? AND a.is_active = TRUE
+	          ORDER BY r.room_number, a.category, a.name`
+
+	rows, err := r.db.Query(query, houseId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []models.AssetRepairSummary{}
+	for rows.Next() {
+		var summary models.AssetRepairSummary
+		var lastRepair sql.NullTime
+		err := scanAsset(rows, &summary.Asset, &summary.RepairCount, &summary.RepairCost, &lastRepair)
+		if err != nil {
+			return nil, err
+		}
+		if lastRepair.Valid {
+			summary.LastRepairDate = &lastRepair.Time
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, rows.Err()
+}
+
+// scanAsset scans the asset columns followed by any extra destinations.
+func scanAsset(row rowScanner, asset *models.Asset, extra ...interface{}) error {
+	var purchaseDate, disposedDate sql.NullTime
+	dest := []interface{}{&asset.ID, &asset.RoomID, &asset.RoomNumber, &asset.Category, &asset.Name,
+		&asset.SerialNumber, &asset.Condition, &purchaseDate, &asset.PurchaseCost,
+		&asset.ExpectedLifeMonths, &asset.Notes, &asset.IsActive, &disposedDate, &asset.CreatedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+
+	if purchaseDate.Valid {
+		asset.PurchaseDate = &purchaseDate.Time
+	}
+	if disposedDate.Valid {
+		asset.DisposedDate = &disposedDate.Time
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+)
+
+// staffProfileColumns lists staff users with their profile, if any, and the
+// number of open requests assigned to them.
+const staffProfileColumns = `u.user_id, u.username, p.house_id, COALESCE(p.skills, ''),
+	          COALESCE(p.on_shift, FALSE), COALESCE(p.max_open_requests, 10),
+	          (SELECT COUNT(*) FROM maintenance_requests m
+	           WHERE m.assigned_to = u.user_id AND m.status IN ('pending', 'in_progress')) AS open_requests,
+	          COALESCE(p.updated_at, u.created_at)
+	          FROM users u
+	          LEFT JOIN staff_profiles p ON p.user_id = u.user_id`
+
+const assignmentRuleColumns = `rule_id, name, house_id, COALESCE(issue_type, ''),
+	          COALESCE(required_skill, ''), on_shift_only, rule_order, is_active, created_at`
+
+type AssignmentRepository struct {
+	db *sql.DB
+}
+
+func NewAssignmentRepository(db *sql.DB) *AssignmentRepository {
+	return &AssignmentRepository{db: db}
+}
+
+// GetStaffProfiles lists every staff user with their current workload.
+func (r *AssignmentRepository) GetStaffProfiles() ([]models.StaffProfile, error) {
+	return r.queryProfiles(`SELECT ` + staffProfileColumns + `
+	          WHERE u.role = 'staff'
+	          ORDER BY u.username`)
+}
+
+func (r *AssignmentRepository) GetStaffProfile(userId int) (*models.StaffProfile, error) {
+	profiles, err := r.queryProfiles(`SELECT `+staffProfileColumns+`
+	          WHERE u.role = 'staff' AND u.user_id = ?`, userId)
+	if err != nil {
+		return nil, err
+	}
+	if len(profiles) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &profiles[0], nil
+}
+
+// GetEligibleStaff returns staff who can take work in a house, optionally
+// limited to a skill and to those on shift, least loaded first.
+func (r *AssignmentRepository) GetEligibleStaff(houseId int, skill string, onShiftOnly bool) ([]models.StaffProfile, error) {
+	return r.queryProfiles(`SELECT `+staffProfileColumns+`
+	          WHERE u.role = 'staff' AND u.is_active = TRUE AND p.user_id IS NOT NULL
+	          AND (p.house_id IS NULL OR p.house_id = ?)
+	          AND (? = '' OR FIND_IN_SET(?, p.skills) > 0)
+	          AND (? = FALSE OR p.on_shift = TRUE)
+	          ORDER BY open_requests, u.user_id`, houseId, skill, skill, onShiftOnly)
+}
+
+// UpsertStaffProfile stores a staff member's house, skills, shift status and
+// workload cap.
+func (r *AssignmentRepository) UpsertStaffProfile(profile *models.StaffProfile) error {
+	_, err := r.db.Exec(`INSERT INTO staff_profiles (user_id, house_id, skills, on_shift, max_open_requests)
+	          VALUES (?, ?, ?, ?, ?)
+	          ON DUPLICATE KEY UPDATE house_id = VALUES(house_id), skills = VALUES(skills),
+	              on_shift = VALUES(on_shift), max_open_requests = VALUES(max_open_requests)`,
+		profile.UserID, profile.HouseID, strings.Join(profile.Skills, ","), profile.OnShift,
+		profile.MaxOpenRequests)
+	return err
+}
+
+// SetOnShift starts or ends a shift, creating a profile if needed.
+func (r *AssignmentRepository) SetOnShift(userId int, onShift bool) error {
+	_, err := r.db.Exec(`INSERT INTO staff_profiles (user_id, on_shift) VALUES (?, ?)
+	          ON DUPLICATE KEY UPDATE on_shift = VALUES(on_shift)`, userId, onShift)
+	return err
+}
+
+func (r *AssignmentRepository) CreateRule(rule *models.AssignmentRule) error {
+	result, err := r.db.Exec(`INSERT INTO assignment_rules
+	          (name, house_id, issue_type, required_skill, on_shift_only, rule_order)
+	          VALUES (?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?)`,
+		rule.Name, rule.HouseID, rule.IssueType, rule.RequiredSkill, rule.OnShiftOnly, rule.RuleOrder)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	rule.ID = int(id)
+	rule.IsActive = true
+	rule.CreatedAt = time.Now()
+	return nil
+}
+
+// GetRules returns assignment rules in the order they are tried.
+func (r *AssignmentRepository) GetRules(activeOnly bool) ([]models.AssignmentRule, error) {
+	rows, err := r.db.Query(`SELECT `+assignmentRuleColumns+` FROM assignment_rules
+	          WHERE ? = FALSE OR is_active = TRUE
+	          ORDER BY rule_order, rule_id`, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.AssignmentRule{}
+	for rows.Next() {
+		var rule models.AssignmentRule
+		if err := rows.Scan(&rule.ID, &rule.Name, &rule.HouseID, &rule.IssueType, &rule.RequiredSkill,
+			&rule.OnShiftOnly, &rule.RuleOrder, &rule.IsActive, &rule.CreatedAt); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (r *AssignmentRepository) GetRule(id int) (*models.AssignmentRule, error) {
+	rule := &models.AssignmentRule{}
+	err := r.db.QueryRow(`SELECT `+assignmentRuleColumns+` FROM assignment_rules WHERE rule_id = ?`, id).
+		Scan(&rule.ID, &rule.Name, &rule.HouseID, &rule.IssueType, &rule.RequiredSkill,
+			&rule.OnShiftOnly, &rule.RuleOrder, &rule.IsActive, &rule.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (r *AssignmentRepository) UpdateRule(id int, rule *models.AssignmentRule) error {
+	_, err := r.db.Exec(`UPDATE assignment_rules SET
+	          name = ?, house_id = ?, issue_type = NULLIF(?, ''), required_skill = NULLIF(?, ''),
+	          on_shift_only = ?, rule_order = ?, is_active = ?
+	          WHERE rule_id = ?`,
+		rule.Name, rule.HouseID, rule.IssueType, rule.RequiredSkill, rule.OnShiftOnly,
+		rule.RuleOrder, rule.IsActive, id)
+	return err
+}
+
+func (r *AssignmentRepository) DeleteRule(id int) error {
+	result, err := r.db.Exec(`DELETE FROM assignment_rules WHERE rule_id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+// GetRequestHouse returns the house a maintenance request's room is in.
+func (r *AssignmentRepository) GetRequestHouse(requestId int) (int, error) {
+	var houseID int
+	err := r.db.QueryRow(`SELECT r.house_id FROM maintenance_requests m
+	          JOIN rooms r ON r.room_id = m.room_id
+	          WHERE m.request_id = ?`, requestId).Scan(&houseID)
+	return houseID, err
+}
+
+// AutoAssign gives an unassigned request to a staff member and records it
+// on the timeline. It reports false if someone assigned the request first.
+func (r *AssignmentRepository) AutoAssign(requestId, staffId int, ruleName string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE maintenance_requests SET assigned_to = ?
+	          WHERE request_id = ? AND assigned_to IS NULL`, staffId, requestId)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	if err := insertMaintenanceEvent(tx, &models.MaintenanceEvent{
+		RequestID: requestId,
+		EventType: "assigned",
+		Note:      fmt.Sprintf("auto-assigned to user %d by rule %q", staffId, ruleName),
+	}); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetQueue returns the open requests assigned to a staff member with the
+// house each one is in.
+func (r *AssignmentRepository) GetQueue(staffId int) ([]models.QueueItem, error) {
+	rows, err := r.db.Query(`SELECT `+maintenanceColumns+`,
+	          (SELECT r.house_id FROM rooms r WHERE r.room_id = maintenance_requests.room_id)
+	          FROM maintenance_requests
+	          WHERE assigned_to = ? AND status IN ('pending', 'in_progress')`, staffId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	queue := []models.QueueItem{}
+	for rows.Next() {
+		var item models.QueueItem
+		if err := scanMaintenanceRequest(rows, &item.Request, &item.HouseID); err != nil {
+			return nil, err
+		}
+		queue = append(queue, item)
+	}
+	return queue, rows.Err()
+}
+
+func (r *AssignmentRepository) queryProfiles(query string, args ...interface{}) ([]models.StaffProfile, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := []models.StaffProfile{}
+	for rows.Next() {
+		var profile models.StaffProfile
+		var skills string
+		if err := rows.Scan(&profile.UserID, &profile.Username, &profile.HouseID, &skills,
+			&profile.OnShift, &profile.MaxOpenRequests, &profile.OpenRequests,
+			&profile.UpdatedAt); err != nil {
+			return nil, err
+		}
+		profile.Skills = []string{}
+		if skills != "" {
+			profile.Skills = strings.Split(skills, ",")
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, rows.Err()
+}
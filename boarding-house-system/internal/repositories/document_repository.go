@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/Kimox23/boarding-house-app/internal/events"
 	"github.com/Kimox23/boarding-house-app/internal/models"
 )
 
@@ -37,6 +38,13 @@ func (r *DocumentRepository) UploadDocument(document *models.Document) error {
 	return nil
 }
 
+// GetTenantUserID returns the user account of a tenant.
+func (r *DocumentRepository) GetTenantUserID(tenantId int) (int, error) {
+	var userID int
+	err := r.db.QueryRow(`SELECT user_id FROM tenants WHERE tenant_id = ?`, tenantId).Scan(&userID)
+	return userID, err
+}
+
 func (r *DocumentRepository) GetTenantDocuments(tenantId int) ([]models.Document, error) {
 	query := `SELECT document_id, tenant_id, document_type, file_path, upload_date,
 	          verified, verified_by, notes
@@ -68,8 +76,24 @@ func (r *DocumentRepository) VerifyDocument(id int, verified bool, notes string,
 	          verified = ?, verified_by = ?, notes = ?
 	          WHERE document_id = ?`
 
-	_, err := r.db.Exec(query, verified, verifiedBy, notes, id)
-	return err
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	event := events.DocumentVerified{DocumentID: id, Verified: verified, Notes: notes, VerifiedBy: verifiedBy}
+	if err := tx.QueryRow(`SELECT tenant_id, document_type FROM documents WHERE document_id = ? FOR UPDATE`,
+		id).Scan(&event.TenantID, &event.DocumentType); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, verified, verifiedBy, notes, id); err != nil {
+		return err
+	}
+	if err := insertOutboxEvent(tx, event); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *DocumentRepository) DeleteDocument(id int) error {
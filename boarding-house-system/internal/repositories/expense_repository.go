@@ -0,0 +1,235 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+)
+
+const expenseColumns = `expense_id, house_id, category, COALESCE(vendor, ''), COALESCE(description, ''),
+	          amount, expense_date, COALESCE(receipt, ''), recurring_id, COALESCE(recorded_by, 0), created_at`
+
+const recurringExpenseColumns = `recurring_id, house_id, category, COALESCE(vendor, ''),
+	          COALESCE(description, ''), amount, day_of_month, start_date, end_date,
+	          last_posted, is_active`
+
+type ExpenseRepository struct {
+	db *sql.DB
+}
+
+func NewExpenseRepository(db *sql.DB) *ExpenseRepository {
+	return &ExpenseRepository{db: db}
+}
+
+func (r *ExpenseRepository) CreateExpense(expense *models.Expense) error {
+	query := `INSERT INTO expenses
+	          (house_id, category, vendor, description, amount, expense_date, receipt, recorded_by)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, expense.HouseID, expense.Category, expense.Vendor,
+		expense.Description, expense.Amount, expense.ExpenseDate, expense.Receipt,
+		expense.RecordedBy)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	expense.ID = int(id)
+	expense.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *ExpenseRepository) GetExpense(id int) (*models.Expense, error) {
+	query := `SELECT ` + expenseColumns + ` FROM expenses WHERE expense_id = ?`
+
+	expense := &models.Expense{}
+	if err := scanExpense(r.db.QueryRow(query, id), expense); err != nil {
+		return nil, err
+	}
+	return expense, nil
+}
+
+// GetExpensesByHouse lists a house's expenses between two dates inclusive.
+// An empty category returns every category.
+func (r *ExpenseRepository) GetExpensesByHouse(houseId int, from, to time.Time, category string) ([]models.Expense, error) {
+	query := `SELECT ` + expenseColumns + `
+	          FROM expenses
+	          WHERE house_id = ? AND expense_date BETWEEN ? AND ?
+	          AND (? = '' OR category = ?)
+	          ORDER BY expense_date, expense_id`
+
+	rows, err := r.db.Query(query, houseId, from, to, category, category)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expenses []models.Expense
+	for rows.Next() {
+		var expense models.Expense
+		if err := scanExpense(rows, &expense); err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, expense)
+	}
+
+	return expenses, rows.Err()
+}
+
+func (r *ExpenseRepository) UpdateExpense(id int, expense *models.Expense) error {
+	query := `UPDATE expenses SET
+	          house_id = ?, category = ?, vendor = ?, description = ?, amount = ?, expense_date = ?
+	          WHERE expense_id = ?`
+
+	_, err := r.db.Exec(query, expense.HouseID, expense.Category, expense.Vendor,
+		expense.Description, expense.Amount, expense.ExpenseDate, id)
+	return err
+}
+
+// SetReceipt replaces an expense's receipt and returns the previous one, if
+// any, so the caller can remove it from storage.
+func (r *ExpenseRepository) SetReceipt(id int, receipt string) (string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var previous string
+	if err := tx.QueryRow(`SELECT COALESCE(receipt, '') FROM expenses WHERE expense_id = ? FOR UPDATE`,
+		id).Scan(&previous); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(`UPDATE expenses SET receipt = ? WHERE expense_id = ?`, receipt, id); err != nil {
+		return "", err
+	}
+	return previous, tx.Commit()
+}
+
+func (r *ExpenseRepository) DeleteExpense(id int) error {
+	query := `DELETE FROM expenses WHERE expense_id = ?`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+func (r *ExpenseRepository) CreateRecurringExpense(recurring *models.RecurringExpense) error {
+	query := `INSERT INTO recurring_expenses
+	          (house_id, category, vendor, description, amount, day_of_month, start_date, end_date)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, recurring.HouseID, recurring.Category, recurring.Vendor,
+		recurring.Description, recurring.Amount, recurring.DayOfMonth, recurring.StartDate,
+		recurring.EndDate)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	recurring.ID = int(id)
+	recurring.IsActive = true
+	return nil
+}
+
+func (r *ExpenseRepository) GetRecurringExpensesByHouse(houseId int) ([]models.RecurringExpense, error) {
+	query := `SELECT ` + recurringExpenseColumns + `
+	          FROM recurring_expenses WHERE house_id = ?`
+	return r.queryRecurringExpenses(query, houseId)
+}
+
+// GetActiveRecurringExpenses returns every schedule that may still need posting.
+func (r *ExpenseRepository) GetActiveRecurringExpenses() ([]models.RecurringExpense, error) {
+	query := `SELECT ` + recurringExpenseColumns + `
+	          FROM recurring_expenses WHERE is_active = TRUE`
+	return r.queryRecurringExpenses(query)
+}
+
+func (r *ExpenseRepository) DeactivateRecurringExpense(id int) error {
+	query := `UPDATE recurring_expenses SET is_active = FALSE WHERE recurring_id = ?`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+// PostRecurringExpense records one occurrence of a recurring expense and
+// advances its last posted date in the same transaction. It returns false
+// when the occurrence had already been posted.
+func (r *ExpenseRepository) PostRecurringExpense(recurring *models.RecurringExpense, date time.Time) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Claim the occurrence first so that two posters running at once do not
+	// both record it
+	result, err := tx.Exec(`UPDATE recurring_expenses SET last_posted = ?
+	          WHERE recurring_id = ? AND (last_posted IS NULL OR last_posted < ?)`,
+		date, recurring.ID, date)
+	if err != nil {
+		return false, err
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if claimed == 0 {
+		return false, nil
+	}
+
+	_, err = tx.Exec(`INSERT INTO expenses
+	          (house_id, category, vendor, description, amount, expense_date, recurring_id)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		recurring.HouseID, recurring.Category, recurring.Vendor, recurring.Description,
+		recurring.Amount, date, recurring.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	recurring.LastPosted = &date
+	return true, nil
+}
+
+func (r *ExpenseRepository) queryRecurringExpenses(query string, args ...interface{}) ([]models.RecurringExpense, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []models.RecurringExpense
+	for rows.Next() {
+		var s models.RecurringExpense
+		var endDate, lastPosted sql.NullTime
+		err := rows.Scan(&s.ID, &s.HouseID, &s.Category, &s.Vendor, &s.Description,
+			&s.Amount, &s.DayOfMonth, &s.StartDate, &endDate, &lastPosted, &s.IsActive)
+		if err != nil {
+			return nil, err
+		}
+		if endDate.Valid {
+			s.EndDate = &endDate.Time
+		}
+		if lastPosted.Valid {
+			s.LastPosted = &lastPosted.Time
+		}
+		schedules = append(schedules, s)
+	}
+
+	return schedules, rows.Err()
+}
+
+func scanExpense(row rowScanner, expense *models.Expense) error {
+	return row.Scan(&expense.ID, &expense.HouseID, &expense.Category, &expense.Vendor,
+		&expense.Description, &expense.Amount, &expense.ExpenseDate, &expense.Receipt,
+		&expense.RecurringID, &expense.RecordedBy, &expense.CreatedAt)
+}
@@ -0,0 +1,348 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+)
+
+var ErrInspectionStatusChanged = errors.New("inspection status changed, reload and try again")
+
+const inspectionColumns = `inspection_id, tenant_id, room_id, inspection_type, status, inspected_by,
+	          COALESCE(notes, ''), created_at, completed_at, acknowledged_at,
+	          COALESCE(tenant_comment, '')`
+
+type InspectionRepository struct {
+	db *sql.DB
+}
+
+func NewInspectionRepository(db *sql.DB) *InspectionRepository {
+	return &InspectionRepository{db: db}
+}
+
+func (r *InspectionRepository) CreateChecklistItem(item *models.ChecklistItem) error {
+	result, err := r.db.Exec(`INSERT INTO inspection_checklist_items (room_type, area, label, sort_order)
+	          VALUES (?, ?, ?, ?)`, item.RoomType, item.Area, item.Label, item.SortOrder)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	item.ID = int(id)
+	item.IsActive = true
+	return nil
+}
+
+// GetChecklist returns the active checklist for a room type in display
+// order.
+func (r *InspectionRepository) GetChecklist(roomType string) ([]models.ChecklistItem, error) {
+	rows, err := r.db.Query(`SELECT checklist_item_id, room_type, area, label, sort_order, is_active
+	          FROM inspection_checklist_items
+	          WHERE room_type = ? AND is_active = TRUE
+	          ORDER BY sort_order, area, checklist_item_id`, roomType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.ChecklistItem{}
+	for rows.Next() {
+		var item models.ChecklistItem
+		if err := rows.Scan(&item.ID, &item.RoomType, &item.Area, &item.Label,
+			&item.SortOrder, &item.IsActive); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *InspectionRepository) GetChecklistItem(id int) (*models.ChecklistItem, error) {
+	item := &models.ChecklistItem{}
+	err := r.db.QueryRow(`SELECT checklist_item_id, room_type, area, label, sort_order, is_active
+	          FROM inspection_checklist_items WHERE checklist_item_id = ?`, id).
+		Scan(&item.ID, &item.RoomType, &item.Area, &item.Label, &item.SortOrder, &item.IsActive)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (r *InspectionRepository) UpdateChecklistItem(id int, item *models.ChecklistItem) error {
+	_, err := r.db.Exec(`UPDATE inspection_checklist_items SET area = ?, label = ?, sort_order = ?
+	          WHERE checklist_item_id = ?`, item.Area, item.Label, item.SortOrder, id)
+	return err
+}
+
+// DeactivateChecklistItem removes an item from future inspections. Existing
+// inspections keep their copy of it.
+func (r *InspectionRepository) DeactivateChecklistItem(id int) error {
+	_, err := r.db.Exec(`UPDATE inspection_checklist_items SET is_active = FALSE
+	          WHERE checklist_item_id = ?`, id)
+	return err
+}
+
+// GetTenant returns the fields of a tenancy that inspections need.
+func (r *InspectionRepository) GetTenant(tenantId int) (*models.Tenant, error) {
+	tenant := &models.Tenant{}
+	err := r.db.QueryRow(`SELECT tenant_id, user_id, COALESCE(room_id, 0), COALESCE(deposit_amount, 0)
+	          FROM tenants WHERE tenant_id = ?`, tenantId).
+		Scan(&tenant.ID, &tenant.UserID, &tenant.RoomID, &tenant.DepositAmount)
+	if err != nil {
+		return nil, err
+	}
+	return tenant, nil
+}
+
+// CreateInspection starts a draft inspection of the tenant's room, copying
+// in the active checklist for the room's type.
+func (r *InspectionRepository) CreateInspection(inspection *models.Inspection) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO inspections (tenant_id, room_id, inspection_type, inspected_by, notes)
+	          VALUES (?, ?, ?, ?, ?)`, inspection.TenantID, inspection.RoomID,
+		inspection.InspectionType, inspection.InspectedBy, inspection.Notes)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO inspection_items (inspection_id, checklist_item_id, area, label)
+	          SELECT ?, c.checklist_item_id, c.area, c.label
+	          FROM inspection_checklist_items c
+	          JOIN rooms r ON r.room_type = c.room_type
+	          WHERE r.room_id = ? AND c.is_active = TRUE
+	          ORDER BY c.sort_order, c.area, c.checklist_item_id`, id, inspection.RoomID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	inspection.ID = int(id)
+	inspection.Status = "draft"
+	inspection.CreatedAt = time.Now()
+	return nil
+}
+
+// GetInspection returns an inspection with its items and their photos.
+func (r *InspectionRepository) GetInspection(id int) (*models.Inspection, error) {
+	inspection := &models.Inspection{}
+	row := r.db.QueryRow(`SELECT `+inspectionColumns+` FROM inspections WHERE inspection_id = ?`, id)
+	if err := scanInspection(row, inspection); err != nil {
+		return nil, err
+	}
+
+	items, err := r.getItems(id)
+	if err != nil {
+		return nil, err
+	}
+	inspection.Items = items
+	return inspection, nil
+}
+
+// GetInspectionsByTenant lists a tenant's inspections without their items.
+func (r *InspectionRepository) GetInspectionsByTenant(tenantId int) ([]models.Inspection, error) {
+	rows, err := r.db.Query(`SELECT `+inspectionColumns+` FROM inspections
+	          WHERE tenant_id = ? ORDER BY created_at`, tenantId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	inspections := []models.Inspection{}
+	for rows.Next() {
+		var inspection models.Inspection
+		if err := scanInspection(rows, &inspection); err != nil {
+			return nil, err
+		}
+		inspections = append(inspections, inspection)
+	}
+	return inspections, rows.Err()
+}
+
+// GetInspectionByType returns a tenant's move-in or move-out inspection.
+func (r *InspectionRepository) GetInspectionByType(tenantId int, inspectionType string) (*models.Inspection, error) {
+	var id int
+	err := r.db.QueryRow(`SELECT inspection_id FROM inspections
+	          WHERE tenant_id = ? AND inspection_type = ?`, tenantId, inspectionType).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetInspection(id)
+}
+
+func (r *InspectionRepository) AddItem(item *models.InspectionItem) error {
+	result, err := r.db.Exec(`INSERT INTO inspection_items
+	          (inspection_id, area, label, item_condition, notes, estimated_cost)
+	          VALUES (?, ?, ?, NULLIF(?, ''), ?, ?)`, item.InspectionID, item.Area, item.Label,
+		item.Condition, item.Notes, item.EstimatedCost)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	item.ID = int(id)
+	item.Photos = []models.InspectionPhoto{}
+	return nil
+}
+
+// UpdateItem records the condition, notes and repair estimate for an item.
+func (r *InspectionRepository) UpdateItem(inspectionId, itemId int, item *models.InspectionItem) error {
+	_, err := r.db.Exec(`UPDATE inspection_items SET
+	          item_condition = NULLIF(?, ''), notes = ?, estimated_cost = ?
+	          WHERE inspection_item_id = ? AND inspection_id = ?`,
+		item.Condition, item.Notes, item.EstimatedCost, itemId, inspectionId)
+	return err
+}
+
+func (r *InspectionRepository) AddPhotos(itemId int, photos []models.InspectionPhoto) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range photos {
+		result, err := tx.Exec(`INSERT INTO inspection_photos (inspection_item_id, file_path, original_name)
+		          VALUES (?, ?, ?)`, itemId, photos[i].FilePath, photos[i].OriginalName)
+		if err != nil {
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		photos[i].ID = int(id)
+		photos[i].InspectionItemID = itemId
+		photos[i].UploadedAt = time.Now()
+	}
+	return tx.Commit()
+}
+
+// UpdateStatus moves an inspection from one status to another, stamping the
+// completion or acknowledgement time. It fails with
+// ErrInspectionStatusChanged if the inspection is no longer in status from.
+func (r *InspectionRepository) UpdateStatus(id int, from, to, tenantComment string) error {
+	query := `UPDATE inspections SET status = ?, completed_at = NOW() WHERE inspection_id = ? AND status = ?`
+	args := []interface{}{to, id, from}
+	if to != "completed" {
+		query = `UPDATE inspections SET status = ?, acknowledged_at = NOW(), tenant_comment = NULLIF(?, '')
+		          WHERE inspection_id = ? AND status = ?`
+		args = []interface{}{to, tenantComment, id, from}
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrInspectionStatusChanged
+	}
+	return nil
+}
+
+func (r *InspectionRepository) getItems(inspectionId int) ([]models.InspectionItem, error) {
+	rows, err := r.db.Query(`SELECT inspection_item_id, inspection_id, checklist_item_id, area, label,
+	          COALESCE(item_condition, ''), COALESCE(notes, ''), estimated_cost
+	          FROM inspection_items WHERE inspection_id = ?
+	          ORDER BY inspection_item_id`, inspectionId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.InspectionItem{}
+	index := make(map[int]int)
+	for rows.Next() {
+		item := models.InspectionItem{Photos: []models.InspectionPhoto{}}
+		if err := rows.Scan(&item.ID, &item.InspectionID, &item.ChecklistItemID, &item.Area,
+			&item.Label, &item.Condition, &item.Notes, &item.EstimatedCost); err != nil {
+			return nil, err
+		}
+		index[item.ID] = len(items)
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	photoRows, err := r.db.Query(`SELECT p.photo_id, p.inspection_item_id, p.file_path,
+	          COALESCE(p.original_name, ''), p.uploaded_at
+	          FROM inspection_photos p
+	          JOIN inspection_items i ON i.inspection_item_id = p.inspection_item_id
+	          WHERE i.inspection_id = ?
+	          ORDER BY p.photo_id`, inspectionId)
+	if err != nil {
+		return nil, err
+	}
+	defer photoRows.Close()
+
+	for photoRows.Next() {
+		var photo models.InspectionPhoto
+		if err := photoRows.Scan(&photo.ID, &photo.InspectionItemID, &photo.FilePath,
+			&photo.OriginalName, &photo.UploadedAt); err != nil {
+			return nil, err
+		}
+		if i, ok := index[photo.InspectionItemID]; ok {
+			items[i].Photos = append(items[i].Photos, photo)
+		}
+	}
+	return items, photoRows.Err()
+}
+
+func scanInspection(row rowScanner, inspection *models.Inspection) error {
+	var completedAt, acknowledgedAt sql.NullTime
+	err := row.Scan(&inspection.ID, &inspection.TenantID, &inspection.RoomID, &inspection.InspectionType,
+		&inspection.Status, &inspection.InspectedBy, &inspection.Notes, &inspection.CreatedAt,
+		&completedAt, &acknowledgedAt, &inspection.TenantComment)
+	if err != nil {
+		return err
+	}
+
+	if completedAt.Valid {
+		inspection.CompletedAt = &completedAt.Time
+	}
+	if acknowledgedAt.Valid {
+		inspection.AcknowledgedAt = &acknowledgedAt.Time
+	}
+	return nil
+}
+
+// expectRow turns a delete that matched nothing into sql.ErrNoRows. Do not
+// use it for updates: MySQL reports rows that already held the new values
+// as unaffected.
+func expectRow(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
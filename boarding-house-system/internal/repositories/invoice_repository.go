@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+)
+
+type InvoiceRepository struct {
+	db *sql.DB
+}
+
+func NewInvoiceRepository(db *sql.DB) *InvoiceRepository {
+	return &InvoiceRepository{db: db}
+}
+
+// GenerateMonthlyInvoices bills every tenant who occupied a room during the
+// period at the room's monthly price. Tenants already invoiced for the period
+// are skipped, so the call is safe to repeat.
+func (r *InvoiceRepository) GenerateMonthlyInvoices(period, dueDate time.Time) (int, error) {
+	periodEnd := period.AddDate(0, 1, -1)
+	query := `INSERT IGNORE INTO invoices (tenant_id, period_month, amount, due_date)
+	          SELECT t.tenant_id, ?, r.price_per_month, ?
+	          FROM tenants t
+	          JOIN rooms r ON r.room_id = t.room_id
+	          WHERE t.status = 'active'
+	          AND t.move_in_date <= ?
+	          AND (t.move_out_date IS NULL OR t.move_out_date >= ?)`
+
+	result, err := r.db.Exec(query, period, dueDate, periodEnd, period)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+func (r *InvoiceRepository) GetInvoicesByTenant(tenantId int) ([]models.Invoice, error) {
+	query := `SELECT invoice_id, tenant_id, period_month, amount, due_date, issued_at
+	          FROM invoices WHERE tenant_id = ?
+	          ORDER BY period_month`
+
+	rows, err := r.db.Query(query, tenantId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invoices []models.Invoice
+	for rows.Next() {
+		var invoice models.Invoice
+		err := rows.Scan(&invoice.ID, &invoice.TenantID, &invoice.PeriodMonth,
+			&invoice.Amount, &invoice.DueDate, &invoice.IssuedAt)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, invoice)
+	}
+
+	return invoices, rows.Err()
+}
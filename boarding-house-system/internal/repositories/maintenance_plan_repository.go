@@ -0,0 +1,301 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+)
+
+const maintenancePlanColumns = `plan_id, house_id, room_id, asset_id, COALESCE(room_type, ''), title,
+	          COALESCE(description, ''), issue_type, priority, interval_months, lead_days,
+	          next_due_date, assigned_to, is_active, created_by, created_at`
+
+const planRunColumns = `pr.run_id, pr.plan_id, p.title, pr.room_id, r.room_number, pr.asset_id, pr.due_date,
+	          pr.request_id, m.status, m.completed_date, pr.generated_at
+	          FROM maintenance_plan_runs pr
+	          JOIN maintenance_plans p ON p.plan_id = pr.plan_id
+	          JOIN rooms r ON r.room_id = pr.room_id
+	          JOIN maintenance_requests m ON m.request_id = pr.request_id`
+
+type MaintenancePlanRepository struct {
+	db *sql.DB
+}
+
+func NewMaintenancePlanRepository(db *sql.DB) *MaintenancePlanRepository {
+	return &MaintenancePlanRepository{db: db}
+}
+
+func (r *MaintenancePlanRepository) CreatePlan(plan *models.MaintenancePlan) error {
+	query := `INSERT INTO maintenance_plans
+	          (house_id, room_id, asset_id, room_type, title, description, issue_type, priority,
+	           interval_months, lead_days, next_due_date, assigned_to, created_by)
+	          VALUES (?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, plan.HouseID, plan.RoomID, plan.AssetID, plan.RoomType, plan.Title,
+		plan.Description, plan.IssueType, plan.Priority, plan.IntervalMonths, plan.LeadDays,
+		plan.NextDueDate, plan.AssignedTo, plan.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	plan.ID = int(id)
+	plan.IsActive = true
+	plan.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *MaintenancePlanRepository) GetPlan(id int) (*models.MaintenancePlan, error) {
+	query := `SELECT ` + maintenancePlanColumns + ` FROM maintenance_plans WHERE plan_id = ?`
+
+	plan := &models.MaintenancePlan{}
+	if err := scanMaintenancePlan(r.db.QueryRow(query, id), plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// GetPlans lists plans for a house, or for every house when houseId is zero.
+func (r *MaintenancePlanRepository) GetPlans(houseId int, activeOnly bool) ([]models.MaintenancePlan, error) {
+	query := `SELECT ` + maintenancePlanColumns + ` FROM maintenance_plans
+	          WHERE (? = 0 OR house_id = ?) AND (? = FALSE OR is_active = TRUE)
+	          ORDER BY next_due_date`
+
+	return r.queryPlans(query, houseId, houseId, activeOnly)
+}
+
+// GetDuePlans returns active plans whose next occurrence falls within its
+// lead time of asOf.
+func (r *MaintenancePlanRepository) GetDuePlans(asOf time.Time) ([]models.MaintenancePlan, error) {
+	query := `SELECT ` + maintenancePlanColumns + ` FROM maintenance_plans
+	          WHERE is_active = TRUE
+	          AND DATE_SUB(next_due_date, INTERVAL lead_days DAY) <= ?`
+
+	return r.queryPlans(query, asOf)
+}
+
+func (r *MaintenancePlanRepository) UpdatePlan(id int, plan *models.MaintenancePlan) error {
+	query := `UPDATE maintenance_plans SET
+	          room_id = ?, asset_id = ?, room_type = NULLIF(?, ''), title = ?, description = ?,
+	          issue_type = ?, priority = ?, interval_months = ?, lead_days = ?, next_due_date = ?,
+	          assigned_to = ?, is_active = ?
+	          WHERE plan_id = ?`
+
+	_, err := r.db.Exec(query, plan.RoomID, plan.AssetID, plan.RoomType, plan.Title, plan.Description,
+		plan.IssueType, plan.Priority, plan.IntervalMonths, plan.LeadDays, plan.NextDueDate,
+		plan.AssignedTo, plan.IsActive, id)
+	return err
+}
+
+func (r *MaintenancePlanRepository) DeactivatePlan(id int) error {
+	_, err := r.db.Exec(`UPDATE maintenance_plans SET is_active = FALSE WHERE plan_id = ?`, id)
+	return err
+}
+
+// GenerateOccurrence creates the maintenance requests for one occurrence of
+// a plan, one per targeted room, and moves the plan on to nextDue. The plan
+// row is locked and its due date re-checked so that concurrent runs cannot
+// generate the same occurrence twice; it returns the requests created.
+func (r *MaintenancePlanRepository) GenerateOccurrence(planId int, dueDate, nextDue time.Time) ([]models.MaintenanceRequest, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	plan := &models.MaintenancePlan{}
+	row := tx.QueryRow(`SELECT `+maintenancePlanColumns+` FROM maintenance_plans
+	          WHERE plan_id = ? FOR UPDATE`, planId)
+	if err := scanMaintenancePlan(row, plan); err != nil {
+		return nil, err
+	}
+	if !plan.IsActive || !sameDay(plan.NextDueDate, dueDate) {
+		return nil, nil
+	}
+
+	var rooms *sql.Rows
+	if plan.AssetID != nil {
+		// An asset plan follows the asset to the room it is in now and stops
+		// once the asset is disposed of
+		rooms, err = tx.Query(`SELECT a.room_id FROM assets a
+		          JOIN rooms r ON r.room_id = a.room_id
+		          WHERE a.asset_id = ? AND a.is_active = TRUE AND r.house_id = ?`,
+			*plan.AssetID, plan.HouseID)
+	} else {
+		rooms, err = tx.Query(`SELECT room_id FROM rooms
+		          WHERE house_id = ? AND (? IS NULL OR room_id = ?)
+		          AND (? = '' OR room_type = ?)`,
+			plan.HouseID, plan.RoomID, plan.RoomID, plan.RoomType, plan.RoomType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var roomIDs []int
+	for rooms.Next() {
+		var roomID int
+		if err := rooms.Scan(&roomID); err != nil {
+			rooms.Close()
+			return nil, err
+		}
+		roomIDs = append(roomIDs, roomID)
+	}
+	rooms.Close()
+	if err := rooms.Err(); err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("Preventive maintenance: %s (due %s)", plan.Title, dueDate.Format("2006-01-02"))
+	if plan.Description != "" {
+		description += "\n\n" + plan.Description
+	}
+
+	requests := make([]models.MaintenanceRequest, 0, len(roomIDs))
+	for _, roomID := range roomIDs {
+		result, err := tx.Exec(`INSERT INTO maintenance_requests
+		          (room_id, reported_by, issue_type, description, priority, assigned_to, asset_id)
+		          VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			roomID, plan.CreatedBy, plan.IssueType, description, plan.Priority, plan.AssignedTo,
+			plan.AssetID)
+		if err != nil {
+			return nil, err
+		}
+		requestID, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := insertMaintenanceEvent(tx, &models.MaintenanceEvent{
+			RequestID: int(requestID),
+			EventType: "created",
+			ToStatus:  "pending",
+			Note:      fmt.Sprintf("generated from maintenance plan %d", plan.ID),
+		}); err != nil {
+			return nil, err
+		}
+
+		if _, err := tx.Exec(`INSERT INTO maintenance_plan_runs (plan_id, room_id, asset_id, due_date, request_id)
+		          VALUES (?, ?, ?, ?, ?)`, plan.ID, roomID, plan.AssetID, dueDate, requestID); err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, models.MaintenanceRequest{
+			ID:          int(requestID),
+			RoomID:      roomID,
+			ReportedBy:  plan.CreatedBy,
+			IssueType:   plan.IssueType,
+			Description: description,
+			Priority:    plan.Priority,
+			Status:      "pending",
+			AssignedTo:  plan.AssignedTo,
+			AssetID:     plan.AssetID,
+		})
+	}
+
+	if _, err := tx.Exec(`UPDATE maintenance_plans SET next_due_date = ? WHERE plan_id = ?`,
+		nextDue, plan.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// GetRuns returns generated occurrences due in [from, to), for every house
+// when houseId is zero.
+func (r *MaintenancePlanRepository) GetRuns(houseId int, from, to time.Time) ([]models.PlanRun, error) {
+	return r.queryRuns(`SELECT `+planRunColumns+`
+	          WHERE pr.due_date >= ? AND pr.due_date < ?
+	          AND (? = 0 OR p.house_id = ?)
+	          ORDER BY pr.due_date, pr.run_id`, from, to, houseId, houseId)
+}
+
+// GetRunsByPlan returns the completion history of a plan, newest first.
+func (r *MaintenancePlanRepository) GetRunsByPlan(planId int) ([]models.PlanRun, error) {
+	return r.queryRuns(`SELECT `+planRunColumns+`
+	          WHERE pr.plan_id = ?
+	          ORDER BY pr.due_date DESC, pr.run_id`, planId)
+}
+
+// GetRunsByRoom returns the preventive maintenance history of a room.
+func (r *MaintenancePlanRepository) GetRunsByRoom(roomId int) ([]models.PlanRun, error) {
+	return r.queryRuns(`SELECT `+planRunColumns+`
+	          WHERE pr.room_id = ?
+	          ORDER BY pr.due_date DESC, pr.run_id`, roomId)
+}
+
+// GetRunsByAsset returns the preventive maintenance history of an asset.
+func (r *MaintenancePlanRepository) GetRunsByAsset(assetId int) ([]models.PlanRun, error) {
+	return r.queryRuns(`SELECT `+planRunColumns+`
+	          WHERE pr.asset_id = ?
+	          ORDER BY pr.due_date DESC, pr.run_id`, assetId)
+}
+
+// GetAssetRoom returns the room and house of an active asset.
+func (r *MaintenancePlanRepository) GetAssetRoom(assetId int) (int, int, error) {
+	var roomID, houseID int
+	err := r.db.QueryRow(`SELECT a.room_id, r.house_id FROM assets a
+	          JOIN rooms r ON r.room_id = a.room_id
+	          WHERE a.asset_id = ? AND a.is_active = TRUE`, assetId).Scan(&roomID, &houseID)
+	return roomID, houseID, err
+}
+
+func (r *MaintenancePlanRepository) queryPlans(query string, args ...interface{}) ([]models.MaintenancePlan, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var plans []models.MaintenancePlan
+	for rows.Next() {
+		var plan models.MaintenancePlan
+		if err := scanMaintenancePlan(rows, &plan); err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	return plans, rows.Err()
+}
+
+func (r *MaintenancePlanRepository) queryRuns(query string, args ...interface{}) ([]models.PlanRun, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []models.PlanRun{}
+	for rows.Next() {
+		var run models.PlanRun
+		var completed sql.NullTime
+		err := rows.Scan(&run.ID, &run.PlanID, &run.PlanTitle, &run.RoomID, &run.RoomNumber,
+			&run.AssetID, &run.DueDate, &run.RequestID, &run.Status, &completed, &run.GeneratedAt)
+		if err != nil {
+			return nil, err
+		}
+		if completed.Valid {
+			run.CompletedDate = &completed.Time
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+func scanMaintenancePlan(row rowScanner, plan *models.MaintenancePlan) error {
+	return row.Scan(&plan.ID, &plan.HouseID, &plan.RoomID, &plan.AssetID, &plan.RoomType, &plan.Title,
+		&plan.Description, &plan.IssueType, &plan.Priority, &plan.IntervalMonths, &plan.LeadDays,
+		&plan.NextDueDate, &plan.AssignedTo, &plan.IsActive, &plan.CreatedBy, &plan.CreatedAt)
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
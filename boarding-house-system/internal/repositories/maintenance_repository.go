@@ -2,11 +2,33 @@ package repositories
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/Kimox23/boarding-house-app/internal/events"
 	"github.com/Kimox23/boarding-house-app/internal/models"
 )
 
+var (
+	ErrMaintenanceStatusChanged = errors.New("maintenance request status was changed by someone else")
+	ErrInvalidCommentParent     = errors.New("reply must belong to a visible comment on the same request")
+	ErrCostFromInvoices         = errors.New("cost is the total of the vendor invoices and cannot be edited")
+)
+
+const maintenanceColumns = `request_id, room_id, reported_by, issue_type, description, priority,
+	          status, reported_date, started_date, completed_date, cancelled_date,
+	          assigned_to, vendor_id, asset_id, cost`
+
+// maintenanceStatusDates sets the timestamp columns that go with entering
+// each status. Reopening clears the closing dates but keeps started_date.
+var maintenanceStatusDates = map[string]string{
+	"pending":     "completed_date = NULL, cancelled_date = NULL",
+	"in_progress": "started_date = COALESCE(started_date, NOW())",
+	"completed":   "completed_date = NOW()",
+	"cancelled":   "cancelled_date = NOW()",
+}
+
 type MaintenanceRepository struct {
 	db *sql.DB
 }
@@ -16,12 +38,18 @@ func NewMaintenanceRepository(db *sql.DB) *MaintenanceRepository {
 }
 
 func (r *MaintenanceRepository) CreateRequest(request *models.MaintenanceRequest) error {
-	query := `INSERT INTO maintenance_requests 
-	          (room_id, reported_by, issue_type, description, priority, assigned_to)
-	          VALUES (?, ?, ?, ?, ?, ?)`
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO maintenance_requests
+	          (room_id, reported_by, issue_type, description, priority, assigned_to, asset_id)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`
 
-	result, err := r.db.Exec(query, request.RoomID, request.ReportedBy, request.IssueType,
-		request.Description, request.Priority, request.AssignedTo)
+	result, err := tx.Exec(query, request.RoomID, request.ReportedBy, request.IssueType,
+		request.Description, request.Priority, request.AssignedTo, request.AssetID)
 	if err != nil {
 		return err
 	}
@@ -31,6 +59,19 @@ func (r *MaintenanceRepository) CreateRequest(request *models.MaintenanceRequest
 		return err
 	}
 
+	if err := insertMaintenanceEvent(tx, &models.MaintenanceEvent{
+		RequestID: int(id),
+		EventType: "created",
+		ToStatus:  "pending",
+		ActorID:   &request.ReportedBy,
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
 	request.ID = int(id)
 	request.ReportedDate = time.Now()
 	request.Status = "pending"
@@ -38,30 +79,18 @@ func (r *MaintenanceRepository) CreateRequest(request *models.MaintenanceRequest
 }
 
 func (r *MaintenanceRepository) GetRequest(id int) (*models.MaintenanceRequest, error) {
-	query := `SELECT request_id, room_id, reported_by, issue_type, description, priority,
-	          status, reported_date, completed_date, assigned_to, cost
+	query := `SELECT ` + maintenanceColumns + `
 	          FROM maintenance_requests WHERE request_id = ?`
 
-	row := r.db.QueryRow(query, id)
-
 	request := &models.MaintenanceRequest{}
-	var completedDate sql.NullTime
-	err := row.Scan(&request.ID, &request.RoomID, &request.ReportedBy, &request.IssueType,
-		&request.Description, &request.Priority, &request.Status, &request.ReportedDate,
-		&completedDate, &request.AssignedTo, &request.Cost)
-	if err != nil {
+	if err := scanMaintenanceRequest(r.db.QueryRow(query, id), request); err != nil {
 		return nil, err
 	}
-
-	if completedDate.Valid {
-		request.CompletedDate = &completedDate.Time
-	}
 	return request, nil
 }
 
 func (r *MaintenanceRepository) GetRequestsByRoom(roomId int) ([]models.MaintenanceRequest, error) {
-	query := `SELECT request_id, room_id, reported_by, issue_type, description, priority,
-	          status, reported_date, completed_date, assigned_to, cost
+	query := `SELECT ` + maintenanceColumns + `
 	          FROM maintenance_requests WHERE room_id = ?`
 
 	rows, err := r.db.Query(query, roomId)
@@ -73,46 +102,200 @@ func (r *MaintenanceRepository) GetRequestsByRoom(roomId int) ([]models.Maintena
 	var requests []models.MaintenanceRequest
 	for rows.Next() {
 		var request models.MaintenanceRequest
-		var completedDate sql.NullTime
-		err := rows.Scan(&request.ID, &request.RoomID, &request.ReportedBy, &request.IssueType,
-			&request.Description, &request.Priority, &request.Status, &request.ReportedDate,
-			&completedDate, &request.AssignedTo, &request.Cost)
-		if err != nil {
+		if err := scanMaintenanceRequest(rows, &request); err != nil {
 			return nil, err
 		}
-		if completedDate.Valid {
-			request.CompletedDate = &completedDate.Time
-		}
 		requests = append(requests, request)
 	}
 
 	return requests, nil
 }
 
-func (r *MaintenanceRepository) UpdateRequest(id int, request *models.MaintenanceRequest) error {
-	query := `UPDATE maintenance_requests SET 
-	          room_id = ?, issue_type = ?, description = ?, priority = ?, cost = ?
+// UpdateRequest edits a request. Once vendor invoices exist the cost is
+// their total, so a different cost is rejected.
+func (r *MaintenanceRepository) UpdateRequest(id int, request *models.MaintenanceRequest, actorID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var cost sql.NullFloat64
+	var invoiced bool
+	err = tx.QueryRow(`SELECT cost, EXISTS (SELECT 1 FROM vendor_invoices WHERE request_id = ?)
+	          FROM maintenance_requests WHERE request_id = ? FOR UPDATE`, id, id).Scan(&cost, &invoiced)
+	if err != nil {
+		return err
+	}
+	if invoiced {
+		if request.Cost != nil && (!cost.Valid || *request.Cost != cost.Float64) {
+			return ErrCostFromInvoices
+		}
+		request.Cost = &cost.Float64
+	}
+
+	query := `UPDATE maintenance_requests SET
+	          room_id = ?, issue_type = ?, description = ?, priority = ?, asset_id = ?, cost = ?
 	          WHERE request_id = ?`
 
-	_, err := r.db.Exec(query, request.RoomID, request.IssueType, request.Description,
-		request.Priority, request.Cost, id)
-	return err
+	_, err = tx.Exec(query, request.RoomID, request.IssueType, request.Description,
+		request.Priority, request.AssetID, request.Cost, id)
+	if err != nil {
+		return err
+	}
+
+	if err := insertMaintenanceEvent(tx, &models.MaintenanceEvent{
+		RequestID: id,
+		EventType: "updated",
+		ActorID:   &actorID,
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (r *MaintenanceRepository) UpdateRequestStatus(id int, status string, assignedTo *int) error {
-	query := `UPDATE maintenance_requests SET 
-	          status = ?, assigned_to = ?, completed_date = ?
-	          WHERE request_id = ?`
+// ChangeStatusAndAssignee applies a status change and a reassignment, with their
+// timeline entries, in a single transaction. An empty to leaves the status
+// alone and a nil assignedTo leaves the assignee alone.
+func (r *MaintenanceRepository) ChangeStatusAndAssignee(id int, from, to string, assignedTo *int, actorID int, note string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	var completedDate interface{}
-	if status == "completed" {
-		completedDate = time.Now()
-	} else {
-		completedDate = nil
+	if assignedTo != nil {
+		if err := assignRequest(tx, id, assignedTo, actorID); err != nil {
+			return err
+		}
+	}
+	if to != "" {
+		if err := updateRequestStatus(tx, id, from, to, actorID, note); err != nil {
+			return err
+		}
 	}
+	return tx.Commit()
+}
 
-	_, err := r.db.Exec(query, status, assignedTo, completedDate, id)
-	return err
+// updateRequestStatus moves a request from one status to another, stamping
+// the matching date column and recording the change on the timeline. The
+// update only applies while the request is still in the from status.
+func updateRequestStatus(tx *sql.Tx, id int, from, to string, actorID int, note string) error {
+	dates, ok := maintenanceStatusDates[to]
+	if !ok {
+		return fmt.Errorf("unknown maintenance status %q", to)
+	}
+
+	result, err := tx.Exec(`UPDATE maintenance_requests SET status = ?, `+dates+`
+	          WHERE request_id = ? AND status = ?`, to, id, from)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrMaintenanceStatusChanged
+	}
+
+	if err := insertMaintenanceEvent(tx, &models.MaintenanceEvent{
+		RequestID:  id,
+		EventType:  "status_changed",
+		FromStatus: from,
+		ToStatus:   to,
+		ActorID:    &actorID,
+		Note:       note,
+	}); err != nil {
+		return err
+	}
+
+	event := events.MaintenanceStatusChanged{
+		RequestID:  id,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorID:    actorID,
+		Note:       note,
+	}
+	var assignedTo sql.NullInt64
+	if err := tx.QueryRow(`SELECT room_id, reported_by, assigned_to, issue_type
+	          FROM maintenance_requests WHERE request_id = ?`, id).
+		Scan(&event.RoomID, &event.ReportedBy, &assignedTo, &event.IssueType); err != nil {
+		return err
+	}
+	if assignedTo.Valid {
+		assignee := int(assignedTo.Int64)
+		event.AssignedTo = &assignee
+	}
+	return insertOutboxEvent(tx, event)
+}
+
+// assignRequest sets the staff member responsible for a request.
+func assignRequest(tx *sql.Tx, id int, assignedTo *int, actorID int) error {
+	if _, err := tx.Exec(`UPDATE maintenance_requests SET assigned_to = ? WHERE request_id = ?`,
+		assignedTo, id); err != nil {
+		return err
+	}
+
+	note := "unassigned"
+	if assignedTo != nil {
+		note = fmt.Sprintf("assigned to user %d", *assignedTo)
+	}
+	return insertMaintenanceEvent(tx, &models.MaintenanceEvent{
+		RequestID: id,
+		EventType: "assigned",
+		ActorID:   &actorID,
+		Note:      note,
+	})
+}
+
+// GetEvents returns a request's timeline, oldest first.
+func (r *MaintenanceRepository) GetEvents(requestId int) ([]models.MaintenanceEvent, error) {
+	query := `SELECT event_id, request_id, event_type, COALESCE(from_status, ''),
+	          COALESCE(to_status, ''), actor_id, COALESCE(note, ''), created_at
+	          FROM maintenance_events WHERE request_id = ?
+	          ORDER BY created_at, event_id`
+
+	rows, err := r.db.Query(query, requestId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.MaintenanceEvent{}
+	for rows.Next() {
+		var event models.MaintenanceEvent
+		var actorID sql.NullInt64
+		err := rows.Scan(&event.ID, &event.RequestID, &event.EventType, &event.FromStatus,
+			&event.ToStatus, &actorID, &event.Note, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if actorID.Valid {
+			id := int(actorID.Int64)
+			event.ActorID = &id
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// HasApprovedQuote reports whether the request's vendor has an approved quote.
+func (r *MaintenanceRepository) HasApprovedQuote(requestId, vendorId int) (bool, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM vendor_quotes
+	          WHERE request_id = ? AND vendor_id = ? AND status = 'approved'`,
+		requestId, vendorId).Scan(&count)
+	return count > 0, err
+}
+
+// AssetInRoom reports whether an active asset is in the given room.
+func (r *MaintenanceRepository) AssetInRoom(assetId, roomId int) (bool, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM assets
+	          WHERE asset_id = ? AND room_id = ? AND is_active = TRUE`,
+		assetId, roomId).Scan(&count)
+	return count > 0, err
 }
 
 func (r *MaintenanceRepository) DeleteRequest(id int) error {
@@ -120,3 +303,203 @@ func (r *MaintenanceRepository) DeleteRequest(id int) error {
 	_, err := r.db.Exec(query, id)
 	return err
 }
+
+// CreateComment stores a comment and its attachments. A public reply cannot
+// be threaded under an internal note, since tenants cannot see the parent.
+func (r *MaintenanceRepository) CreateComment(comment *models.MaintenanceComment) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if comment.ParentID != nil {
+		var requestID int
+		var parentInternal bool
+		err := tx.QueryRow(`SELECT request_id, is_internal FROM maintenance_comments
+		          WHERE comment_id = ?`, *comment.ParentID).Scan(&requestID, &parentInternal)
+		if err == sql.ErrNoRows {
+			return ErrInvalidCommentParent
+		}
+		if err != nil {
+			return err
+		}
+		if requestID != comment.RequestID || (parentInternal && !comment.IsInternal) {
+			return ErrInvalidCommentParent
+		}
+	}
+
+	result, err := tx.Exec(`INSERT INTO maintenance_comments
+	          (request_id, parent_id, author_id, body, is_internal)
+	          VALUES (?, ?, ?, ?, ?)`,
+		comment.RequestID, comment.ParentID, comment.AuthorID, comment.Body, comment.IsInternal)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range comment.Attachments {
+		attachment := &comment.Attachments[i]
+		attachment.CommentID = int(id)
+		result, err := tx.Exec(`INSERT INTO maintenance_attachments
+		          (comment_id, file_path, original_name, media_type, file_size)
+		          VALUES (?, ?, ?, ?, ?)`,
+			attachment.CommentID, attachment.FilePath, attachment.OriginalName,
+			attachment.MediaType, attachment.FileSize)
+		if err != nil {
+			return err
+		}
+		attachmentID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		attachment.ID = int(attachmentID)
+		attachment.UploadedAt = now
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	comment.ID = int(id)
+	comment.CreatedAt = now
+	return nil
+}
+
+// GetComments returns a request's comments oldest first with their
+// attachments. Internal staff notes are left out unless requested.
+func (r *MaintenanceRepository) GetComments(requestId int, includeInternal bool) ([]models.MaintenanceComment, error) {
+	rows, err := r.db.Query(`SELECT c.comment_id, c.request_id, c.parent_id, c.author_id,
+	          u.username, u.role, c.body, c.is_internal, c.created_at
+	          FROM maintenance_comments c
+	          JOIN users u ON u.user_id = c.author_id
+	          WHERE c.request_id = ? AND (? OR c.is_internal = FALSE)
+	          ORDER BY c.created_at, c.comment_id`, requestId, includeInternal)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []models.MaintenanceComment
+	index := make(map[int]int)
+	for rows.Next() {
+		var comment models.MaintenanceComment
+		var parentID sql.NullInt64
+		err := rows.Scan(&comment.ID, &comment.RequestID, &parentID, &comment.AuthorID,
+			&comment.AuthorName, &comment.AuthorRole, &comment.Body, &comment.IsInternal,
+			&comment.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if parentID.Valid {
+			id := int(parentID.Int64)
+			comment.ParentID = &id
+		}
+		comment.Attachments = []models.MaintenanceAttachment{}
+		index[comment.ID] = len(comments)
+		comments = append(comments, comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	attachments, err := r.db.Query(`SELECT a.attachment_id, a.comment_id, a.file_path,
+	          COALESCE(a.original_name, ''), a.media_type, a.file_size, a.uploaded_at
+	          FROM maintenance_attachments a
+	          JOIN maintenance_comments c ON c.comment_id = a.comment_id
+	          WHERE c.request_id = ?
+	          ORDER BY a.attachment_id`, requestId)
+	if err != nil {
+		return nil, err
+	}
+	defer attachments.Close()
+
+	for attachments.Next() {
+		var attachment models.MaintenanceAttachment
+		err := attachments.Scan(&attachment.ID, &attachment.CommentID, &attachment.FilePath,
+			&attachment.OriginalName, &attachment.MediaType, &attachment.FileSize,
+			&attachment.UploadedAt)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := index[attachment.CommentID]; ok {
+			comments[i].Attachments = append(comments[i].Attachments, attachment)
+		}
+	}
+	return comments, attachments.Err()
+}
+
+// GetParticipants returns the users involved in a request: the reporter,
+// the assignee and everyone who has commented. With staffOnly set, tenants
+// are left out.
+func (r *MaintenanceRepository) GetParticipants(requestId int, staffOnly bool) ([]int, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT u.user_id FROM users u
+	          JOIN (
+	              SELECT reported_by AS user_id FROM maintenance_requests WHERE request_id = ?
+	              UNION SELECT assigned_to FROM maintenance_requests WHERE request_id = ?
+	              UNION SELECT author_id FROM maintenance_comments WHERE request_id = ?
+	          ) p ON p.user_id = u.user_id
+	          WHERE u.is_active = TRUE AND (? = FALSE OR u.role <> 'tenant')`,
+		requestId, requestId, requestId, staffOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		users = append(users, id)
+	}
+	return users, rows.Err()
+}
+
+func insertMaintenanceEvent(tx *sql.Tx, event *models.MaintenanceEvent) error {
+	_, err := tx.Exec(`INSERT INTO maintenance_events
+	          (request_id, event_type, from_status, to_status, actor_id, note)
+	          VALUES (?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, NULLIF(?, ''))`,
+		event.RequestID, event.EventType, event.FromStatus, event.ToStatus,
+		event.ActorID, event.Note)
+	return err
+}
+
+// scanMaintenanceRequest scans the maintenance columns followed by any extra
+// destinations.
+func scanMaintenanceRequest(row rowScanner, request *models.MaintenanceRequest, extra ...interface{}) error {
+	var startedDate, completedDate, cancelledDate sql.NullTime
+	dest := []interface{}{&request.ID, &request.RoomID, &request.ReportedBy, &request.IssueType,
+		&request.Description, &request.Priority, &request.Status, &request.ReportedDate,
+		&startedDate, &completedDate, &cancelledDate, &request.AssignedTo, &request.VendorID,
+		&request.AssetID, &request.Cost}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+
+	if startedDate.Valid {
+		request.StartedDate = &startedDate.Time
+	}
+	if completedDate.Valid {
+		request.CompletedDate = &completedDate.Time
+	}
+	if cancelledDate.Valid {
+		request.CancelledDate = &cancelledDate.Time
+	}
+	return nil
+}
+
+// IsTenantOfRoom reports whether a user is an active tenant of a room.
+func (r *MaintenanceRepository) IsTenantOfRoom(userId, roomId int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (
+	          SELECT 1 FROM tenants WHERE user_id = ? AND room_id = ? AND status = 'active')`,
+		userId, roomId).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,314 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+)
+
+// ConversationFilter narrows a conversation list to one tenant, one house,
+// the houses a staff member works in or one status. Zero values match
+// everything.
+type ConversationFilter struct {
+	TenantUserID int
+	HouseID      int
+	StaffUserID  int
+	Status       string
+}
+
+type MessageRepository struct {
+	db *sql.DB
+}
+
+func NewMessageRepository(db *sql.DB) *MessageRepository {
+	return &MessageRepository{db: db}
+}
+
+// Messages are unread by the staff side when the tenant sent them and by
+// the tenant when staff sent them. unreadBy is bound to whether the viewer
+// is staff.
+const unreadBy = `m.read_at IS NULL AND (m.sender_id = c.tenant_user_id) = ?`
+
+// staffHouses selects the houses a staff member manages or is assigned to
+// in their staff profile. It is bound to the staff user id twice.
+const staffHouses = `(SELECT h.house_id FROM boarding_houses h WHERE h.manager_id = ?
+	UNION SELECT p.house_id FROM staff_profiles p WHERE p.user_id = ? AND p.house_id IS NOT NULL)`
+
+const conversationColumns = `c.conversation_id, c.house_id, h.name, c.tenant_user_id, u.username,
+	c.subject, c.status, c.started_by, c.last_message_at, c.created_at`
+
+// GetTenantHouse returns the house a user currently rents a room in.
+func (r *MessageRepository) GetTenantHouse(userId int) (int, error) {
+	var houseID int
+	err := r.db.QueryRow(`SELECT r.house_id FROM tenants t
+	          JOIN rooms r ON r.room_id = t.room_id
+	          WHERE t.user_id = ? AND t.status = 'active'`, userId).Scan(&houseID)
+	return houseID, err
+}
+
+// WorksInHouse reports whether a staff member manages a house or is
+// assigned to it.
+func (r *MessageRepository) WorksInHouse(userId, houseId int) (bool, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM `+staffHouses+` s WHERE s.house_id = ?`,
+		userId, userId, houseId).Scan(&count)
+	return count > 0, err
+}
+
+// CreateConversation starts a conversation with its first message.
+func (r *MessageRepository) CreateConversation(conversation *models.Conversation, message *models.Message) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO conversations (house_id, tenant_user_id, subject, started_by)
+	          VALUES (?, ?, ?, ?)`,
+		conversation.HouseID, conversation.TenantUserID, conversation.Subject, conversation.StartedBy)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	conversation.ID = int(id)
+	message.ConversationID = conversation.ID
+	if err := insertMessage(tx, message); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	conversation.Status = "open"
+	conversation.CreatedAt = message.CreatedAt
+	conversation.LastMessageAt = message.CreatedAt
+	return nil
+}
+
+// CreateMessage adds a message to a conversation and reopens it if it was
+// closed.
+func (r *MessageRepository) CreateMessage(message *models.Message) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertMessage(tx, message); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE conversations SET last_message_at = CURRENT_TIMESTAMP, status = 'open'
+	          WHERE conversation_id = ?`, message.ConversationID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetConversation returns a conversation with the number of messages the
+// viewing side has not read.
+func (r *MessageRepository) GetConversation(id int, staffView bool) (*models.Conversation, error) {
+	conversation := &models.Conversation{}
+	err := r.db.QueryRow(`SELECT `+conversationColumns+`,
+	          (SELECT COUNT(*) FROM messages m WHERE m.conversation_id = c.conversation_id AND `+unreadBy+`)
+	          FROM conversations c
+	          JOIN boarding_houses h ON h.house_id = c.house_id
+	          JOIN users u ON u.user_id = c.tenant_user_id
+	          WHERE c.conversation_id = ?`, staffView, id).
+		Scan(&conversation.ID, &conversation.HouseID, &conversation.HouseName, &conversation.TenantUserID,
+			&conversation.TenantName, &conversation.Subject, &conversation.Status, &conversation.StartedBy,
+			&conversation.LastMessageAt, &conversation.CreatedAt, &conversation.UnreadCount)
+	if err != nil {
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// GetConversations returns the matching conversations with the latest
+// activity first.
+func (r *MessageRepository) GetConversations(filter ConversationFilter, staffView bool) ([]models.Conversation, error) {
+	rows, err := r.db.Query(`SELECT `+conversationColumns+`,
+	          (SELECT COUNT(*) FROM messages m WHERE m.conversation_id = c.conversation_id AND `+unreadBy+`)
+	          FROM conversations c
+	          JOIN boarding_houses h ON h.house_id = c.house_id
+	          JOIN users u ON u.user_id = c.tenant_user_id
+	          WHERE (? = 0 OR c.tenant_user_id = ?) AND (? = 0 OR c.house_id = ?)
+	          AND (? = 0 OR c.house_id IN `+staffHouses+`) AND (? = '' OR c.status = ?)
+	          ORDER BY c.last_message_at DESC, c.conversation_id DESC`,
+		staffView, filter.TenantUserID, filter.TenantUserID, filter.HouseID, filter.HouseID,
+		filter.StaffUserID, filter.StaffUserID, filter.StaffUserID, filter.Status, filter.Status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conversations := []models.Conversation{}
+	for rows.Next() {
+		var conversation models.Conversation
+		if err := rows.Scan(&conversation.ID, &conversation.HouseID, &conversation.HouseName,
+			&conversation.TenantUserID, &conversation.TenantName, &conversation.Subject, &conversation.Status,
+			&conversation.StartedBy, &conversation.LastMessageAt, &conversation.CreatedAt,
+			&conversation.UnreadCount); err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, conversation)
+	}
+	return conversations, rows.Err()
+}
+
+// GetMessages returns a conversation's messages, oldest first, with their
+// attachments.
+func (r *MessageRepository) GetMessages(conversationId int) ([]models.Message, error) {
+	rows, err := r.db.Query(`SELECT m.message_id, m.conversation_id, m.sender_id, u.username, u.role,
+	          m.body, m.read_at, m.created_at
+	          FROM messages m
+	          JOIN users u ON u.user_id = m.sender_id
+	          WHERE m.conversation_id = ?
+	          ORDER BY m.created_at, m.message_id`, conversationId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []models.Message{}
+	index := make(map[int]int)
+	for rows.Next() {
+		var message models.Message
+		var readAt sql.NullTime
+		if err := rows.Scan(&message.ID, &message.ConversationID, &message.SenderID, &message.SenderName,
+			&message.SenderRole, &message.Body, &readAt, &message.CreatedAt); err != nil {
+			return nil, err
+		}
+		if readAt.Valid {
+			message.ReadAt = &readAt.Time
+		}
+		message.Attachments = []models.MessageAttachment{}
+		index[message.ID] = len(messages)
+		messages = append(messages, message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	attachments, err := r.db.Query(`SELECT a.attachment_id, a.message_id, a.file_path,
+	          COALESCE(a.original_name, ''), a.file_size, a.uploaded_at
+	          FROM message_attachments a
+	          JOIN messages m ON m.message_id = a.message_id
+	          WHERE m.conversation_id = ?
+	          ORDER BY a.attachment_id`, conversationId)
+	if err != nil {
+		return nil, err
+	}
+	defer attachments.Close()
+
+	for attachments.Next() {
+		var attachment models.MessageAttachment
+		if err := attachments.Scan(&attachment.ID, &attachment.MessageID, &attachment.FilePath,
+			&attachment.OriginalName, &attachment.FileSize, &attachment.UploadedAt); err != nil {
+			return nil, err
+		}
+		if i, ok := index[attachment.MessageID]; ok {
+			messages[i].Attachments = append(messages[i].Attachments, attachment)
+		}
+	}
+	return messages, attachments.Err()
+}
+
+// MarkRead marks the messages the viewing side received in a conversation
+// as read and returns how many changed.
+func (r *MessageRepository) MarkRead(conversationId int, staffView bool) (int64, error) {
+	result, err := r.db.Exec(`UPDATE messages m
+	          JOIN conversations c ON c.conversation_id = m.conversation_id
+	          SET m.read_at = CURRENT_TIMESTAMP
+	          WHERE m.conversation_id = ? AND `+unreadBy, conversationId, staffView)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// CountUnread returns how many messages a tenant has not read, or, for
+// staff, how many tenant messages in the matching conversations no staff
+// member has read.
+func (r *MessageRepository) CountUnread(filter ConversationFilter, staffView bool) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM messages m
+	          JOIN conversations c ON c.conversation_id = m.conversation_id
+	          WHERE `+unreadBy+` AND (? = 0 OR c.tenant_user_id = ?) AND (? = 0 OR c.house_id = ?)
+	          AND (? = 0 OR c.house_id IN `+staffHouses+`)`,
+		staffView, filter.TenantUserID, filter.TenantUserID, filter.HouseID, filter.HouseID,
+		filter.StaffUserID, filter.StaffUserID, filter.StaffUserID).Scan(&count)
+	return count, err
+}
+
+func (r *MessageRepository) UpdateStatus(conversationId int, status string) error {
+	_, err := r.db.Exec(`UPDATE conversations SET status = ? WHERE conversation_id = ?`, status, conversationId)
+	return err
+}
+
+// GetStaffRecipients returns the active staff to notify about a tenant's
+// message: the house manager and every staff member who has written in the
+// conversation.
+func (r *MessageRepository) GetStaffRecipients(conversationId int) ([]int, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT u.user_id FROM users u
+	          JOIN (
+	              SELECT h.manager_id AS user_id FROM conversations c
+	              JOIN boarding_houses h ON h.house_id = c.house_id
+	              WHERE c.conversation_id = ?
+	              UNION SELECT sender_id FROM messages WHERE conversation_id = ?
+	          ) p ON p.user_id = u.user_id
+	          WHERE u.is_active = TRUE AND u.role <> 'tenant'`, conversationId, conversationId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		users = append(users, id)
+	}
+	return users, rows.Err()
+}
+
+func insertMessage(tx *sql.Tx, message *models.Message) error {
+	result, err := tx.Exec(`INSERT INTO messages (conversation_id, sender_id, body) VALUES (?, ?, ?)`,
+		message.ConversationID, message.SenderID, message.Body)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	message.ID = int(id)
+	message.CreatedAt = now
+	for i := range message.Attachments {
+		attachment := &message.Attachments[i]
+		attachment.MessageID = message.ID
+		result, err := tx.Exec(`INSERT INTO message_attachments (message_id, file_path, original_name, file_size)
+		          VALUES (?, ?, ?, ?)`,
+			attachment.MessageID, attachment.FilePath, attachment.OriginalName, attachment.FileSize)
+		if err != nil {
+			return err
+		}
+		attachmentID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		attachment.ID = int(attachmentID)
+		attachment.UploadedAt = now
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+)
+
+// DeliveryJob is a pending delivery with what is needed to send it.
+type DeliveryJob struct {
+	Delivery models.NotificationDelivery
+	UserID   int
+	Email    string
+	Phone    string
+	Title    string
+	Message  string
+	Link     string
+}
+
+type NotificationDeliveryRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationDeliveryRepository(db *sql.DB) *NotificationDeliveryRepository {
+	return &NotificationDeliveryRepository{db: db}
+}
+
+// GetPreferences returns a user's preferences, or the defaults (email on,
+// SMS off, English, no quiet hours) when they have not set any.
+func (r *NotificationDeliveryRepository) GetPreferences(userId int) (*models.NotificationPreferences, error) {
+	prefs := &models.NotificationPreferences{UserID: userId, EmailEnabled: true, Locale: DefaultLocale}
+	err := r.db.QueryRow(`SELECT email_enabled, sms_enabled, COALESCE(locale, ''),
+	          COALESCE(quiet_hours_start, ''), COALESCE(quiet_hours_end, ''), updated_at
+	          FROM notification_preferences WHERE user_id = ?`, userId).
+		Scan(&prefs.EmailEnabled, &prefs.SMSEnabled, &prefs.Locale, &prefs.QuietHoursStart,
+			&prefs.QuietHoursEnd, &prefs.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return prefs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if prefs.Locale == "" {
+		prefs.Locale = DefaultLocale
+	}
+	return prefs, nil
+}
+
+func (r *NotificationDeliveryRepository) UpsertPreferences(prefs *models.NotificationPreferences) error {
+	_, err := r.db.Exec(`INSERT INTO notification_preferences
+	          (user_id, email_enabled, sms_enabled, locale, quiet_hours_start, quiet_hours_end)
+	          VALUES (?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''))
+	          ON DUPLICATE KEY UPDATE email_enabled = VALUES(email_enabled),
+	          sms_enabled = VALUES(sms_enabled), locale = VALUES(locale),
+	          quiet_hours_start = VALUES(quiet_hours_start), quiet_hours_end = VALUES(quiet_hours_end)`,
+		prefs.UserID, prefs.EmailEnabled, prefs.SMSEnabled, prefs.Locale, prefs.QuietHoursStart,
+		prefs.QuietHoursEnd)
+	if err != nil {
+		return err
+	}
+	prefs.UpdatedAt = time.Now()
+	return nil
+}
+
+// CreateDelivery records a notification on one channel. A notification is
+// delivered at most once per channel, so duplicates are ignored.
+func (r *NotificationDeliveryRepository) CreateDelivery(delivery *models.NotificationDelivery) error {
+	result, err := r.db.Exec(`INSERT IGNORE INTO notification_deliveries
+	          (notification_id, channel, status, last_error, next_attempt_at, sent_at)
+	          VALUES (?, ?, ?, NULLIF(?, ''), ?, ?)`,
+		delivery.NotificationID, delivery.Channel, delivery.Status, delivery.LastError,
+		delivery.NextAttemptAt, delivery.SentAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	delivery.ID = int(id)
+	delivery.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *NotificationDeliveryRepository) GetDeliveries(notificationId int) ([]models.NotificationDelivery, error) {
+	rows, err := r.db.Query(`SELECT delivery_id, notification_id, channel, status, attempts,
+	          COALESCE(last_error, ''), next_attempt_at, sent_at, created_at
+	          FROM notification_deliveries WHERE notification_id = ?
+	          ORDER BY delivery_id`, notificationId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.NotificationDelivery{}
+	for rows.Next() {
+		var delivery models.NotificationDelivery
+		if err := scanDelivery(rows, &delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// GetDueDeliveries returns up to limit pending deliveries whose next attempt
+// is at or before asOf, oldest first.
+func (r *NotificationDeliveryRepository) GetDueDeliveries(asOf time.Time, limit int) ([]DeliveryJob, error) {
+	rows, err := r.db.Query(`SELECT d.delivery_id, d.notification_id, d.channel, d.status, d.attempts,
+	          COALESCE(d.last_error, ''), d.next_attempt_at, d.sent_at, d.created_at,
+	          n.user_id, u.email, COALESCE(u.phone, ''), n.title, n.message, COALESCE(n.link, '')
+	          FROM notification_deliveries d
+	          JOIN notifications n ON n.notification_id = d.notification_id
+	          JOIN users u ON u.user_id = n.user_id
+	          WHERE d.status = 'pending' AND d.next_attempt_at <= ?
+	          ORDER BY d.next_attempt_at
+	          LIMIT ?`, asOf, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []DeliveryJob
+	for rows.Next() {
+		var job DeliveryJob
+		if err := scanDelivery(rows, &job.Delivery, &job.UserID, &job.Email, &job.Phone,
+			&job.Title, &job.Message, &job.Link); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// Claim pushes a due delivery's next attempt out by lease so that no other
+// instance picks it up while it is being sent. It returns false when the
+// delivery was already claimed.
+func (r *NotificationDeliveryRepository) Claim(id int, asOf time.Time, lease time.Duration) (bool, error) {
+	result, err := r.db.Exec(`UPDATE notification_deliveries SET next_attempt_at = ?
+	          WHERE delivery_id = ? AND status = 'pending' AND next_attempt_at <= ?`,
+		asOf.Add(lease), id, asOf)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *NotificationDeliveryRepository) MarkSent(id int) error {
+	_, err := r.db.Exec(`UPDATE notification_deliveries
+	          SET status = 'sent', attempts = attempts + 1, last_error = NULL,
+	          next_attempt_at = NULL, sent_at = CURRENT_TIMESTAMP
+	          WHERE delivery_id = ?`, id)
+	return err
+}
+
+// MarkAttemptFailed records a failed attempt. A nil retryAt gives up and
+// marks the delivery failed.
+func (r *NotificationDeliveryRepository) MarkAttemptFailed(id int, reason string, retryAt *time.Time) error {
+	status := "pending"
+	if retryAt == nil {
+		status = "failed"
+	}
+	_, err := r.db.Exec(`UPDATE notification_deliveries
+	          SET status = ?, attempts = attempts + 1, last_error = LEFT(?, 255), next_attempt_at = ?
+	          WHERE delivery_id = ?`, status, reason, retryAt, id)
+	return err
+}
+
+// Skip closes a delivery that will not be attempted, such as when the user
+// turned the channel off or has no phone number.
+func (r *NotificationDeliveryRepository) Skip(id int, reason string) error {
+	_, err := r.db.Exec(`UPDATE notification_deliveries
+	          SET status = 'skipped', last_error = LEFT(?, 255), next_attempt_at = NULL
+	          WHERE delivery_id = ?`, reason, id)
+	return err
+}
+
+// Reschedule moves a delivery's next attempt without counting an attempt.
+func (r *NotificationDeliveryRepository) Reschedule(id int, at time.Time) error {
+	_, err := r.db.Exec(`UPDATE notification_deliveries SET next_attempt_at = ?
+	          WHERE delivery_id = ?`, at, id)
+	return err
+}
+
+func scanDelivery(row rowScanner, delivery *models.NotificationDelivery, extra ...interface{}) error {
+	var nextAttemptAt, sentAt sql.NullTime
+	dest := []interface{}{&delivery.ID, &delivery.NotificationID, &delivery.Channel, &delivery.Status,
+		&delivery.Attempts, &delivery.LastError, &nextAttemptAt, &sentAt, &delivery.CreatedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	if nextAttemptAt.Valid {
+		delivery.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if sentAt.Valid {
+		delivery.SentAt = &sentAt.Time
+	}
+	return nil
+}
@@ -2,13 +2,29 @@ package repositories
 
 import (
 	"database/sql"
+	"strings"
 	"time"
 
 	"github.com/Kimox23/boarding-house-app/internal/models"
 )
 
+// NotificationListener is called after a user's notifications change. The
+// notification is nil when one was read or deleted rather than created.
+type NotificationListener func(userID int, notification *models.Notification)
+
+// DefaultCategory is the category of notifications created without one.
+const DefaultCategory = "general"
+
+// InboxFilter narrows a user's inbox to unread notifications or to one
+// category.
+type InboxFilter struct {
+	UnreadOnly bool
+	Category   string
+}
+
 type NotificationRepository struct {
-	db *sql.DB
+	db        *sql.DB
+	listeners []NotificationListener
 }
 
 func NewNotificationRepository(db *sql.DB) *NotificationRepository {
@@ -17,11 +33,14 @@ func NewNotificationRepository(db *sql.DB) *NotificationRepository {
 
 func (r *NotificationRepository) CreateNotification(notification *models.Notification) error {
 	query := `INSERT INTO notifications 
-	          (user_id, title, message, link)
-	          VALUES (?, ?, ?, ?)`
+	          (user_id, title, message, link, category)
+	          VALUES (?, ?, ?, ?, ?)`
 
+	if notification.Category == "" {
+		notification.Category = DefaultCategory
+	}
 	result, err := r.db.Exec(query, notification.UserID, notification.Title,
-		notification.Message, notification.Link)
+		notification.Message, notification.Link, notification.Category)
 	if err != nil {
 		return err
 	}
@@ -34,11 +53,38 @@ func (r *NotificationRepository) CreateNotification(notification *models.Notific
 	notification.ID = int(id)
 	notification.CreatedAt = time.Now()
 	notification.IsRead = false
+	r.notify(notification.UserID, notification)
 	return nil
 }
 
+// Listen registers a listener for notification changes. Listeners must be
+// registered before the repository is used.
+func (r *NotificationRepository) Listen(listener NotificationListener) {
+	r.listeners = append(r.listeners, listener)
+}
+
+func (r *NotificationRepository) notify(userID int, notification *models.Notification) {
+	for _, listener := range r.listeners {
+		listener(userID, notification)
+	}
+}
+
+func (r *NotificationRepository) CountUnread(userId int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = ? AND is_read = false`,
+		userId).Scan(&count)
+	return count, err
+}
+
+// GetOwner returns the ID of the user a notification belongs to.
+func (r *NotificationRepository) GetOwner(id int) (int, error) {
+	var userID int
+	err := r.db.QueryRow(`SELECT user_id FROM notifications WHERE notification_id = ?`, id).Scan(&userID)
+	return userID, err
+}
+
 func (r *NotificationRepository) GetUserNotifications(userId int) ([]models.Notification, error) {
-	query := `SELECT notification_id, user_id, title, message, is_read, created_at, link
+	query := `SELECT notification_id, user_id, title, message, is_read, created_at, link, category
 	          FROM notifications WHERE user_id = ?
 	          ORDER BY created_at DESC`
 
@@ -53,7 +99,7 @@ func (r *NotificationRepository) GetUserNotifications(userId int) ([]models.Noti
 		var notification models.Notification
 		err := rows.Scan(&notification.ID, &notification.UserID, &notification.Title,
 			&notification.Message, &notification.IsRead, &notification.CreatedAt,
-			&notification.Link)
+			&notification.Link, &notification.Category)
 		if err != nil {
 			return nil, err
 		}
@@ -64,13 +110,139 @@ func (r *NotificationRepository) GetUserNotifications(userId int) ([]models.Noti
 }
 
 func (r *NotificationRepository) MarkAsRead(id int) error {
+	userID, err := r.GetOwner(id)
+	if err != nil {
+		return err
+	}
 	query := `UPDATE notifications SET is_read = true WHERE notification_id = ?`
-	_, err := r.db.Exec(query, id)
-	return err
+	if _, err := r.db.Exec(query, id); err != nil {
+		return err
+	}
+	r.notify(userID, nil)
+	return nil
 }
 
 func (r *NotificationRepository) DeleteNotification(id int) error {
+	userID, err := r.GetOwner(id)
+	if err != nil {
+		return err
+	}
 	query := `DELETE FROM notifications WHERE notification_id = ?`
-	_, err := r.db.Exec(query, id)
-	return err
+	if _, err := r.db.Exec(query, id); err != nil {
+		return err
+	}
+	r.notify(userID, nil)
+	return nil
+}
+
+// GetInbox returns one page of a user's notifications, newest first, and
+// how many match the filter in total.
+func (r *NotificationRepository) GetInbox(userId int, filter InboxFilter, page, pageSize int) ([]models.Notification, int, error) {
+	where := `user_id = ? AND (? = FALSE OR is_read = FALSE) AND (? = '' OR category = ?)`
+	args := []interface{}{userId, filter.UnreadOnly, filter.Category, filter.Category}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`SELECT notification_id, user_id, title, message, is_read, created_at,
+	          COALESCE(link, ''), category
+	          FROM notifications WHERE `+where+`
+	          ORDER BY created_at DESC, notification_id DESC
+	          LIMIT ? OFFSET ?`, append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var notification models.Notification
+		if err := rows.Scan(&notification.ID, &notification.UserID, &notification.Title,
+			&notification.Message, &notification.IsRead, &notification.CreatedAt,
+			&notification.Link, &notification.Category); err != nil {
+			return nil, 0, err
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, total, rows.Err()
+}
+
+// CountUnreadByCategory returns how many unread notifications a user has in
+// each category that has any.
+func (r *NotificationRepository) CountUnreadByCategory(userId int) (map[string]int, error) {
+	rows, err := r.db.Query(`SELECT category, COUNT(*) FROM notifications
+	          WHERE user_id = ? AND is_read = FALSE
+	          GROUP BY category`, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var category string
+		var count int
+		if err := rows.Scan(&category, &count); err != nil {
+			return nil, err
+		}
+		counts[category] = count
+	}
+	return counts, rows.Err()
+}
+
+// MarkAllRead marks a user's unread notifications read, optionally only
+// those of one category, and returns how many changed.
+func (r *NotificationRepository) MarkAllRead(userId int, category string) (int64, error) {
+	result, err := r.db.Exec(`UPDATE notifications SET is_read = TRUE
+	          WHERE user_id = ? AND is_read = FALSE AND (? = '' OR category = ?)`,
+		userId, category, category)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if affected > 0 {
+		r.notify(userId, nil)
+	}
+	return affected, nil
+}
+
+// DeleteNotifications deletes those of the given notifications that belong
+// to the user and returns how many were deleted.
+func (r *NotificationRepository) DeleteNotifications(userId int, ids []int) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	args := []interface{}{userId}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	result, err := r.db.Exec(`DELETE FROM notifications
+	          WHERE user_id = ? AND notification_id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if affected > 0 {
+		r.notify(userId, nil)
+	}
+	return affected, nil
+}
+
+// PurgeRead deletes read notifications created before cutoff and returns
+// how many were deleted. Unread notifications are kept however old.
+func (r *NotificationRepository) PurgeRead(cutoff time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM notifications WHERE is_read = TRUE AND created_at < ?`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
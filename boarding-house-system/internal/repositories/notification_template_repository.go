@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"database/sql"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+)
+
+// DefaultLocale is used for users without a language preference and when a
+// template has no variant in the user's language.
+const DefaultLocale = "en"
+
+type NotificationTemplateRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationTemplateRepository(db *sql.DB) *NotificationTemplateRepository {
+	return &NotificationTemplateRepository{db: db}
+}
+
+const templateColumns = `template_id, name, locale, title, message, COALESCE(link, ''),
+	updated_by, updated_at`
+
+// GetTemplates returns the stored template variants. Built-in templates
+// that were never customised are not stored.
+func (r *NotificationTemplateRepository) GetTemplates() ([]models.NotificationTemplate, error) {
+	rows, err := r.db.Query(`SELECT ` + templateColumns + `
+	          FROM notification_templates ORDER BY name, locale`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []models.NotificationTemplate
+	for rows.Next() {
+		var tmpl models.NotificationTemplate
+		if err := scanTemplate(rows, &tmpl); err != nil {
+			return nil, err
+		}
+		templates = append(templates, tmpl)
+	}
+	return templates, rows.Err()
+}
+
+func (r *NotificationTemplateRepository) GetTemplate(name, locale string) (*models.NotificationTemplate, error) {
+	var tmpl models.NotificationTemplate
+	row := r.db.QueryRow(`SELECT `+templateColumns+`
+	          FROM notification_templates WHERE name = ? AND locale = ?`, name, locale)
+	if err := scanTemplate(row, &tmpl); err != nil {
+		return nil, err
+	}
+	return &tmpl, nil
+}
+
+// UpsertTemplate creates or replaces the variant of a template for its
+// locale.
+func (r *NotificationTemplateRepository) UpsertTemplate(tmpl *models.NotificationTemplate) error {
+	_, err := r.db.Exec(`INSERT INTO notification_templates
+	          (name, locale, title, message, link, updated_by)
+	          VALUES (?, ?, ?, ?, NULLIF(?, ''), ?)
+	          ON DUPLICATE KEY UPDATE title = VALUES(title), message = VALUES(message),
+	          link = VALUES(link), updated_by = VALUES(updated_by)`,
+		tmpl.Name, tmpl.Locale, tmpl.Title, tmpl.Message, tmpl.Link, tmpl.UpdatedBy)
+	if err != nil {
+		return err
+	}
+	stored, err := r.GetTemplate(tmpl.Name, tmpl.Locale)
+	if err != nil {
+		return err
+	}
+	*tmpl = *stored
+	return nil
+}
+
+func (r *NotificationTemplateRepository) DeleteTemplate(name, locale string) error {
+	result, err := r.db.Exec(`DELETE FROM notification_templates WHERE name = ? AND locale = ?`,
+		name, locale)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+// GetTenantUserID returns the user account of a tenant.
+func (r *NotificationTemplateRepository) GetTenantUserID(tenantId int) (int, error) {
+	var userID int
+	err := r.db.QueryRow(`SELECT user_id FROM tenants WHERE tenant_id = ?`, tenantId).Scan(&userID)
+	return userID, err
+}
+
+func scanTemplate(row rowScanner, tmpl *models.NotificationTemplate) error {
+	var updatedBy sql.NullInt64
+	if err := row.Scan(&tmpl.ID, &tmpl.Name, &tmpl.Locale, &tmpl.Title, &tmpl.Message,
+		&tmpl.Link, &updatedBy, &tmpl.UpdatedAt); err != nil {
+		return err
+	}
+	if updatedBy.Valid {
+		id := int(updatedBy.Int64)
+		tmpl.UpdatedBy = &id
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/events"
+	"github.com/Kimox23/boarding-house-app/internal/models"
+)
+
+// insertOutboxEvent records an event in the outbox as part of tx, so the
+// event is stored if and only if the change it describes is committed.
+func insertOutboxEvent(tx *sql.Tx, event events.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	subjectType, subjectID := event.Subject()
+	_, err = tx.Exec(`INSERT INTO outbox_events (event_type, subject_type, subject_id, payload)
+	          VALUES (?, ?, ?, ?)`, event.EventType(), subjectType, subjectID, payload)
+	return err
+}
+
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+const outboxColumns = `event_id, event_type, subject_type, subject_id, payload, occurred_at`
+
+// OutboxEvent is a pending event with how often it has been attempted.
+type OutboxEvent struct {
+	events.Envelope
+	Attempts int
+}
+
+// GetDueEvents returns up to limit pending events whose next attempt is at
+// or before asOf, in the order they occurred.
+func (r *OutboxRepository) GetDueEvents(asOf time.Time, limit int) ([]OutboxEvent, error) {
+	rows, err := r.db.Query(`SELECT `+outboxColumns+`, attempts
+	          FROM outbox_events
+	          WHERE status = 'pending' AND next_attempt_at <= ?
+	          ORDER BY event_id
+	          LIMIT ?`, asOf, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pending []OutboxEvent
+	for rows.Next() {
+		var event OutboxEvent
+		if err := scanEnvelope(rows, &event.Envelope, &event.Attempts); err != nil {
+			return nil, err
+		}
+		pending = append(pending, event)
+	}
+	return pending, rows.Err()
+}
+
+// Claim pushes a due event's next attempt out by lease so that no other
+// instance processes it at the same time. It returns false when the event
+// was already claimed.
+func (r *OutboxRepository) Claim(id int64, asOf time.Time, lease time.Duration) (bool, error) {
+	result, err := r.db.Exec(`UPDATE outbox_events SET next_attempt_at = ?
+	          WHERE event_id = ? AND status = 'pending' AND next_attempt_at <= ?`,
+		asOf.Add(lease), id, asOf)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// GetHandled returns the subscribers that already handled an event.
+func (r *OutboxRepository) GetHandled(id int64) (map[string]bool, error) {
+	rows, err := r.db.Query(`SELECT subscriber FROM outbox_handled WHERE event_id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	handled := make(map[string]bool)
+	for rows.Next() {
+		var subscriber string
+		if err := rows.Scan(&subscriber); err != nil {
+			return nil, err
+		}
+		handled[subscriber] = true
+	}
+	return handled, rows.Err()
+}
+
+func (r *OutboxRepository) MarkHandled(id int64, subscriber string) error {
+	_, err := r.db.Exec(`INSERT IGNORE INTO outbox_handled (event_id, subscriber) VALUES (?, ?)`,
+		id, subscriber)
+	return err
+}
+
+func (r *OutboxRepository) MarkProcessed(id int64) error {
+	_, err := r.db.Exec(`UPDATE outbox_events
+	          SET status = 'processed', attempts = attempts + 1, last_error = NULL,
+	          processed_at = CURRENT_TIMESTAMP
+	          WHERE event_id = ?`, id)
+	return err
+}
+
+// MarkAttemptFailed records a failed attempt. A nil retryAt gives up and
+// marks the event failed.
+func (r *OutboxRepository) MarkAttemptFailed(id int64, reason string, retryAt *time.Time) error {
+	if retryAt == nil {
+		_, err := r.db.Exec(`UPDATE outbox_events
+		          SET status = 'failed', attempts = attempts + 1, last_error = LEFT(?, 255)
+		          WHERE event_id = ?`, reason, id)
+		return err
+	}
+	_, err := r.db.Exec(`UPDATE outbox_events
+	          SET attempts = attempts + 1, last_error = LEFT(?, 255), next_attempt_at = ?
+	          WHERE event_id = ?`, reason, *retryAt, id)
+	return err
+}
+
+// RecordAudit stores an event in the audit log. Recording the same event
+// twice is a no-op.
+func (r *OutboxRepository) RecordAudit(envelope events.Envelope) error {
+	_, err := r.db.Exec(`INSERT IGNORE INTO audit_log
+	          (event_id, event_type, subject_type, subject_id, payload, occurred_at)
+	          VALUES (?, ?, ?, ?, ?, ?)`,
+		envelope.ID, envelope.Type, envelope.SubjectType, envelope.SubjectID,
+		[]byte(envelope.Payload), envelope.OccurredAt)
+	return err
+}
+
+// GetAuditLog returns the newest audit entries, optionally limited to one
+// subject type or one subject.
+func (r *OutboxRepository) GetAuditLog(subjectType string, subjectID, limit int) ([]models.AuditEntry, error) {
+	rows, err := r.db.Query(`SELECT audit_id, event_id, event_type, subject_type, subject_id,
+	          payload, occurred_at, recorded_at
+	          FROM audit_log
+	          WHERE (? = '' OR subject_type = ?) AND (? = 0 OR subject_id = ?)
+	          ORDER BY audit_id DESC
+	          LIMIT ?`, subjectType, subjectType, subjectID, subjectID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var payload []byte
+		if err := rows.Scan(&entry.ID, &entry.EventID, &entry.EventType, &entry.SubjectType,
+			&entry.SubjectID, &payload, &entry.OccurredAt, &entry.RecordedAt); err != nil {
+			return nil, err
+		}
+		entry.Payload = json.RawMessage(payload)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func scanEnvelope(row rowScanner, envelope *events.Envelope, extra ...interface{}) error {
+	var payload []byte
+	dest := []interface{}{&envelope.ID, &envelope.Type, &envelope.SubjectType, &envelope.SubjectID,
+		&payload, &envelope.OccurredAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	envelope.Payload = json.RawMessage(payload)
+	return nil
+}
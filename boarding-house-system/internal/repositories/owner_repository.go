@@ -0,0 +1,341 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+)
+
+var (
+	ErrStatementHasPayouts  = errors.New("statement already has payouts and cannot be regenerated")
+	ErrPayoutExceedsBalance = errors.New("payout exceeds the unpaid statement balance")
+)
+
+type OwnerRepository struct {
+	db *sql.DB
+}
+
+func NewOwnerRepository(db *sql.DB) *OwnerRepository {
+	return &OwnerRepository{db: db}
+}
+
+func (r *OwnerRepository) CreateOwner(owner *models.Owner) error {
+	query := `INSERT INTO owners (name, email, phone, management_fee_percent)
+	          VALUES (?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, owner.Name, owner.Email, owner.Phone, owner.ManagementFeePercent)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	owner.ID = int(id)
+	owner.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *OwnerRepository) GetOwner(id int) (*models.Owner, error) {
+	query := `SELECT owner_id, name, COALESCE(email, ''), COALESCE(phone, ''),
+	          management_fee_percent, created_at
+	          FROM owners WHERE owner_id = ?`
+
+	owner := &models.Owner{}
+	err := r.db.QueryRow(query, id).Scan(&owner.ID, &owner.Name, &owner.Email, &owner.Phone,
+		&owner.ManagementFeePercent, &owner.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	owner.HouseIDs, err = r.getOwnerHouseIDs(id)
+	if err != nil {
+		return nil, err
+	}
+	return owner, nil
+}
+
+func (r *OwnerRepository) GetAllOwners() ([]models.Owner, error) {
+	query := `SELECT owner_id, name, COALESCE(email, ''), COALESCE(phone, ''),
+	          management_fee_percent, created_at
+	          FROM owners ORDER BY name`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var owners []models.Owner
+	for rows.Next() {
+		var owner models.Owner
+		err := rows.Scan(&owner.ID, &owner.Name, &owner.Email, &owner.Phone,
+			&owner.ManagementFeePercent, &owner.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		owners = append(owners, owner)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range owners {
+		if owners[i].HouseIDs, err = r.getOwnerHouseIDs(owners[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return owners, nil
+}
+
+func (r *OwnerRepository) UpdateOwner(id int, owner *models.Owner) error {
+	query := `UPDATE owners SET
+	          name = ?, email = ?, phone = ?, management_fee_percent = ?
+	          WHERE owner_id = ?`
+
+	_, err := r.db.Exec(query, owner.Name, owner.Email, owner.Phone, owner.ManagementFeePercent, id)
+	return err
+}
+
+// AssignHouse links a house to an owner, moving it from any previous owner.
+func (r *OwnerRepository) AssignHouse(ownerId, houseId int) error {
+	query := `INSERT INTO owner_houses (owner_id, house_id) VALUES (?, ?)
+	          ON DUPLICATE KEY UPDATE owner_id = VALUES(owner_id)`
+	_, err := r.db.Exec(query, ownerId, houseId)
+	return err
+}
+
+func (r *OwnerRepository) UnassignHouse(ownerId, houseId int) error {
+	query := `DELETE FROM owner_houses WHERE owner_id = ? AND house_id = ?`
+	_, err := r.db.Exec(query, ownerId, houseId)
+	return err
+}
+
+// SaveStatement stores a statement and its per-house lines, replacing any
+// earlier version for the same period that has not been paid out yet.
+func (r *OwnerRepository) SaveStatement(statement *models.OwnerStatement) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var existingID int
+	err = tx.QueryRow(`SELECT statement_id FROM owner_statements
+	          WHERE owner_id = ? AND period_month = ? FOR UPDATE`,
+		statement.OwnerID, statement.PeriodMonth).Scan(&existingID)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return err
+	default:
+		var payouts int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM owner_payouts WHERE statement_id = ?`,
+			existingID).Scan(&payouts); err != nil {
+			return err
+		}
+		if payouts > 0 {
+			return ErrStatementHasPayouts
+		}
+		if _, err := tx.Exec(`DELETE FROM owner_statements WHERE statement_id = ?`, existingID); err != nil {
+			return err
+		}
+	}
+
+	result, err := tx.Exec(`INSERT INTO owner_statements
+	          (owner_id, period_month, income, expenses, maintenance_cost, management_fee, net_payout)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		statement.OwnerID, statement.PeriodMonth, statement.Income, statement.Expenses,
+		statement.MaintenanceCost, statement.ManagementFee, statement.NetPayout)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, line := range statement.Houses {
+		_, err := tx.Exec(`INSERT INTO owner_statement_lines
+		          (statement_id, house_id, house_name, income, expenses, maintenance_cost)
+		          VALUES (?, ?, ?, ?, ?, ?)`,
+			id, line.HouseID, line.HouseName, line.Income, line.Expenses, line.MaintenanceCost)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	statement.ID = int(id)
+	statement.GeneratedAt = time.Now()
+	return nil
+}
+
+func (r *OwnerRepository) GetStatement(id int) (*models.OwnerStatement, error) {
+	query := `SELECT statement_id, owner_id, period_month, income, expenses, maintenance_cost,
+	          management_fee, net_payout, generated_at
+	          FROM owner_statements WHERE statement_id = ?`
+
+	statement := &models.OwnerStatement{}
+	if err := scanOwnerStatement(r.db.QueryRow(query, id), statement); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadStatementDetails(statement); err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
+
+func (r *OwnerRepository) GetStatementsByOwner(ownerId int) ([]models.OwnerStatement, error) {
+	query := `SELECT statement_id, owner_id, period_month, income, expenses, maintenance_cost,
+	          management_fee, net_payout, generated_at
+	          FROM owner_statements WHERE owner_id = ?
+	          ORDER BY period_month DESC`
+
+	rows, err := r.db.Query(query, ownerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statements []models.OwnerStatement
+	for rows.Next() {
+		var statement models.OwnerStatement
+		if err := scanOwnerStatement(rows, &statement); err != nil {
+			return nil, err
+		}
+		statements = append(statements, statement)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range statements {
+		if err := r.loadStatementDetails(&statements[i]); err != nil {
+			return nil, err
+		}
+	}
+	return statements, nil
+}
+
+// CreatePayout records a payout against a statement. The statement is
+// locked while its unpaid balance is checked, so concurrent payouts cannot
+// add up to more than the net payout.
+func (r *OwnerRepository) CreatePayout(payout *models.OwnerPayout) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var netPayout float64
+	if err := tx.QueryRow(`SELECT net_payout FROM owner_statements WHERE statement_id = ? FOR UPDATE`,
+		payout.StatementID).Scan(&netPayout); err != nil {
+		return err
+	}
+	var paid float64
+	if err := tx.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM owner_payouts WHERE statement_id = ?`,
+		payout.StatementID).Scan(&paid); err != nil {
+		return err
+	}
+	if payout.Amount > netPayout-paid+0.005 {
+		return ErrPayoutExceedsBalance
+	}
+
+	query := `INSERT INTO owner_payouts (statement_id, amount, method, reference, recorded_by)
+	          VALUES (?, ?, ?, ?, ?)`
+
+	result, err := tx.Exec(query, payout.StatementID, payout.Amount, payout.Method,
+		payout.Reference, payout.RecordedBy)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	payout.ID = int(id)
+	payout.PaidAt = time.Now()
+	return nil
+}
+
+func (r *OwnerRepository) getOwnerHouseIDs(ownerId int) ([]int, error) {
+	rows, err := r.db.Query(`SELECT house_id FROM owner_houses WHERE owner_id = ? ORDER BY house_id`, ownerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	houseIDs := []int{}
+	for rows.Next() {
+		var houseID int
+		if err := rows.Scan(&houseID); err != nil {
+			return nil, err
+		}
+		houseIDs = append(houseIDs, houseID)
+	}
+	return houseIDs, rows.Err()
+}
+
+func (r *OwnerRepository) loadStatementDetails(statement *models.OwnerStatement) error {
+	rows, err := r.db.Query(`SELECT house_id, house_name, income, expenses, maintenance_cost
+	          FROM owner_statement_lines WHERE statement_id = ? ORDER BY house_id`, statement.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	statement.Houses = []models.OwnerStatementLine{}
+	for rows.Next() {
+		var line models.OwnerStatementLine
+		err := rows.Scan(&line.HouseID, &line.HouseName, &line.Income, &line.Expenses,
+			&line.MaintenanceCost)
+		if err != nil {
+			return err
+		}
+		statement.Houses = append(statement.Houses, line)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	payoutRows, err := r.db.Query(`SELECT payout_id, statement_id, amount, method,
+	          COALESCE(reference, ''), paid_at, COALESCE(recorded_by, 0)
+	          FROM owner_payouts WHERE statement_id = ? ORDER BY paid_at`, statement.ID)
+	if err != nil {
+		return err
+	}
+	defer payoutRows.Close()
+
+	statement.Payouts = []models.OwnerPayout{}
+	for payoutRows.Next() {
+		var payout models.OwnerPayout
+		err := payoutRows.Scan(&payout.ID, &payout.StatementID, &payout.Amount, &payout.Method,
+			&payout.Reference, &payout.PaidAt, &payout.RecordedBy)
+		if err != nil {
+			return err
+		}
+		statement.Payouts = append(statement.Payouts, payout)
+	}
+	return payoutRows.Err()
+}
+
+func scanOwnerStatement(row rowScanner, statement *models.OwnerStatement) error {
+	return row.Scan(&statement.ID, &statement.OwnerID, &statement.PeriodMonth, &statement.Income,
+		&statement.Expenses, &statement.MaintenanceCost, &statement.ManagementFee,
+		&statement.NetPayout, &statement.GeneratedAt)
+}
@@ -2,10 +2,25 @@ package repositories
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/Kimox23/boarding-house-app/internal/events"
 	"github.com/Kimox23/boarding-house-app/internal/models"
 )
 
+var (
+	ErrPaymentIsReversal    = errors.New("reversal entries cannot be reversed")
+	ErrPaymentAlreadyVoided = errors.New("payment has already been voided")
+	ErrPaymentHasRefunds    = errors.New("payment with refunds cannot be voided")
+	ErrRefundExceedsBalance = errors.New("refund amount exceeds remaining payment balance")
+)
+
+const paymentColumns = `payment_id, tenant_id, amount, payment_date, payment_method,
+	          payment_for_month, receipt_number, status, notes, recorded_by,
+	          entry_type, reversal_of, COALESCE(reason, ''), created_at`
+
 type PaymentRepository struct {
 	db *sql.DB
 }
@@ -15,12 +30,18 @@ func NewPaymentRepository(db *sql.DB) *PaymentRepository {
 }
 
 func (r *PaymentRepository) CreatePayment(payment *models.Payment) error {
-	query := `INSERT INTO payments 
-	          (tenant_id, amount, payment_date, payment_method, 
-	           payment_for_month, receipt_number, status, notes, recorded_by)
-	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO payments
+	          (tenant_id, amount, payment_date, payment_method,
+	           payment_for_month, receipt_number, status, notes, recorded_by, entry_type)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 'payment')`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	result, err := r.db.Exec(query, payment.TenantID, payment.Amount, payment.PaymentDate,
+	result, err := tx.Exec(query, payment.TenantID, payment.Amount, payment.PaymentDate,
 		payment.PaymentMethod, payment.PaymentForMonth, payment.ReceiptNumber,
 		payment.Status, payment.Notes, payment.RecordedBy)
 	if err != nil {
@@ -32,22 +53,35 @@ func (r *PaymentRepository) CreatePayment(payment *models.Payment) error {
 		return err
 	}
 
+	if err := insertOutboxEvent(tx, events.PaymentRecorded{
+		PaymentID:       int(id),
+		TenantID:        payment.TenantID,
+		Amount:          payment.Amount,
+		PaymentMethod:   payment.PaymentMethod,
+		PaymentForMonth: payment.PaymentForMonth,
+		ReceiptNumber:   payment.ReceiptNumber,
+		RecordedBy:      payment.RecordedBy,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
 	payment.ID = int(id)
+	payment.EntryType = "payment"
+	payment.CreatedAt = time.Now()
 	return nil
 }
 
 func (r *PaymentRepository) GetPayment(id int) (*models.Payment, error) {
-	query := `SELECT payment_id, tenant_id, amount, payment_date, payment_method,
-	          payment_for_month, receipt_number, status, notes, recorded_by
+	query := `SELECT ` + paymentColumns + `
 	          FROM payments WHERE payment_id = ?`
 
 	row := r.db.QueryRow(query, id)
 
 	payment := &models.Payment{}
-	err := row.Scan(&payment.ID, &payment.TenantID, &payment.Amount, &payment.PaymentDate,
-		&payment.PaymentMethod, &payment.PaymentForMonth, &payment.ReceiptNumber,
-		&payment.Status, &payment.Notes, &payment.RecordedBy)
-	if err != nil {
+	if err := scanPayment(row, payment); err != nil {
 		return nil, err
 	}
 
@@ -55,11 +89,124 @@ func (r *PaymentRepository) GetPayment(id int) (*models.Payment, error) {
 }
 
 func (r *PaymentRepository) GetPaymentsByTenant(tenantId int) ([]models.Payment, error) {
-	query := `SELECT payment_id, tenant_id, amount, payment_date, payment_method,
-	          payment_for_month, receipt_number, status, notes, recorded_by
-	          FROM payments WHERE tenant_id = ?`
+	query := `SELECT ` + paymentColumns + `
+	          FROM payments WHERE tenant_id = ?
+	          ORDER BY payment_date, payment_id`
+
+	return r.queryPayments(query, tenantId)
+}
+
+// GetReversals returns the void and refund entries recorded against a payment.
+func (r *PaymentRepository) GetReversals(paymentId int) ([]models.Payment, error) {
+	query := `SELECT ` + paymentColumns + `
+	          FROM payments WHERE reversal_of = ?
+	          ORDER BY created_at, payment_id`
+
+	return r.queryPayments(query, paymentId)
+}
 
-	rows, err := r.db.Query(query, tenantId)
+// CreateReversal records a void or refund entry against the original payment.
+// The original row is locked for the duration of the transaction so that
+// concurrent reversals cannot exceed the amount that was paid.
+func (r *PaymentRepository) CreateReversal(originalId int, reversal *models.Payment) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	original := &models.Payment{}
+	row := tx.QueryRow(`SELECT `+paymentColumns+`
+	          FROM payments WHERE payment_id = ? FOR UPDATE`, originalId)
+	if err := scanPayment(row, original); err != nil {
+		return err
+	}
+	if original.EntryType != "payment" {
+		return ErrPaymentIsReversal
+	}
+
+	var voids int
+	var reversed float64
+	err = tx.QueryRow(`SELECT COUNT(CASE WHEN entry_type = 'void' THEN 1 END),
+	          COALESCE(-SUM(amount), 0)
+	          FROM payments WHERE reversal_of = ?`, originalId).Scan(&voids, &reversed)
+	if err != nil {
+		return err
+	}
+	if voids > 0 {
+		return ErrPaymentAlreadyVoided
+	}
+
+	switch reversal.EntryType {
+	case "void":
+		if reversed > 0 {
+			return ErrPaymentHasRefunds
+		}
+		reversal.Amount = original.Amount
+		reversal.Status = "voided"
+	case "refund":
+		if reversal.Amount > original.Amount-reversed+0.005 {
+			return ErrRefundExceedsBalance
+		}
+		reversal.Status = "refunded"
+	default:
+		return fmt.Errorf("unknown reversal type %q", reversal.EntryType)
+	}
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM payments WHERE reversal_of = ?`,
+		originalId).Scan(&count); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	reversal.TenantID = original.TenantID
+	reversal.Amount = -reversal.Amount
+	reversal.PaymentDate = now
+	reversal.PaymentMethod = original.PaymentMethod
+	reversal.PaymentForMonth = original.PaymentForMonth
+	reversal.ReceiptNumber = fmt.Sprintf("REV-%d-%d", originalId, count+1)
+	reversal.ReversalOf = &original.ID
+
+	result, err := tx.Exec(`INSERT INTO payments
+	          (tenant_id, amount, payment_date, payment_method, payment_for_month,
+	           receipt_number, status, notes, recorded_by, entry_type, reversal_of, reason)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		reversal.TenantID, reversal.Amount, reversal.PaymentDate, reversal.PaymentMethod,
+		reversal.PaymentForMonth, reversal.ReceiptNumber, reversal.Status, reversal.Notes,
+		reversal.RecordedBy, reversal.EntryType, reversal.ReversalOf, reversal.Reason)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	if err := insertOutboxEvent(tx, events.PaymentReversed{
+		PaymentID:     int(id),
+		ReversalOf:    original.ID,
+		TenantID:      reversal.TenantID,
+		EntryType:     reversal.EntryType,
+		Amount:        -reversal.Amount,
+		ReceiptNumber: reversal.ReceiptNumber,
+		Reason:        reversal.Reason,
+		RecordedBy:    reversal.RecordedBy,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	reversal.ID = int(id)
+	reversal.CreatedAt = now
+	return nil
+}
+
+func (r *PaymentRepository) queryPayments(query string, args ...interface{}) ([]models.Payment, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -68,32 +215,22 @@ func (r *PaymentRepository) GetPaymentsByTenant(tenantId int) ([]models.Payment,
 	var payments []models.Payment
 	for rows.Next() {
 		var payment models.Payment
-		err := rows.Scan(&payment.ID, &payment.TenantID, &payment.Amount, &payment.PaymentDate,
-			&payment.PaymentMethod, &payment.PaymentForMonth, &payment.ReceiptNumber,
-			&payment.Status, &payment.Notes, &payment.RecordedBy)
-		if err != nil {
+		if err := scanPayment(rows, &payment); err != nil {
 			return nil, err
 		}
 		payments = append(payments, payment)
 	}
 
-	return payments, nil
+	return payments, rows.Err()
 }
 
-func (r *PaymentRepository) UpdatePayment(id int, payment *models.Payment) error {
-	query := `UPDATE payments SET 
-	          tenant_id = ?, amount = ?, payment_date = ?, payment_method = ?,
-	          payment_for_month = ?, receipt_number = ?, status = ?, notes = ?, recorded_by = ?
-	          WHERE payment_id = ?`
-
-	_, err := r.db.Exec(query, payment.TenantID, payment.Amount, payment.PaymentDate,
-		payment.PaymentMethod, payment.PaymentForMonth, payment.ReceiptNumber,
-		payment.Status, payment.Notes, payment.RecordedBy, id)
-	return err
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-func (r *PaymentRepository) DeletePayment(id int) error {
-	query := `DELETE FROM payments WHERE payment_id = ?`
-	_, err := r.db.Exec(query, id)
-	return err
+func scanPayment(row rowScanner, payment *models.Payment) error {
+	return row.Scan(&payment.ID, &payment.TenantID, &payment.Amount, &payment.PaymentDate,
+		&payment.PaymentMethod, &payment.PaymentForMonth, &payment.ReceiptNumber,
+		&payment.Status, &payment.Notes, &payment.RecordedBy, &payment.EntryType,
+		&payment.ReversalOf, &payment.Reason, &payment.CreatedAt)
 }
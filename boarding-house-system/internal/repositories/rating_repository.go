@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+)
+
+var ErrAlreadyRated = errors.New("this fix has already been rated")
+
+// RatingRow is a rating with the request details it is reported by.
+type RatingRow struct {
+	Score        int
+	Reopened     bool
+	HouseID      int
+	HouseName    string
+	IssueType    string
+	AssignedTo   int
+	AssigneeName string
+}
+
+type RatingRepository struct {
+	db *sql.DB
+}
+
+func NewRatingRepository(db *sql.DB) *RatingRepository {
+	return &RatingRepository{db: db}
+}
+
+// CreateRating stores a rating for one completion of a request. A request
+// that is reopened and completed again can be rated again. A rating that
+// reopens the request moves it back to pending in the same transaction,
+// with note on the timeline.
+func (r *RatingRepository) CreateRating(rating *models.MaintenanceRating, note string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT IGNORE INTO maintenance_ratings
+	          (request_id, rated_by, score, feedback, reopened, completed_date)
+	          VALUES (?, ?, ?, NULLIF(?, ''), ?, ?)`,
+		rating.RequestID, rating.RatedBy, rating.Score, rating.Feedback, rating.Reopened,
+		rating.CompletedDate)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrAlreadyRated
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	if rating.Reopened {
+		if err := updateRequestStatus(tx, rating.RequestID, "completed", "pending", rating.RatedBy, note); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	rating.ID = int(id)
+	rating.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *RatingRepository) GetRatings(requestId int) ([]models.MaintenanceRating, error) {
+	rows, err := r.db.Query(`SELECT rating_id, request_id, rated_by, score, COALESCE(feedback, ''),
+	          reopened, completed_date, created_at
+	          FROM maintenance_ratings WHERE request_id = ?
+	          ORDER BY created_at`, requestId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ratings := []models.MaintenanceRating{}
+	for rows.Next() {
+		var rating models.MaintenanceRating
+		if err := rows.Scan(&rating.ID, &rating.RequestID, &rating.RatedBy, &rating.Score,
+			&rating.Feedback, &rating.Reopened, &rating.CompletedDate, &rating.CreatedAt); err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, rating)
+	}
+	return ratings, rows.Err()
+}
+
+// GetRatingsInRange returns ratings given in [from, to), for every house
+// when houseId is zero.
+func (r *RatingRepository) GetRatingsInRange(houseId int, from, to time.Time) ([]RatingRow, error) {
+	rows, err := r.db.Query(`SELECT mr.score, mr.reopened, rm.house_id, h.name, m.issue_type,
+	          COALESCE(m.assigned_to, 0), COALESCE(u.username, '')
+	          FROM maintenance_ratings mr
+	          JOIN maintenance_requests m ON m.request_id = mr.request_id
+	          JOIN rooms rm ON rm.room_id = m.room_id
+	          JOIN boarding_houses h ON h.house_id = rm.house_id
+	          LEFT JOIN users u ON u.user_id = m.assigned_to
+	          WHERE mr.created_at >= ? AND mr.created_at < ?
+	          AND (? = 0 OR rm.house_id = ?)`, from, to, houseId, houseId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ratings []RatingRow
+	for rows.Next() {
+		var row RatingRow
+		if err := rows.Scan(&row.Score, &row.Reopened, &row.HouseID, &row.HouseName,
+			&row.IssueType, &row.AssignedTo, &row.AssigneeName); err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, row)
+	}
+	return ratings, rows.Err()
+}
@@ -0,0 +1,263 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/events"
+	"github.com/Kimox23/boarding-house-app/internal/models"
+)
+
+var (
+	ErrLineAlreadyReconciled = errors.New("statement line has already been reconciled")
+	ErrLineNotCredit         = errors.New("only incoming transfers can be recorded as payments")
+)
+
+// MatchCandidate is an active tenant that a bank transfer may belong to.
+type MatchCandidate struct {
+	TenantID      int
+	Username      string
+	FullName      string
+	PricePerMonth float64
+	MoveInDate    time.Time
+}
+
+type ReconciliationRepository struct {
+	db *sql.DB
+}
+
+func NewReconciliationRepository(db *sql.DB) *ReconciliationRepository {
+	return &ReconciliationRepository{db: db}
+}
+
+// CreateImport stores an uploaded statement and all of its lines atomically.
+func (r *ReconciliationRepository) CreateImport(statement *models.BankStatementImport) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO bank_statement_imports (file_name, format, uploaded_by)
+	          VALUES (?, ?, ?)`, statement.FileName, statement.Format, statement.UploadedBy)
+	if err != nil {
+		return err
+	}
+	importID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for i := range statement.Lines {
+		line := &statement.Lines[i]
+		line.ImportID = int(importID)
+		result, err := tx.Exec(`INSERT INTO bank_statement_lines
+		          (import_id, transaction_date, amount, reference, description,
+		           matched_tenant_id, confidence, status)
+		          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			line.ImportID, line.TransactionDate, line.Amount, line.Reference,
+			line.Description, line.MatchedTenantID, line.Confidence, line.Status)
+		if err != nil {
+			return err
+		}
+		lineID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		line.ID = int(lineID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	statement.ID = int(importID)
+	statement.UploadedAt = time.Now()
+	return nil
+}
+
+func (r *ReconciliationRepository) GetImport(id int) (*models.BankStatementImport, error) {
+	query := `SELECT import_id, file_name, format, uploaded_by, uploaded_at
+	          FROM bank_statement_imports WHERE import_id = ?`
+
+	statement := &models.BankStatementImport{}
+	err := r.db.QueryRow(query, id).Scan(&statement.ID, &statement.FileName,
+		&statement.Format, &statement.UploadedBy, &statement.UploadedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := r.GetImportLines(id)
+	if err != nil {
+		return nil, err
+	}
+	statement.Lines = lines
+	return statement, nil
+}
+
+func (r *ReconciliationRepository) GetImportLines(importId int) ([]models.BankStatementLine, error) {
+	query := `SELECT line_id, import_id, transaction_date, amount, COALESCE(reference, ''),
+	          COALESCE(description, ''), matched_tenant_id, confidence, status, payment_id
+	          FROM bank_statement_lines WHERE import_id = ?
+	          ORDER BY transaction_date, line_id`
+
+	rows, err := r.db.Query(query, importId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []models.BankStatementLine
+	for rows.Next() {
+		var line models.BankStatementLine
+		err := rows.Scan(&line.ID, &line.ImportID, &line.TransactionDate, &line.Amount,
+			&line.Reference, &line.Description, &line.MatchedTenantID, &line.Confidence,
+			&line.Status, &line.PaymentID)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+// GetMatchCandidates lists active tenants with their room rent for matching.
+func (r *ReconciliationRepository) GetMatchCandidates() ([]MatchCandidate, error) {
+	query := `SELECT t.tenant_id, u.username,
+	          COALESCE(CONCAT(p.first_name, ' ', p.last_name), ''),
+	          COALESCE(r.price_per_month, 0), t.move_in_date
+	          FROM tenants t
+	          JOIN users u ON u.user_id = t.user_id
+	          LEFT JOIN user_profiles p ON p.user_id = t.user_id
+	          LEFT JOIN rooms r ON r.room_id = t.room_id
+	          WHERE t.status = 'active'`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []MatchCandidate
+	for rows.Next() {
+		var candidate MatchCandidate
+		err := rows.Scan(&candidate.TenantID, &candidate.Username, &candidate.FullName,
+			&candidate.PricePerMonth, &candidate.MoveInDate)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, rows.Err()
+}
+
+// IgnoreLine marks a statement line as not relating to rent.
+func (r *ReconciliationRepository) IgnoreLine(importId, lineId int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	if err := tx.QueryRow(`SELECT status FROM bank_statement_lines
+	          WHERE line_id = ? AND import_id = ? FOR UPDATE`, lineId, importId).Scan(&status); err != nil {
+		return err
+	}
+	if status == "confirmed" {
+		return ErrLineAlreadyReconciled
+	}
+
+	if _, err := tx.Exec(`UPDATE bank_statement_lines SET status = 'ignored' WHERE line_id = ?`, lineId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ConfirmMatches creates a bank transfer payment for every confirmed line and
+// links it back to the statement, all in a single transaction.
+func (r *ReconciliationRepository) ConfirmMatches(importId int, matches map[int]int, recordedBy int) ([]models.Payment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var payments []models.Payment
+	for lineID, tenantID := range matches {
+		var line models.BankStatementLine
+		err := tx.QueryRow(`SELECT line_id, transaction_date, amount, COALESCE(reference, ''), status
+		          FROM bank_statement_lines WHERE line_id = ? AND import_id = ? FOR UPDATE`,
+			lineID, importId).Scan(&line.ID, &line.TransactionDate, &line.Amount,
+			&line.Reference, &line.Status)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineID, err)
+		}
+		if line.Status == "confirmed" || line.Status == "ignored" {
+			return nil, fmt.Errorf("line %d: %w", lineID, ErrLineAlreadyReconciled)
+		}
+		if line.Amount <= 0 {
+			return nil, fmt.Errorf("line %d: %w", lineID, ErrLineNotCredit)
+		}
+
+		date := line.TransactionDate
+		payment := models.Payment{
+			TenantID:        tenantID,
+			Amount:          line.Amount,
+			PaymentDate:     date,
+			PaymentMethod:   "bank_transfer",
+			PaymentForMonth: time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location()),
+			ReceiptNumber:   fmt.Sprintf("BANK-%d", line.ID),
+			Status:          "paid",
+			Notes:           line.Reference,
+			RecordedBy:      recordedBy,
+			EntryType:       "payment",
+		}
+
+		result, err := tx.Exec(`INSERT INTO payments
+		          (tenant_id, amount, payment_date, payment_method,
+		           payment_for_month, receipt_number, status, notes, recorded_by, entry_type)
+		          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 'payment')`,
+			payment.TenantID, payment.Amount, payment.PaymentDate, payment.PaymentMethod,
+			payment.PaymentForMonth, payment.ReceiptNumber, payment.Status, payment.Notes,
+			payment.RecordedBy)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineID, err)
+		}
+		paymentID, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		payment.ID = int(paymentID)
+
+		if err := insertOutboxEvent(tx, events.PaymentRecorded{
+			PaymentID:       payment.ID,
+			TenantID:        payment.TenantID,
+			Amount:          payment.Amount,
+			PaymentMethod:   payment.PaymentMethod,
+			PaymentForMonth: payment.PaymentForMonth,
+			ReceiptNumber:   payment.ReceiptNumber,
+			RecordedBy:      payment.RecordedBy,
+		}); err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(`UPDATE bank_statement_lines
+		          SET status = 'confirmed', matched_tenant_id = ?, payment_id = ?
+		          WHERE line_id = ?`, tenantID, payment.ID, line.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		payments = append(payments, payment)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
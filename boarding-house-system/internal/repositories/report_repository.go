@@ -0,0 +1,196 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ReceivableInvoice is an invoice together with the tenant, room and house
+// it belongs to, as needed by the aging report.
+type ReceivableInvoice struct {
+	TenantID   int
+	Username   string
+	RoomID     int
+	RoomNumber string
+	HouseID    int
+	HouseName  string
+	Amount     float64
+	DueDate    time.Time
+}
+
+type ReportRepository struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// GetReceivableInvoices returns all invoices issued up to asOf, oldest first
+// per tenant. A zero houseId includes every house.
+func (r *ReportRepository) GetReceivableInvoices(asOf time.Time, houseId int) ([]ReceivableInvoice, error) {
+	query := `SELECT i.tenant_id, u.username, r.room_id, r.room_number, h.house_id, h.name,
+	          i.amount, i.due_date
+	          FROM invoices i
+	          JOIN tenants t ON t.tenant_id = i.tenant_id
+	          JOIN users u ON u.user_id = t.user_id
+	          JOIN rooms r ON r.room_id = t.room_id
+	          JOIN boarding_houses h ON h.house_id = r.house_id
+	          WHERE i.period_month <= ? AND (? = 0 OR h.house_id = ?)
+	          ORDER BY h.house_id, r.room_number, i.tenant_id, i.due_date`
+
+	rows, err := r.db.Query(query, asOf, houseId, houseId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invoices []ReceivableInvoice
+	for rows.Next() {
+		var invoice ReceivableInvoice
+		err := rows.Scan(&invoice.TenantID, &invoice.Username, &invoice.RoomID,
+			&invoice.RoomNumber, &invoice.HouseID, &invoice.HouseName,
+			&invoice.Amount, &invoice.DueDate)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, invoice)
+	}
+
+	return invoices, rows.Err()
+}
+
+// GetPaidTotals returns the net amount received per tenant up to asOf,
+// including void and refund reversals.
+func (r *ReportRepository) GetPaidTotals(asOf time.Time) (map[int]float64, error) {
+	query := `SELECT tenant_id, SUM(amount)
+	          FROM payments
+	          WHERE payment_date <= ?
+	          AND status IN ('paid', 'partial', 'voided', 'refunded')
+	          GROUP BY tenant_id`
+
+	rows, err := r.db.Query(query, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make(map[int]float64)
+	for rows.Next() {
+		var tenantID int
+		var total float64
+		if err := rows.Scan(&tenantID, &total); err != nil {
+			return nil, err
+		}
+		totals[tenantID] = total
+	}
+
+	return totals, rows.Err()
+}
+
+// HouseSummary identifies a house in report output.
+type HouseSummary struct {
+	ID   int
+	Name string
+}
+
+// GetHouses returns the houses to report on. A zero houseId returns all.
+func (r *ReportRepository) GetHouses(houseId int) ([]HouseSummary, error) {
+	query := `SELECT house_id, name FROM boarding_houses
+	          WHERE ? = 0 OR house_id = ?
+	          ORDER BY house_id`
+
+	rows, err := r.db.Query(query, houseId, houseId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var houses []HouseSummary
+	for rows.Next() {
+		var house HouseSummary
+		if err := rows.Scan(&house.ID, &house.Name); err != nil {
+			return nil, err
+		}
+		houses = append(houses, house)
+	}
+
+	return houses, rows.Err()
+}
+
+// GetMonthlyRentIncome returns net rent received per YYYY-MM for a house,
+// after voids and refunds. The monthly queries below all cover [from, to).
+func (r *ReportRepository) GetMonthlyRentIncome(houseId int, from, to time.Time) (map[string]float64, error) {
+	query := `SELECT DATE_FORMAT(p.payment_date, '%Y-%m'), SUM(p.amount)
+	          FROM payments p
+	          JOIN tenants t ON t.tenant_id = p.tenant_id
+	          JOIN rooms r ON r.room_id = t.room_id
+	          WHERE r.house_id = ? AND p.payment_date >= ? AND p.payment_date < ?
+	          AND p.status IN ('paid', 'partial', 'voided', 'refunded')
+	          GROUP BY DATE_FORMAT(p.payment_date, '%Y-%m')`
+
+	return r.monthlyTotals(query, houseId, from, to)
+}
+
+// GetMonthlyMaintenanceCost returns maintenance spend per YYYY-MM for a
+// house, dated by completion where known.
+func (r *ReportRepository) GetMonthlyMaintenanceCost(houseId int, from, to time.Time) (map[string]float64, error) {
+	query := `SELECT DATE_FORMAT(COALESCE(m.completed_date, m.reported_date), '%Y-%m'), SUM(m.cost)
+	          FROM maintenance_requests m
+	          JOIN rooms r ON r.room_id = m.room_id
+	          WHERE r.house_id = ? AND m.cost IS NOT NULL
+	          AND COALESCE(m.completed_date, m.reported_date) >= ?
+	          AND COALESCE(m.completed_date, m.reported_date) < ?
+	          GROUP BY DATE_FORMAT(COALESCE(m.completed_date, m.reported_date), '%Y-%m')`
+
+	return r.monthlyTotals(query, houseId, from, to)
+}
+
+// GetMonthlyExpenses returns operating expenses per YYYY-MM and category.
+func (r *ReportRepository) GetMonthlyExpenses(houseId int, from, to time.Time) (map[string]map[string]float64, error) {
+	query := `SELECT DATE_FORMAT(expense_date, '%Y-%m'), category, SUM(amount)
+	          FROM expenses
+	          WHERE house_id = ? AND expense_date >= ? AND expense_date < ?
+	          GROUP BY DATE_FORMAT(expense_date, '%Y-%m'), category`
+
+	rows, err := r.db.Query(query, houseId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make(map[string]map[string]float64)
+	for rows.Next() {
+		var month, category string
+		var total float64
+		if err := rows.Scan(&month, &category, &total); err != nil {
+			return nil, err
+		}
+		if totals[month] == nil {
+			totals[month] = make(map[string]float64)
+		}
+		totals[month][category] = total
+	}
+
+	return totals, rows.Err()
+}
+
+func (r *ReportRepository) monthlyTotals(query string, args ...interface{}) (map[string]float64, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make(map[string]float64)
+	for rows.Next() {
+		var month string
+		var total float64
+		if err := rows.Scan(&month, &total); err != nil {
+			return nil, err
+		}
+		totals[month] = total
+	}
+
+	return totals, rows.Err()
+}
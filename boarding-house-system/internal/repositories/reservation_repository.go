@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+)
+
+const reservationColumns = `res.reservation_id, res.room_id, res.guest_name, COALESCE(res.guest_email, ''),
+	          COALESCE(res.guest_phone, ''), res.expected_move_in, res.expected_move_out,
+	          res.monthly_rate, res.status, COALESCE(res.created_by, 0), res.created_at`
+
+type ReservationRepository struct {
+	db *sql.DB
+}
+
+func NewReservationRepository(db *sql.DB) *ReservationRepository {
+	return &ReservationRepository{db: db}
+}
+
+func (r *ReservationRepository) CreateReservation(reservation *models.Reservation) error {
+	query := `INSERT INTO reservations
+	          (room_id, guest_name, guest_email, guest_phone, expected_move_in,
+	           expected_move_out, monthly_rate, status, created_by)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, reservation.RoomID, reservation.GuestName,
+		reservation.GuestEmail, reservation.GuestPhone, reservation.ExpectedMoveIn,
+		reservation.ExpectedMoveOut, reservation.MonthlyRate, reservation.Status,
+		reservation.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	reservation.ID = int(id)
+	reservation.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *ReservationRepository) GetReservation(id int) (*models.Reservation, error) {
+	query := `SELECT ` + reservationColumns + ` FROM reservations res WHERE res.reservation_id = ?`
+
+	reservation := &models.Reservation{}
+	if err := scanReservation(r.db.QueryRow(query, id), reservation); err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// GetReservationsByHouse lists reservations for a house, optionally
+// filtered by status.
+func (r *ReservationRepository) GetReservationsByHouse(houseId int, status string) ([]models.Reservation, error) {
+	query := `SELECT ` + reservationColumns + `
+	          FROM reservations res
+	          JOIN rooms r ON r.room_id = res.room_id
+	          WHERE r.house_id = ? AND (? = '' OR res.status = ?)
+	          ORDER BY res.expected_move_in`
+
+	return r.queryReservations(query, houseId, status, status)
+}
+
+// GetConfirmedReservations returns confirmed reservations moving in before
+// the given date, for every house when houseId is zero.
+func (r *ReservationRepository) GetConfirmedReservations(houseId int, before time.Time) (map[int][]models.Reservation, error) {
+	query := `SELECT r.house_id, ` + reservationColumns + `
+	          FROM reservations res
+	          JOIN rooms r ON r.room_id = res.room_id
+	          WHERE res.status = 'confirmed' AND res.expected_move_in < ?
+	          AND (? = 0 OR r.house_id = ?)`
+
+	rows, err := r.db.Query(query, before, houseId, houseId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byHouse := make(map[int][]models.Reservation)
+	for rows.Next() {
+		var house int
+		var res models.Reservation
+		var moveOut sql.NullTime
+		err := rows.Scan(&house, &res.ID, &res.RoomID, &res.GuestName, &res.GuestEmail,
+			&res.GuestPhone, &res.ExpectedMoveIn, &moveOut, &res.MonthlyRate, &res.Status,
+			&res.CreatedBy, &res.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if moveOut.Valid {
+			res.ExpectedMoveOut = &moveOut.Time
+		}
+		byHouse[house] = append(byHouse[house], res)
+	}
+	return byHouse, rows.Err()
+}
+
+func (r *ReservationRepository) UpdateReservationStatus(id int, status string) error {
+	query := `UPDATE reservations SET status = ? WHERE reservation_id = ?`
+	_, err := r.db.Exec(query, status, id)
+	return err
+}
+
+func (r *ReservationRepository) queryReservations(query string, args ...interface{}) ([]models.Reservation, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reservations []models.Reservation
+	for rows.Next() {
+		var reservation models.Reservation
+		if err := scanReservation(rows, &reservation); err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, reservation)
+	}
+	return reservations, rows.Err()
+}
+
+func scanReservation(row rowScanner, res *models.Reservation) error {
+	var moveOut sql.NullTime
+	err := row.Scan(&res.ID, &res.RoomID, &res.GuestName, &res.GuestEmail, &res.GuestPhone,
+		&res.ExpectedMoveIn, &moveOut, &res.MonthlyRate, &res.Status, &res.CreatedBy,
+		&res.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if moveOut.Valid {
+		res.ExpectedMoveOut = &moveOut.Time
+	}
+	return nil
+}
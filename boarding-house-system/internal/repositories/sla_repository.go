@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+)
+
+// SLARequest is a maintenance request with the fields needed to measure it
+// against its SLA.
+type SLARequest struct {
+	RequestID     int
+	HouseID       int
+	HouseName     string
+	ManagerID     int
+	Priority      string
+	Status        string
+	ReportedDate  time.Time
+	StartedDate   *time.Time
+	CompletedDate *time.Time
+	AssignedTo    int
+	AssigneeName  string
+}
+
+const slaRequestColumns = `m.request_id, r.house_id, h.name, COALESCE(h.manager_id, 0), m.priority,
+	          m.status, m.reported_date, m.started_date, m.completed_date,
+	          COALESCE(m.assigned_to, 0), COALESCE(u.username, '')
+	          FROM maintenance_requests m
+	          JOIN rooms r ON r.room_id = m.room_id
+	          JOIN boarding_houses h ON h.house_id = r.house_id
+	          LEFT JOIN users u ON u.user_id = m.assigned_to`
+
+type SLARepository struct {
+	db *sql.DB
+}
+
+func NewSLARepository(db *sql.DB) *SLARepository {
+	return &SLARepository{db: db}
+}
+
+// GetPolicies returns the SLA overrides configured for a house, or for all
+// houses when houseId is zero.
+func (r *SLARepository) GetPolicies(houseId int) ([]models.SLAPolicy, error) {
+	rows, err := r.db.Query(`SELECT house_id, priority, response_minutes, resolution_minutes
+	          FROM maintenance_sla_policies
+	          WHERE ? = 0 OR house_id = ?`, houseId, houseId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []models.SLAPolicy
+	for rows.Next() {
+		var policy models.SLAPolicy
+		err := rows.Scan(&policy.HouseID, &policy.Priority, &policy.ResponseMinutes,
+			&policy.ResolutionMinutes)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, rows.Err()
+}
+
+func (r *SLARepository) UpsertPolicy(policy *models.SLAPolicy) error {
+	_, err := r.db.Exec(`INSERT INTO maintenance_sla_policies
+	          (house_id, priority, response_minutes, resolution_minutes)
+	          VALUES (?, ?, ?, ?)
+	          ON DUPLICATE KEY UPDATE response_minutes = VALUES(response_minutes),
+	              resolution_minutes = VALUES(resolution_minutes)`,
+		policy.HouseID, policy.Priority, policy.ResponseMinutes, policy.ResolutionMinutes)
+	return err
+}
+
+// DeletePolicy drops a house override so the default target applies again.
+func (r *SLARepository) DeletePolicy(houseId int, priority string) error {
+	_, err := r.db.Exec(`DELETE FROM maintenance_sla_policies WHERE house_id = ? AND priority = ?`,
+		houseId, priority)
+	return err
+}
+
+// GetOpenRequests returns requests that are still pending or in progress.
+func (r *SLARepository) GetOpenRequests() ([]SLARequest, error) {
+	return r.queryRequests(`SELECT ` + slaRequestColumns + `
+	          WHERE m.status IN ('pending', 'in_progress')`)
+}
+
+// GetRequestsReported returns non-cancelled requests reported in [from, to).
+func (r *SLARepository) GetRequestsReported(houseId int, from, to time.Time) ([]SLARequest, error) {
+	return r.queryRequests(`SELECT `+slaRequestColumns+`
+	          WHERE m.reported_date >= ? AND m.reported_date < ?
+	          AND m.status <> 'cancelled'
+	          AND (? = 0 OR r.house_id = ?)
+	          ORDER BY m.reported_date`, from, to, houseId, houseId)
+}
+
+// RecordEscalation stores an escalation level for a breached request and adds
+// it to the request timeline. It reports false when the level was already
+// recorded, so that each level is only notified once.
+func (r *SLARepository) RecordEscalation(requestId int, breachType string, level int) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT IGNORE INTO maintenance_escalations
+	          (request_id, breach_type, level) VALUES (?, ?, ?)`, requestId, breachType, level)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	if err := insertMaintenanceEvent(tx, &models.MaintenanceEvent{
+		RequestID: requestId,
+		EventType: "escalated",
+		Note:      fmt.Sprintf("%s SLA breached, escalated to level %d", breachType, level),
+	}); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// GetAdminIDs returns the active administrators.
+func (r *SLARepository) GetAdminIDs() ([]int, error) {
+	rows, err := r.db.Query(`SELECT user_id FROM users WHERE role = 'admin' AND is_active = TRUE`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *SLARepository) queryRequests(query string, args ...interface{}) ([]SLARequest, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []SLARequest
+	for rows.Next() {
+		var req SLARequest
+		var started, completed sql.NullTime
+		err := rows.Scan(&req.RequestID, &req.HouseID, &req.HouseName, &req.ManagerID,
+			&req.Priority, &req.Status, &req.ReportedDate, &started, &completed,
+			&req.AssignedTo, &req.AssigneeName)
+		if err != nil {
+			return nil, err
+		}
+		if started.Valid {
+			req.StartedDate = &started.Time
+		}
+		if completed.Valid {
+			req.CompletedDate = &completed.Time
+		}
+		requests = append(requests, req)
+	}
+	return requests, rows.Err()
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"database/sql"
 
+	"github.com/Kimox23/boarding-house-app/internal/events"
 	"github.com/Kimox23/boarding-house-app/internal/models"
 )
 
@@ -16,13 +17,19 @@ func NewTenantRepository(db *sql.DB) *TenantRepository {
 
 func (r *TenantRepository) CreateTenant(tenant *models.Tenant) error {
 	query := `INSERT INTO tenants 
-	          (user_id, room_id, move_in_date, move_out_date, 
+	          (user_id, room_id, move_in_date, move_out_date, lease_end_date,
 	           deposit_amount, deposit_paid, contract_document, status)
-	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	result, err := r.db.Exec(query, tenant.UserID, tenant.RoomID, tenant.MoveInDate,
-		tenant.MoveOutDate, tenant.DepositAmount, tenant.DepositPaid, tenant.ContractDocument,
-		tenant.Status)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, tenant.UserID, tenant.RoomID, tenant.MoveInDate,
+		tenant.MoveOutDate, tenant.LeaseEndDate, tenant.DepositAmount, tenant.DepositPaid,
+		tenant.ContractDocument, tenant.Status)
 	if err != nil {
 		return err
 	}
@@ -32,12 +39,25 @@ func (r *TenantRepository) CreateTenant(tenant *models.Tenant) error {
 		return err
 	}
 
+	if err := insertOutboxEvent(tx, events.TenantCheckedIn{
+		TenantID:     int(id),
+		UserID:       tenant.UserID,
+		RoomID:       tenant.RoomID,
+		MoveInDate:   tenant.MoveInDate,
+		LeaseEndDate: tenant.LeaseEndDate,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
 	tenant.ID = int(id)
 	return nil
 }
 
 func (r *TenantRepository) GetTenant(id int) (*models.Tenant, error) {
-	query := `SELECT tenant_id, user_id, room_id, move_in_date, move_out_date,
+	query := `SELECT tenant_id, user_id, room_id, move_in_date, move_out_date, lease_end_date,
 	          deposit_amount, deposit_paid, contract_document, status
 	          FROM tenants WHERE id = ?`
 
@@ -45,7 +65,7 @@ func (r *TenantRepository) GetTenant(id int) (*models.Tenant, error) {
 
 	tenant := &models.Tenant{}
 	err := row.Scan(&tenant.ID, &tenant.UserID, &tenant.RoomID, &tenant.MoveInDate,
-		&tenant.MoveOutDate, &tenant.DepositAmount, &tenant.DepositPaid,
+		&tenant.MoveOutDate, &tenant.LeaseEndDate, &tenant.DepositAmount, &tenant.DepositPaid,
 		&tenant.ContractDocument, &tenant.Status)
 	if err != nil {
 		return nil, err
@@ -55,7 +75,7 @@ func (r *TenantRepository) GetTenant(id int) (*models.Tenant, error) {
 }
 
 func (r *TenantRepository) GetTenantsByHouse(houseId int) ([]models.Tenant, error) {
-	query := `SELECT t.tenant_id, t.user_id, t.room_id, t.move_in_date, t.move_out_date,
+	query := `SELECT t.tenant_id, t.user_id, t.room_id, t.move_in_date, t.move_out_date, t.lease_end_date,
 	          t.deposit_amount, t.deposit_paid, t.contract_document, t.status
 	          FROM tenants t
 	          JOIN rooms r ON t.room_id = r.tenant_id
@@ -71,7 +91,7 @@ func (r *TenantRepository) GetTenantsByHouse(houseId int) ([]models.Tenant, erro
 	for rows.Next() {
 		var tenant models.Tenant
 		err := rows.Scan(&tenant.ID, &tenant.UserID, &tenant.RoomID, &tenant.MoveInDate,
-			&tenant.MoveOutDate, &tenant.DepositAmount, &tenant.DepositPaid,
+			&tenant.MoveOutDate, &tenant.LeaseEndDate, &tenant.DepositAmount, &tenant.DepositPaid,
 			&tenant.ContractDocument, &tenant.Status)
 		if err != nil {
 			return nil, err
@@ -84,12 +104,12 @@ func (r *TenantRepository) GetTenantsByHouse(houseId int) ([]models.Tenant, erro
 
 func (r *TenantRepository) UpdateTenant(id int, tenant *models.Tenant) error {
 	query := `UPDATE tenants SET 
-	          room_id = ?, move_in_date = ?, move_out_date = ?,
+	          room_id = ?, move_in_date = ?, move_out_date = ?, lease_end_date = ?,
 	          deposit_amount = ?, deposit_paid = ?, contract_document = ?, status = ?
 	          WHERE tenant_id = ?`
 
 	_, err := r.db.Exec(query, tenant.RoomID, tenant.MoveInDate, tenant.MoveOutDate,
-		tenant.DepositAmount, tenant.DepositPaid, tenant.ContractDocument,
+		tenant.LeaseEndDate, tenant.DepositAmount, tenant.DepositPaid, tenant.ContractDocument,
 		tenant.Status, id)
 	return err
 }
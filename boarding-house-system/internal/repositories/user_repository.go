@@ -147,3 +147,23 @@ func (r *UserRepository) GetProfile(userId int) (*models.UserProfile, error) {
 
 	return profile, nil
 }
+
+// SetProfilePicture points a profile at a new picture and returns the key
+// of the picture it replaced, if any.
+func (r *UserRepository) SetProfilePicture(userId int, key string) (string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var previous string
+	if err := tx.QueryRow(`SELECT COALESCE(profile_picture, '') FROM user_profiles WHERE user_id = ? FOR UPDATE`,
+		userId).Scan(&previous); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(`UPDATE user_profiles SET profile_picture = ? WHERE user_id = ?`, key, userId); err != nil {
+		return "", err
+	}
+	return previous, tx.Commit()
+}
@@ -0,0 +1,470 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+)
+
+var (
+	ErrQuoteAlreadyDecided = errors.New("quote has already been approved or rejected")
+	ErrInvoiceAlreadyPaid  = errors.New("vendor invoice has already been paid")
+)
+
+const vendorColumns = `vendor_id, name, trade, COALESCE(contact_name, ''), COALESCE(email, ''),
+	          COALESCE(phone, ''), COALESCE(hourly_rate, 0), COALESCE(callout_fee, 0),
+	          COALESCE(notes, ''), is_active, created_at`
+
+const vendorQuoteColumns = `quote_id, request_id, vendor_id, amount, COALESCE(description, ''),
+	          status, submitted_at, decided_by, decided_at`
+
+const vendorInvoiceColumns = `invoice_id, request_id, vendor_id, quote_id, invoice_number, amount,
+	          invoice_date, COALESCE(file_path, ''), status, paid_at, created_at`
+
+type VendorRepository struct {
+	db *sql.DB
+}
+
+func NewVendorRepository(db *sql.DB) *VendorRepository {
+	return &VendorRepository{db: db}
+}
+
+func (r *VendorRepository) CreateVendor(vendor *models.Vendor) error {
+	query := `INSERT INTO vendors
+	          (name, trade, contact_name, email, phone, hourly_rate, callout_fee, notes)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, vendor.Name, vendor.Trade, vendor.ContactName, vendor.Email,
+		vendor.Phone, vendor.HourlyRate, vendor.CalloutFee, vendor.Notes)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	vendor.ID = int(id)
+	vendor.IsActive = true
+	vendor.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *VendorRepository) GetVendor(id int) (*models.Vendor, error) {
+	query := `SELECT ` + vendorColumns + ` FROM vendors WHERE vendor_id = ?`
+
+	vendor := &models.Vendor{}
+	if err := scanVendor(r.db.QueryRow(query, id), vendor); err != nil {
+		return nil, err
+	}
+
+	documents, err := r.GetDocuments(id)
+	if err != nil {
+		return nil, err
+	}
+	vendor.Documents = documents
+	return vendor, nil
+}
+
+// GetVendors lists vendors, optionally only those of one trade.
+func (r *VendorRepository) GetVendors(trade string) ([]models.Vendor, error) {
+	query := `SELECT ` + vendorColumns + ` FROM vendors
+	          WHERE ? = '' OR trade = ?
+	          ORDER BY name`
+
+	rows, err := r.db.Query(query, trade, trade)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vendors []models.Vendor
+	for rows.Next() {
+		var vendor models.Vendor
+		if err := scanVendor(rows, &vendor); err != nil {
+			return nil, err
+		}
+		vendors = append(vendors, vendor)
+	}
+	return vendors, rows.Err()
+}
+
+func (r *VendorRepository) UpdateVendor(id int, vendor *models.Vendor) error {
+	query := `UPDATE vendors SET
+	          name = ?, trade = ?, contact_name = ?, email = ?, phone = ?,
+	          hourly_rate = ?, callout_fee = ?, notes = ?, is_active = ?
+	          WHERE vendor_id = ?`
+
+	_, err := r.db.Exec(query, vendor.Name, vendor.Trade, vendor.ContactName, vendor.Email,
+		vendor.Phone, vendor.HourlyRate, vendor.CalloutFee, vendor.Notes, vendor.IsActive, id)
+	return err
+}
+
+func (r *VendorRepository) AddDocument(document *models.VendorDocument) error {
+	query := `INSERT INTO vendor_documents (vendor_id, document_type, file_path, expires_on)
+	          VALUES (?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, document.VendorID, document.DocumentType,
+		document.FilePath, document.ExpiresOn)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	document.ID = int(id)
+	document.UploadedAt = time.Now()
+	return nil
+}
+
+func (r *VendorRepository) GetDocuments(vendorId int) ([]models.VendorDocument, error) {
+	query := `SELECT document_id, vendor_id, document_type, file_path, expires_on, uploaded_at
+	          FROM vendor_documents WHERE vendor_id = ?
+	          ORDER BY uploaded_at`
+
+	rows, err := r.db.Query(query, vendorId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	documents := []models.VendorDocument{}
+	for rows.Next() {
+		var document models.VendorDocument
+		var expires sql.NullTime
+		err := rows.Scan(&document.ID, &document.VendorID, &document.DocumentType,
+			&document.FilePath, &expires, &document.UploadedAt)
+		if err != nil {
+			return nil, err
+		}
+		if expires.Valid {
+			document.ExpiresOn = &expires.Time
+		}
+		documents = append(documents, document)
+	}
+	return documents, rows.Err()
+}
+
+// GetExpiredCompliance returns the insurance and licence document types for
+// which the vendor's most recent expiry date has passed.
+func (r *VendorRepository) GetExpiredCompliance(vendorId int, asOf time.Time) ([]string, error) {
+	query := `SELECT document_type FROM vendor_documents
+	          WHERE vendor_id = ? AND document_type IN ('insurance', 'licence')
+	          GROUP BY document_type
+	          HAVING MAX(COALESCE(expires_on, '9999-12-31')) < ?`
+
+	rows, err := r.db.Query(query, vendorId, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expired []string
+	for rows.Next() {
+		var documentType string
+		if err := rows.Scan(&documentType); err != nil {
+			return nil, err
+		}
+		expired = append(expired, documentType)
+	}
+	return expired, rows.Err()
+}
+
+// AssignVendor sets the vendor doing the work on a request.
+func (r *VendorRepository) AssignVendor(requestId int, vendorId *int, actorID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE maintenance_requests SET vendor_id = ? WHERE request_id = ?`,
+		vendorId, requestId); err != nil {
+		return err
+	}
+
+	note := "vendor unassigned"
+	if vendorId != nil {
+		note = fmt.Sprintf("assigned to vendor %d", *vendorId)
+	}
+	if err := insertMaintenanceEvent(tx, &models.MaintenanceEvent{
+		RequestID: requestId,
+		EventType: "assigned",
+		ActorID:   &actorID,
+		Note:      note,
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *VendorRepository) CreateQuote(quote *models.VendorQuote) error {
+	query := `INSERT INTO vendor_quotes (request_id, vendor_id, amount, description)
+	          VALUES (?, ?, ?, ?)`
+
+	result, err := r.db.Exec(query, quote.RequestID, quote.VendorID, quote.Amount, quote.Description)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	quote.ID = int(id)
+	quote.Status = "submitted"
+	quote.SubmittedAt = time.Now()
+	return nil
+}
+
+func (r *VendorRepository) GetQuote(id int) (*models.VendorQuote, error) {
+	query := `SELECT ` + vendorQuoteColumns + ` FROM vendor_quotes WHERE quote_id = ?`
+
+	quote := &models.VendorQuote{}
+	if err := scanVendorQuote(r.db.QueryRow(query, id), quote); err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+func (r *VendorRepository) GetQuotesByRequest(requestId int) ([]models.VendorQuote, error) {
+	query := `SELECT ` + vendorQuoteColumns + ` FROM vendor_quotes
+	          WHERE request_id = ? ORDER BY submitted_at`
+
+	rows, err := r.db.Query(query, requestId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	quotes := []models.VendorQuote{}
+	for rows.Next() {
+		var quote models.VendorQuote
+		if err := scanVendorQuote(rows, &quote); err != nil {
+			return nil, err
+		}
+		quotes = append(quotes, quote)
+	}
+	return quotes, rows.Err()
+}
+
+// DecideQuote approves or rejects a submitted quote. Approving a quote
+// rejects the other open quotes on the request and assigns its vendor.
+func (r *VendorRepository) DecideQuote(id int, status string, decidedBy int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	quote := &models.VendorQuote{}
+	row := tx.QueryRow(`SELECT `+vendorQuoteColumns+` FROM vendor_quotes
+	          WHERE quote_id = ? FOR UPDATE`, id)
+	if err := scanVendorQuote(row, quote); err != nil {
+		return err
+	}
+	if quote.Status != "submitted" {
+		return ErrQuoteAlreadyDecided
+	}
+
+	if _, err := tx.Exec(`UPDATE vendor_quotes SET status = ?, decided_by = ?, decided_at = NOW()
+	          WHERE quote_id = ?`, status, decidedBy, id); err != nil {
+		return err
+	}
+
+	if status == "approved" {
+		if _, err := tx.Exec(`UPDATE vendor_quotes SET status = 'rejected', decided_by = ?, decided_at = NOW()
+		          WHERE request_id = ? AND quote_id <> ? AND status = 'submitted'`,
+			decidedBy, quote.RequestID, id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE maintenance_requests SET vendor_id = ? WHERE request_id = ?`,
+			quote.VendorID, quote.RequestID); err != nil {
+			return err
+		}
+	}
+
+	if err := insertMaintenanceEvent(tx, &models.MaintenanceEvent{
+		RequestID: quote.RequestID,
+		EventType: "updated",
+		ActorID:   &decidedBy,
+		Note:      fmt.Sprintf("quote %d from vendor %d %s", id, quote.VendorID, status),
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateInvoice records a vendor invoice and sets the request cost to the
+// total invoiced for it.
+func (r *VendorRepository) CreateInvoice(invoice *models.VendorInvoice) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO vendor_invoices
+	          (request_id, vendor_id, quote_id, invoice_number, amount, invoice_date, file_path)
+	          VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''))`,
+		invoice.RequestID, invoice.VendorID, invoice.QuoteID, invoice.InvoiceNumber,
+		invoice.Amount, invoice.InvoiceDate, invoice.FilePath)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE maintenance_requests SET cost =
+	          (SELECT SUM(amount) FROM vendor_invoices WHERE request_id = ?)
+	          WHERE request_id = ?`, invoice.RequestID, invoice.RequestID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	invoice.ID = int(id)
+	invoice.Status = "unpaid"
+	invoice.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *VendorRepository) GetInvoicesByRequest(requestId int) ([]models.VendorInvoice, error) {
+	query := `SELECT ` + vendorInvoiceColumns + ` FROM vendor_invoices
+	          WHERE request_id = ? ORDER BY invoice_date, invoice_id`
+
+	rows, err := r.db.Query(query, requestId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoices := []models.VendorInvoice{}
+	for rows.Next() {
+		var invoice models.VendorInvoice
+		var paidAt sql.NullTime
+		err := rows.Scan(&invoice.ID, &invoice.RequestID, &invoice.VendorID, &invoice.QuoteID,
+			&invoice.InvoiceNumber, &invoice.Amount, &invoice.InvoiceDate, &invoice.FilePath,
+			&invoice.Status, &paidAt, &invoice.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if paidAt.Valid {
+			invoice.PaidAt = &paidAt.Time
+		}
+		invoices = append(invoices, invoice)
+	}
+	return invoices, rows.Err()
+}
+
+func (r *VendorRepository) MarkInvoicePaid(id int) error {
+	result, err := r.db.Exec(`UPDATE vendor_invoices SET status = 'paid', paid_at = NOW()
+	          WHERE invoice_id = ? AND status = 'unpaid'`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		var exists int
+		if err := r.db.QueryRow(`SELECT COUNT(*) FROM vendor_invoices WHERE invoice_id = ?`,
+			id).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return sql.ErrNoRows
+		}
+		return ErrInvoiceAlreadyPaid
+	}
+	return nil
+}
+
+// GetPerformance summarises jobs, quotes and invoices per vendor for work
+// reported in [from, to), for every vendor when vendorId is zero.
+func (r *VendorRepository) GetPerformance(vendorId int, from, to time.Time) ([]models.VendorPerformance, error) {
+	query := `SELECT v.vendor_id, v.name, v.trade,
+	          COALESCE(j.assigned, 0), COALESCE(j.completed, 0), COALESCE(j.resolution_hours, 0),
+	          COALESCE(q.submitted, 0), COALESCE(q.approved, 0), COALESCE(q.approved_amount, 0),
+	          COALESCE(i.invoiced, 0)
+	          FROM vendors v
+	          LEFT JOIN (
+	              SELECT vendor_id, COUNT(*) AS assigned,
+	                     SUM(status = 'completed') AS completed,
+	                     AVG(CASE WHEN status = 'completed'
+	                         THEN TIMESTAMPDIFF(MINUTE, reported_date, completed_date) END) / 60 AS resolution_hours
+	              FROM maintenance_requests
+	              WHERE vendor_id IS NOT NULL AND reported_date >= ? AND reported_date < ?
+	              GROUP BY vendor_id
+	          ) j ON j.vendor_id = v.vendor_id
+	          LEFT JOIN (
+	              SELECT vq.vendor_id, COUNT(*) AS submitted,
+	                     SUM(vq.status = 'approved') AS approved,
+	                     SUM(CASE WHEN vq.status = 'approved' THEN vq.amount ELSE 0 END) AS approved_amount
+	              FROM vendor_quotes vq
+	              JOIN maintenance_requests m ON m.request_id = vq.request_id
+	              WHERE m.reported_date >= ? AND m.reported_date < ?
+	              GROUP BY vq.vendor_id
+	          ) q ON q.vendor_id = v.vendor_id
+	          LEFT JOIN (
+	              SELECT vi.vendor_id, SUM(vi.amount) AS invoiced
+	              FROM vendor_invoices vi
+	              JOIN maintenance_requests m ON m.request_id = vi.request_id
+	              WHERE m.reported_date >= ? AND m.reported_date < ?
+	              GROUP BY vi.vendor_id
+	          ) i ON i.vendor_id = v.vendor_id
+	          WHERE ? = 0 OR v.vendor_id = ?
+	          ORDER BY v.name`
+
+	rows, err := r.db.Query(query, from, to, from, to, from, to, vendorId, vendorId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.VendorPerformance
+	for rows.Next() {
+		var p models.VendorPerformance
+		err := rows.Scan(&p.VendorID, &p.VendorName, &p.Trade, &p.JobsAssigned, &p.JobsCompleted,
+			&p.AverageResolutionHours, &p.QuotesSubmitted, &p.QuotesApproved, &p.TotalQuoted,
+			&p.TotalInvoiced)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, p)
+	}
+	return results, rows.Err()
+}
+
+func scanVendor(row rowScanner, vendor *models.Vendor) error {
+	return row.Scan(&vendor.ID, &vendor.Name, &vendor.Trade, &vendor.ContactName, &vendor.Email,
+		&vendor.Phone, &vendor.HourlyRate, &vendor.CalloutFee, &vendor.Notes, &vendor.IsActive,
+		&vendor.CreatedAt)
+}
+
+func scanVendorQuote(row rowScanner, quote *models.VendorQuote) error {
+	var decidedAt sql.NullTime
+	err := row.Scan(&quote.ID, &quote.RequestID, &quote.VendorID, &quote.Amount,
+		&quote.Description, &quote.Status, &quote.SubmittedAt, &quote.DecidedBy, &decidedAt)
+	if err != nil {
+		return err
+	}
+	if decidedAt.Valid {
+		quote.DecidedAt = &decidedAt.Time
+	}
+	return nil
+}
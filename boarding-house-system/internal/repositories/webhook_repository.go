@@ -0,0 +1,346 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/events"
+	"github.com/Kimox23/boarding-house-app/internal/models"
+)
+
+// WebhookJob is a pending delivery with the endpoint it is sent to.
+type WebhookJob struct {
+	Delivery models.WebhookDelivery
+	URL      string
+	Secret   string
+}
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// The secret is deliberately left out so that it is only ever returned when
+// a webhook is created.
+const webhookColumns = `webhook_id, url, event_types, COALESCE(description, ''), is_active,
+	consecutive_failures, COALESCE(disabled_reason, ''), created_by, created_at, updated_at`
+
+const webhookDeliveryColumns = `d.delivery_id, d.webhook_id, d.event_id, d.event_type, d.redelivery,
+	d.payload, d.status, d.attempts, d.response_code, COALESCE(d.response_body, ''),
+	COALESCE(d.last_error, ''), d.next_attempt_at, d.delivered_at, d.created_at`
+
+func (r *WebhookRepository) CreateWebhook(webhook *models.Webhook) error {
+	result, err := r.db.Exec(`INSERT INTO webhooks (url, secret, event_types, description, is_active, created_by)
+	          VALUES (?, ?, ?, NULLIF(?, ''), ?, ?)`,
+		webhook.URL, webhook.Secret, strings.Join(webhook.EventTypes, ","), webhook.Description,
+		webhook.IsActive, webhook.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	webhook.ID = int(id)
+	webhook.CreatedAt = time.Now()
+	webhook.UpdatedAt = webhook.CreatedAt
+	return nil
+}
+
+func (r *WebhookRepository) GetWebhooks() ([]models.Webhook, error) {
+	rows, err := r.db.Query(`SELECT ` + webhookColumns + ` FROM webhooks ORDER BY webhook_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		var webhook models.Webhook
+		if err := scanWebhook(rows, &webhook); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+func (r *WebhookRepository) GetWebhook(id int) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+	row := r.db.QueryRow(`SELECT `+webhookColumns+` FROM webhooks WHERE webhook_id = ?`, id)
+	if err := scanWebhook(row, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// UpdateWebhook changes a webhook's endpoint, events and state. Activating a
+// webhook clears its failure count and the reason it was disabled.
+func (r *WebhookRepository) UpdateWebhook(webhook *models.Webhook) error {
+	_, err := r.db.Exec(`UPDATE webhooks
+	          SET url = ?, event_types = ?, description = NULLIF(?, ''), is_active = ?,
+	          consecutive_failures = IF(?, 0, consecutive_failures),
+	          disabled_reason = IF(?, NULL, disabled_reason)
+	          WHERE webhook_id = ?`,
+		webhook.URL, strings.Join(webhook.EventTypes, ","), webhook.Description, webhook.IsActive,
+		webhook.IsActive, webhook.IsActive, webhook.ID)
+	return err
+}
+
+func (r *WebhookRepository) DeleteWebhook(id int) error {
+	result, err := r.db.Exec(`DELETE FROM webhooks WHERE webhook_id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+// EnqueueEvent queues an event for every active webhook subscribed to its
+// type. Queuing the same event twice is a no-op.
+func (r *WebhookRepository) EnqueueEvent(envelope events.Envelope) error {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`INSERT IGNORE INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+	          SELECT webhook_id, ?, ?, ?
+	          FROM webhooks
+	          WHERE is_active = TRUE
+	          AND (FIND_IN_SET(?, event_types) > 0 OR FIND_IN_SET(?, event_types) > 0)`,
+		envelope.ID, envelope.Type, payload, envelope.Type, events.All)
+	return err
+}
+
+// GetDeliveries returns a webhook's newest deliveries first.
+func (r *WebhookRepository) GetDeliveries(webhookId, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := r.db.Query(`SELECT `+webhookDeliveryColumns+`
+	          FROM webhook_deliveries d
+	          WHERE d.webhook_id = ?
+	          ORDER BY d.delivery_id DESC
+	          LIMIT ?`, webhookId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		if err := scanWebhookDelivery(rows, &delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// Redeliver queues a copy of an earlier delivery with the same payload, so
+// the receiver sees the same event again. It returns sql.ErrNoRows when the
+// delivery does not belong to the webhook.
+func (r *WebhookRepository) Redeliver(webhookId int, deliveryId int64) (*models.WebhookDelivery, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	original := &models.WebhookDelivery{}
+	row := tx.QueryRow(`SELECT `+webhookDeliveryColumns+`
+	          FROM webhook_deliveries d
+	          WHERE d.delivery_id = ? AND d.webhook_id = ?`, deliveryId, webhookId)
+	if err := scanWebhookDelivery(row, original); err != nil {
+		return nil, err
+	}
+
+	var redelivery int
+	if err := tx.QueryRow(`SELECT MAX(redelivery) + 1 FROM webhook_deliveries
+	          WHERE webhook_id = ? AND event_id = ? FOR UPDATE`,
+		webhookId, original.EventID).Scan(&redelivery); err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(`INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, redelivery, payload)
+	          VALUES (?, ?, ?, ?, ?)`,
+		webhookId, original.EventID, original.EventType, redelivery, []byte(original.Payload))
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &models.WebhookDelivery{
+		ID:            id,
+		WebhookID:     webhookId,
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		Redelivery:    redelivery,
+		Payload:       original.Payload,
+		Status:        "pending",
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+	}, nil
+}
+
+// GetDueDeliveries returns up to limit pending deliveries of active webhooks
+// whose next attempt is at or before asOf, oldest first. Deliveries of a
+// disabled webhook wait until it is activated again.
+func (r *WebhookRepository) GetDueDeliveries(asOf time.Time, limit int) ([]WebhookJob, error) {
+	rows, err := r.db.Query(`SELECT `+webhookDeliveryColumns+`, w.url, w.secret
+	          FROM webhook_deliveries d
+	          JOIN webhooks w ON w.webhook_id = d.webhook_id
+	          WHERE d.status = 'pending' AND d.next_attempt_at <= ? AND w.is_active = TRUE
+	          ORDER BY d.next_attempt_at
+	          LIMIT ?`, asOf, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []WebhookJob
+	for rows.Next() {
+		var job WebhookJob
+		if err := scanWebhookDelivery(rows, &job.Delivery, &job.URL, &job.Secret); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// Claim pushes a due delivery's next attempt out by lease so that no other
+// instance sends it at the same time. It returns false when the delivery
+// was already claimed.
+func (r *WebhookRepository) Claim(id int64, asOf time.Time, lease time.Duration) (bool, error) {
+	result, err := r.db.Exec(`UPDATE webhook_deliveries SET next_attempt_at = ?
+	          WHERE delivery_id = ? AND status = 'pending' AND next_attempt_at <= ?`,
+		asOf.Add(lease), id, asOf)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// MarkSucceeded records a delivery the receiver accepted and resets the
+// webhook's failure count.
+func (r *WebhookRepository) MarkSucceeded(delivery *models.WebhookDelivery, responseCode int, responseBody string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE webhook_deliveries
+	          SET status = 'succeeded', attempts = attempts + 1, response_code = ?,
+	          response_body = NULLIF(?, ''), last_error = NULL, next_attempt_at = NULL,
+	          delivered_at = CURRENT_TIMESTAMP
+	          WHERE delivery_id = ?`, responseCode, responseBody, delivery.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE webhooks SET consecutive_failures = 0 WHERE webhook_id = ?`,
+		delivery.WebhookID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MarkAttemptFailed records a failed attempt and counts it against the
+// webhook. A nil retryAt gives up and marks the delivery failed; responseCode
+// is nil when no response was received. Once the webhook has failed
+// disableAfter times in a row it is disabled with reason, and disabled
+// reports whether this attempt did so.
+func (r *WebhookRepository) MarkAttemptFailed(delivery *models.WebhookDelivery, responseCode *int,
+	responseBody, lastError string, retryAt *time.Time, disableAfter int, reason string) (bool, error) {
+	status := "pending"
+	if retryAt == nil {
+		status = "failed"
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE webhook_deliveries
+	          SET status = ?, attempts = attempts + 1, response_code = ?, response_body = NULLIF(?, ''),
+	          last_error = LEFT(?, 255), next_attempt_at = ?
+	          WHERE delivery_id = ?`,
+		status, responseCode, responseBody, lastError, retryAt, delivery.ID); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`UPDATE webhooks SET consecutive_failures = consecutive_failures + 1
+	          WHERE webhook_id = ?`, delivery.WebhookID); err != nil {
+		return false, err
+	}
+	result, err := tx.Exec(`UPDATE webhooks SET is_active = FALSE, disabled_reason = LEFT(?, 255)
+	          WHERE webhook_id = ? AND is_active = TRUE AND consecutive_failures >= ?`,
+		reason, delivery.WebhookID, disableAfter)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func scanWebhook(row rowScanner, webhook *models.Webhook) error {
+	var eventTypes string
+	var createdBy sql.NullInt64
+	if err := row.Scan(&webhook.ID, &webhook.URL, &eventTypes, &webhook.Description, &webhook.IsActive,
+		&webhook.ConsecutiveFailures, &webhook.DisabledReason, &createdBy, &webhook.CreatedAt,
+		&webhook.UpdatedAt); err != nil {
+		return err
+	}
+	webhook.EventTypes = strings.Split(eventTypes, ",")
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		webhook.CreatedBy = &id
+	}
+	return nil
+}
+
+func scanWebhookDelivery(row rowScanner, delivery *models.WebhookDelivery, extra ...interface{}) error {
+	var payload []byte
+	var responseCode sql.NullInt64
+	var nextAttemptAt, deliveredAt sql.NullTime
+	dest := []interface{}{&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType,
+		&delivery.Redelivery, &payload, &delivery.Status, &delivery.Attempts, &responseCode,
+		&delivery.ResponseBody, &delivery.LastError, &nextAttemptAt, &deliveredAt, &delivery.CreatedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	delivery.Payload = json.RawMessage(payload)
+	if responseCode.Valid {
+		code := int(responseCode.Int64)
+		delivery.ResponseCode = &code
+	}
+	if nextAttemptAt.Valid {
+		delivery.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return nil
+}
@@ -3,13 +3,16 @@ package routes
 import (
 	"database/sql"
 	"log"
-	"os"
 
 	"github.com/Kimox23/boarding-house-app/internal/config"
 	"github.com/Kimox23/boarding-house-app/internal/controllers"
+	"github.com/Kimox23/boarding-house-app/internal/events"
 	"github.com/Kimox23/boarding-house-app/internal/middleware"
+	"github.com/Kimox23/boarding-house-app/internal/notify"
+	"github.com/Kimox23/boarding-house-app/internal/realtime"
 	"github.com/Kimox23/boarding-house-app/internal/repositories"
 	"github.com/Kimox23/boarding-house-app/internal/services"
+	"github.com/Kimox23/boarding-house-app/internal/storage"
 
 	"github.com/gofiber/fiber/v3"
 )
@@ -20,12 +23,28 @@ func SetupRoutes(app *fiber.App, db *sql.DB, cfg *config.Config) {
 	if db == nil {
 		panic("Database connection is nil!")
 	}
-	// Ensure upload directory exists
-	uploadDir := "uploads"
-	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
+	// Uploaded files are kept in the local upload directory or in an
+	// S3-compatible bucket, depending on cfg.StorageDriver.
+	store, err := storage.New(cfg)
+	if err != nil {
 		panic(err)
 	}
 
+	// Real-time events are fanned out through the broker so that clients
+	// connected to any instance receive them.
+	broker, err := realtime.NewBroker(cfg, db)
+	if err != nil {
+		panic(err)
+	}
+	hub, err := realtime.NewHub(broker)
+	if err != nil {
+		panic(err)
+	}
+
+	// Domain events are written to the outbox by the repositories and
+	// relayed to these subscribers in the background.
+	bus := events.NewBus()
+
 	// Initialize all repositories
 	userRepo := repositories.NewUserRepository(db)
 	houseRepo := repositories.NewHouseRepository(db)
@@ -35,32 +54,111 @@ func SetupRoutes(app *fiber.App, db *sql.DB, cfg *config.Config) {
 	maintenanceRepo := repositories.NewMaintenanceRepository(db)
 	notificationRepo := repositories.NewNotificationRepository(db)
 	documentRepo := repositories.NewDocumentRepository(db)
+	reconciliationRepo := repositories.NewReconciliationRepository(db)
+	invoiceRepo := repositories.NewInvoiceRepository(db)
+	reportRepo := repositories.NewReportRepository(db)
+	expenseRepo := repositories.NewExpenseRepository(db)
+	ownerRepo := repositories.NewOwnerRepository(db)
+	analyticsRepo := repositories.NewAnalyticsRepository(db)
+	reservationRepo := repositories.NewReservationRepository(db)
+	slaRepo := repositories.NewSLARepository(db)
+	vendorRepo := repositories.NewVendorRepository(db)
+	planRepo := repositories.NewMaintenancePlanRepository(db)
+	assetRepo := repositories.NewAssetRepository(db)
+	inspectionRepo := repositories.NewInspectionRepository(db)
+	assignmentRepo := repositories.NewAssignmentRepository(db)
+	ratingRepo := repositories.NewRatingRepository(db)
+	deliveryRepo := repositories.NewNotificationDeliveryRepository(db)
+	templateRepo := repositories.NewNotificationTemplateRepository(db)
+	outboxRepo := repositories.NewOutboxRepository(db)
+	webhookRepo := repositories.NewWebhookRepository(db)
+	announcementRepo := repositories.NewAnnouncementRepository(db)
+	messageRepo := repositories.NewMessageRepository(db)
 
 	// Initialize all services
+	templateService := services.NewNotificationTemplateService(templateRepo, notificationRepo, deliveryRepo)
 	userService := services.NewUserService(userRepo)
 	houseService := services.NewHouseService(houseRepo)
 	roomService := services.NewRoomService(roomRepo)
 	tenantService := services.NewTenantService(tenantRepo)
 	paymentService := services.NewPaymentService(paymentRepo)
-	maintenanceService := services.NewMaintenanceService(maintenanceRepo)
-	notificationService := services.NewNotificationService(notificationRepo)
+	slaService := services.NewSLAService(slaRepo, templateService)
+	assignmentService := services.NewAssignmentService(assignmentRepo, templateService, slaService)
+	maintenanceService := services.NewMaintenanceService(maintenanceRepo, templateService, assignmentService, cfg.MaintenanceReopenDays)
+	notificationService := services.NewNotificationService(notificationRepo, cfg.NotificationRetentionDays)
 	documentService := services.NewDocumentService(documentRepo)
+	reconciliationService := services.NewReconciliationService(reconciliationRepo)
+	invoiceService := services.NewInvoiceService(invoiceRepo)
+	reportService := services.NewReportService(reportRepo)
+	expenseService := services.NewExpenseService(expenseRepo)
+	ownerService := services.NewOwnerService(ownerRepo, reportRepo)
+	analyticsService := services.NewAnalyticsService(analyticsRepo, reportRepo, reservationRepo, cfg.AnalyticsCacheTTL)
+	reservationService := services.NewReservationService(reservationRepo)
+	vendorService := services.NewVendorService(vendorRepo, maintenanceRepo)
+	planService := services.NewMaintenancePlanService(planRepo, templateService, assignmentService)
+	assetService := services.NewAssetService(assetRepo)
+	inspectionService := services.NewInspectionService(inspectionRepo, templateService)
+	ratingService := services.NewRatingService(ratingRepo, maintenanceService)
+	realtimeService := services.NewRealtimeService(hub, notificationRepo, maintenanceService)
+	emailSender := notify.NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPassword, cfg.EmailFrom)
+	auditService := services.NewAuditService(outboxRepo)
+	eventRelay := services.NewEventRelay(outboxRepo, bus)
+	templateService.Subscribe(bus)
+	auditService.Subscribe(bus)
+	webhookService := services.NewWebhookService(webhookRepo)
+	webhookService.Subscribe(bus)
+	announcementService := services.NewAnnouncementService(announcementRepo, notificationRepo)
+	announcementService.Subscribe(bus)
+	messageService := services.NewMessageService(messageRepo, templateService)
+	dispatcher := services.NewNotificationDispatcher(deliveryRepo, notificationRepo, emailSender,
+		notify.NewFakeSMSProvider(), cfg.AppURL)
 
 	// Initialize all controllers
 	authController := controllers.NewAuthController(userService, cfg)
-	userController := controllers.NewUserController(userService)
+	userController := controllers.NewUserController(userService, store, cfg.MaxUploadSize, cfg.SignedURLTTL)
 	houseController := controllers.NewHouseController(houseService)
 	roomController := controllers.NewRoomController(roomService)
 	tenantController := controllers.NewTenantController(tenantService)
 	paymentController := controllers.NewPaymentController(paymentService)
-	maintenanceController := controllers.NewMaintenanceController(maintenanceService)
-	notificationController := controllers.NewNotificationController(notificationService)
-	documentController := controllers.NewDocumentController(documentService, uploadDir)
+	maintenanceController := controllers.NewMaintenanceController(maintenanceService, store, cfg.MaxAttachmentSize, cfg.SignedURLTTL)
+	notificationController := controllers.NewNotificationController(notificationService, dispatcher, templateService)
+	documentController := controllers.NewDocumentController(documentService, store, cfg.SignedURLTTL)
+	reconciliationController := controllers.NewReconciliationController(reconciliationService)
+	invoiceController := controllers.NewInvoiceController(invoiceService)
+	reportController := controllers.NewReportController(reportService)
+	expenseController := controllers.NewExpenseController(expenseService, store, cfg.SignedURLTTL)
+	ownerController := controllers.NewOwnerController(ownerService)
+	analyticsController := controllers.NewAnalyticsController(analyticsService)
+	reservationController := controllers.NewReservationController(reservationService)
+	slaController := controllers.NewSLAController(slaService)
+	vendorController := controllers.NewVendorController(vendorService, store, cfg.SignedURLTTL)
+	planController := controllers.NewMaintenancePlanController(planService)
+	assetController := controllers.NewAssetController(assetService)
+	inspectionController := controllers.NewInspectionController(inspectionService, store, cfg.MaxAttachmentSize, cfg.SignedURLTTL)
+	assignmentController := controllers.NewAssignmentController(assignmentService, maintenanceService)
+	ratingController := controllers.NewRatingController(ratingService)
+	realtimeController := controllers.NewRealtimeController(realtimeService, cfg)
+	auditController := controllers.NewAuditController(auditService)
+	webhookController := controllers.NewWebhookController(webhookService)
+	announcementController := controllers.NewAnnouncementController(announcementService)
+	messageController := controllers.NewMessageController(messageService, store, cfg.MaxAttachmentSize, cfg.AllowedTypes,
+		cfg.SignedURLTTL)
+	fileController := controllers.NewFileController(store, cfg.SignedURLTTL)
 
-	app.Get("/uploads/*", func(c fiber.Ctx) error {
-		file := "./uploads/" + c.Params("*")
-		return c.SendFile(file)
-	})
+	// Background jobs
+	go slaService.Run(cfg.SLACheckInterval)
+	go planService.Run(cfg.PlanCheckInterval)
+	go dispatcher.Run(cfg.NotificationDispatchInterval)
+	go eventRelay.Run(cfg.OutboxPollInterval)
+	go webhookService.Run(cfg.WebhookDeliveryInterval)
+	go announcementService.Run(cfg.AnnouncementInterval)
+	go notificationService.Run(cfg.NotificationPurgeInterval)
+	go expenseService.Run(cfg.RecurringExpenseInterval)
+
+	// Signed URLs of the local backend point back at this route
+	if _, ok := store.(storage.URLVerifier); ok {
+		app.Get("/uploads/*", fileController.Serve)
+	}
 
 	// Auth routes
 	authGroup := app.Group("/api/auth")
@@ -81,6 +179,7 @@ func SetupRoutes(app *fiber.App, db *sql.DB, cfg *config.Config) {
 		// User profile routes
 		userGroup.Post("/:userId/profile", userController.CreateProfile)
 		userGroup.Get("/:userId/profile", userController.GetProfile)
+		userGroup.Post("/:userId/profile/picture", userController.UploadProfilePicture)
 	}
 
 	// Boarding house routes
@@ -119,27 +218,72 @@ func SetupRoutes(app *fiber.App, db *sql.DB, cfg *config.Config) {
 		paymentGroup.Post("/", paymentController.CreatePayment, middleware.RoleRequired("staff", cfg))
 		paymentGroup.Get("/tenant/:tenantId", paymentController.GetPaymentsByTenant)
 		paymentGroup.Get("/:id", paymentController.GetPayment)
-		paymentGroup.Put("/:id", paymentController.UpdatePayment, middleware.RoleRequired("staff", cfg))
-		paymentGroup.Delete("/:id", paymentController.DeletePayment, middleware.RoleRequired("staff", cfg))
+		paymentGroup.Post("/:id/void", paymentController.VoidPayment, middleware.RoleRequired("staff", cfg))
+		paymentGroup.Post("/:id/refund", paymentController.RefundPayment, middleware.RoleRequired("staff", cfg))
 	}
 
 	// Maintenance routes
 	maintenanceGroup := app.Group("/api/maintenance", middleware.AuthRequired(cfg))
 	{
 		maintenanceGroup.Post("/", maintenanceController.CreateRequest)
+		maintenanceGroup.Get("/sla/metrics", slaController.Metrics, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Get("/sla/policies/:houseId", slaController.GetPolicies, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Put("/sla/policies/:houseId", slaController.SetPolicy, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Delete("/sla/policies/:houseId/:priority", slaController.ResetPolicy, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Get("/plans", planController.GetPlans, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Post("/plans", planController.CreatePlan, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Post("/plans/generate", planController.Generate, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Get("/plans/calendar", planController.Calendar, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Get("/plans/history/room/:roomId", planController.RoomHistory, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Get("/plans/history/asset/:assetId", planController.AssetHistory, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Get("/plans/:planId", planController.GetPlan, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Put("/plans/:planId", planController.UpdatePlan, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Delete("/plans/:planId", planController.DeactivatePlan, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Get("/plans/:planId/history", planController.PlanHistory, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Get("/assignment/rules", assignmentController.GetRules, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Post("/assignment/rules", assignmentController.CreateRule, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Put("/assignment/rules/:ruleId", assignmentController.UpdateRule, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Delete("/assignment/rules/:ruleId", assignmentController.DeleteRule, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Get("/assignment/staff", assignmentController.GetStaff, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Put("/assignment/staff/:userId", assignmentController.UpdateStaffProfile, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Put("/assignment/shift", assignmentController.SetShift, middleware.RoleRequired("staff", cfg))
+		maintenanceGroup.Get("/queue", assignmentController.MyQueue, middleware.RoleRequired("staff", cfg))
+		maintenanceGroup.Get("/queue/:staffId", assignmentController.Queue, middleware.RoleRequired("manager", cfg))
 		maintenanceGroup.Get("/room/:roomId", maintenanceController.GetRequestsByRoom)
 		maintenanceGroup.Get("/:id", maintenanceController.GetRequest)
+		maintenanceGroup.Get("/:id/timeline", maintenanceController.GetTimeline)
+		maintenanceGroup.Post("/:id/auto-assign", assignmentController.AutoAssign, middleware.RoleRequired("manager", cfg))
+		maintenanceGroup.Get("/:id/rating", ratingController.GetRatings)
+		maintenanceGroup.Post("/:id/rating", ratingController.Rate)
+		maintenanceGroup.Get("/:id/comments", maintenanceController.GetComments)
+		maintenanceGroup.Post("/:id/comments", maintenanceController.AddComment)
 		maintenanceGroup.Put("/:id", maintenanceController.UpdateRequest, middleware.RoleRequired("staff", cfg))
 		maintenanceGroup.Patch("/:id/status", maintenanceController.UpdateRequestStatus, middleware.RoleRequired("staff", cfg))
 		maintenanceGroup.Delete("/:id", maintenanceController.DeleteRequest, middleware.RoleRequired("staff", cfg))
 	}
 
+	// The event stream also accepts a stream token in the query string, so
+	// it is registered ahead of the notification group's AuthRequired
+	app.Get("/api/notifications/stream", realtimeController.Stream, middleware.StreamAuth(cfg))
+
 	// Notification routes
 	notificationGroup := app.Group("/api/notifications", middleware.AuthRequired(cfg))
 	{
 		notificationGroup.Post("/", notificationController.CreateNotification, middleware.RoleRequired("admin", cfg))
+		notificationGroup.Post("/stream-token", realtimeController.StreamToken)
+		notificationGroup.Get("/me", notificationController.GetMyNotifications)
+		notificationGroup.Get("/me/unread-count", notificationController.GetUnreadCount)
+		notificationGroup.Post("/me/read-all", notificationController.MarkAllRead)
+		notificationGroup.Post("/me/delete", notificationController.DeleteNotifications)
+		notificationGroup.Get("/preferences", notificationController.GetPreferences)
+		notificationGroup.Put("/preferences", notificationController.UpdatePreferences)
+		notificationGroup.Get("/templates", notificationController.GetTemplates, middleware.RoleRequired("admin", cfg))
+		notificationGroup.Post("/templates/:name/preview", notificationController.PreviewTemplate, middleware.RoleRequired("admin", cfg))
+		notificationGroup.Put("/templates/:name/:locale", notificationController.SaveTemplate, middleware.RoleRequired("admin", cfg))
+		notificationGroup.Delete("/templates/:name/:locale", notificationController.DeleteTemplate, middleware.RoleRequired("admin", cfg))
 		notificationGroup.Get("/user/:userId", notificationController.GetUserNotifications)
 		notificationGroup.Patch("/:id/read", notificationController.MarkAsRead)
+		notificationGroup.Get("/:id/deliveries", notificationController.GetDeliveries)
 		notificationGroup.Delete("/:id", notificationController.DeleteNotification)
 	}
 
@@ -151,4 +295,173 @@ func SetupRoutes(app *fiber.App, db *sql.DB, cfg *config.Config) {
 		documentGroup.Patch("/:id/verify", documentController.VerifyDocument, middleware.RoleRequired("staff", cfg))
 		documentGroup.Delete("/:id", documentController.DeleteDocument)
 	}
+
+	// Bank reconciliation routes
+	reconciliationGroup := app.Group("/api/reconciliation", middleware.AuthRequired(cfg), middleware.RoleRequired("staff", cfg))
+	{
+		reconciliationGroup.Post("/imports", reconciliationController.ImportStatement)
+		reconciliationGroup.Get("/imports/:id", reconciliationController.GetImport)
+		reconciliationGroup.Post("/imports/:id/lines/:lineId/ignore", reconciliationController.IgnoreLine)
+		reconciliationGroup.Post("/imports/:id/confirm", reconciliationController.ConfirmMatches)
+	}
+
+	// Invoice routes
+	invoiceGroup := app.Group("/api/invoices", middleware.AuthRequired(cfg))
+	{
+		invoiceGroup.Post("/generate", invoiceController.GenerateMonthlyInvoices, middleware.RoleRequired("manager", cfg))
+		invoiceGroup.Get("/tenant/:tenantId", invoiceController.GetInvoicesByTenant)
+	}
+
+	// Report routes
+	reportGroup := app.Group("/api/reports", middleware.AuthRequired(cfg), middleware.RoleRequired("manager", cfg))
+	{
+		reportGroup.Get("/aging", reportController.AgingReport)
+		reportGroup.Get("/profit-loss", reportController.ProfitAndLoss)
+		reportGroup.Get("/satisfaction", ratingController.Report)
+	}
+
+	// Reservation routes
+	reservationGroup := app.Group("/api/reservations", middleware.AuthRequired(cfg), middleware.RoleRequired("manager", cfg))
+	{
+		reservationGroup.Post("/", reservationController.CreateReservation)
+		reservationGroup.Get("/house/:houseId", reservationController.GetReservationsByHouse)
+		reservationGroup.Get("/:id", reservationController.GetReservation)
+		reservationGroup.Post("/:id/confirm", reservationController.ConfirmReservation)
+		reservationGroup.Post("/:id/cancel", reservationController.CancelReservation)
+		reservationGroup.Post("/:id/convert", reservationController.ConvertReservation)
+	}
+
+	// Expense routes
+	expenseGroup := app.Group("/api/expenses", middleware.AuthRequired(cfg), middleware.RoleRequired("manager", cfg))
+	{
+		expenseGroup.Post("/", expenseController.CreateExpense)
+		expenseGroup.Get("/house/:houseId", expenseController.GetExpensesByHouse)
+		expenseGroup.Post("/recurring", expenseController.CreateRecurringExpense)
+		expenseGroup.Post("/recurring/post", expenseController.PostRecurringExpenses)
+		expenseGroup.Get("/recurring/house/:houseId", expenseController.GetRecurringExpensesByHouse)
+		expenseGroup.Delete("/recurring/:id", expenseController.DeactivateRecurringExpense)
+		expenseGroup.Get("/:id", expenseController.GetExpense)
+		expenseGroup.Put("/:id", expenseController.UpdateExpense)
+		expenseGroup.Delete("/:id", expenseController.DeleteExpense)
+		expenseGroup.Post("/:id/receipt", expenseController.UploadReceipt)
+	}
+
+	// Owner routes
+	ownerGroup := app.Group("/api/owners", middleware.AuthRequired(cfg), middleware.RoleRequired("admin", cfg))
+	{
+		ownerGroup.Post("/", ownerController.CreateOwner)
+		ownerGroup.Get("/", ownerController.GetAllOwners)
+		ownerGroup.Get("/statements/:statementId", ownerController.GetStatement)
+		ownerGroup.Post("/statements/:statementId/payouts", ownerController.RecordPayout)
+		ownerGroup.Get("/:id", ownerController.GetOwner)
+		ownerGroup.Put("/:id", ownerController.UpdateOwner)
+		ownerGroup.Post("/:id/houses", ownerController.AssignHouse)
+		ownerGroup.Delete("/:id/houses/:houseId", ownerController.UnassignHouse)
+		ownerGroup.Post("/:id/statements", ownerController.GenerateStatement)
+		ownerGroup.Get("/:id/statements", ownerController.GetStatementsByOwner)
+	}
+
+	// Analytics routes
+	analyticsGroup := app.Group("/api/analytics", middleware.AuthRequired(cfg), middleware.RoleRequired("admin", cfg))
+	{
+		analyticsGroup.Get("/", analyticsController.Summary)
+		analyticsGroup.Get("/occupancy", analyticsController.Occupancy)
+		analyticsGroup.Get("/revenue", analyticsController.Revenue)
+		analyticsGroup.Get("/maintenance", analyticsController.Maintenance)
+		analyticsGroup.Get("/forecast", analyticsController.Forecast)
+	}
+
+	// Vendor routes
+	vendorGroup := app.Group("/api/vendors", middleware.AuthRequired(cfg), middleware.RoleRequired("manager", cfg))
+	{
+		vendorGroup.Post("/", vendorController.CreateVendor)
+		vendorGroup.Get("/", vendorController.GetVendors)
+		vendorGroup.Get("/performance", vendorController.Performance)
+		vendorGroup.Post("/requests/:requestId/assign", vendorController.AssignVendor)
+		vendorGroup.Get("/requests/:requestId/quotes", vendorController.GetQuotes)
+		vendorGroup.Post("/requests/:requestId/quotes", vendorController.SubmitQuote)
+		vendorGroup.Get("/requests/:requestId/invoices", vendorController.GetInvoices)
+		vendorGroup.Post("/requests/:requestId/invoices", vendorController.RecordInvoice)
+		vendorGroup.Post("/quotes/:quoteId/approve", vendorController.ApproveQuote)
+		vendorGroup.Post("/quotes/:quoteId/reject", vendorController.RejectQuote)
+		vendorGroup.Post("/invoices/:invoiceId/pay", vendorController.MarkInvoicePaid)
+		vendorGroup.Get("/:id", vendorController.GetVendor)
+		vendorGroup.Put("/:id", vendorController.UpdateVendor)
+		vendorGroup.Post("/:id/documents", vendorController.UploadDocument)
+		vendorGroup.Get("/:id/performance", vendorController.Performance)
+	}
+
+	// Asset inventory routes
+	assetGroup := app.Group("/api/assets", middleware.AuthRequired(cfg))
+	{
+		assetGroup.Post("/", assetController.CreateAsset, middleware.RoleRequired("manager", cfg))
+		assetGroup.Get("/room/:roomId", assetController.GetAssetsByRoom)
+		assetGroup.Get("/report/:houseId", assetController.Report, middleware.RoleRequired("manager", cfg))
+		assetGroup.Get("/:id", assetController.GetAsset)
+		assetGroup.Put("/:id", assetController.UpdateAsset, middleware.RoleRequired("manager", cfg))
+		assetGroup.Delete("/:id", assetController.DisposeAsset, middleware.RoleRequired("manager", cfg))
+		assetGroup.Get("/:id/repairs", assetController.RepairHistory, middleware.RoleRequired("manager", cfg))
+	}
+
+	// Inspection routes
+	inspectionGroup := app.Group("/api/inspections", middleware.AuthRequired(cfg))
+	{
+		inspectionGroup.Get("/checklists/:roomType", inspectionController.GetChecklist)
+		inspectionGroup.Post("/checklists", inspectionController.CreateChecklistItem, middleware.RoleRequired("manager", cfg))
+		inspectionGroup.Put("/checklists/items/:itemId", inspectionController.UpdateChecklistItem, middleware.RoleRequired("manager", cfg))
+		inspectionGroup.Delete("/checklists/items/:itemId", inspectionController.DeactivateChecklistItem, middleware.RoleRequired("manager", cfg))
+		inspectionGroup.Post("/", inspectionController.StartInspection, middleware.RoleRequired("manager", cfg))
+		inspectionGroup.Get("/tenant/:tenantId", inspectionController.GetInspectionsByTenant)
+		inspectionGroup.Get("/tenant/:tenantId/diff", inspectionController.Diff)
+		inspectionGroup.Get("/:id", inspectionController.GetInspection)
+		inspectionGroup.Post("/:id/items", inspectionController.AddItem, middleware.RoleRequired("manager", cfg))
+		inspectionGroup.Put("/:id/items/:itemId", inspectionController.UpdateItem, middleware.RoleRequired("manager", cfg))
+		inspectionGroup.Post("/:id/items/:itemId/photos", inspectionController.UploadPhotos, middleware.RoleRequired("manager", cfg))
+		inspectionGroup.Post("/:id/complete", inspectionController.CompleteInspection, middleware.RoleRequired("manager", cfg))
+		inspectionGroup.Post("/:id/acknowledge", inspectionController.Acknowledge)
+	}
+
+	// Audit routes
+	auditGroup := app.Group("/api/audit", middleware.AuthRequired(cfg), middleware.RoleRequired("admin", cfg))
+	{
+		auditGroup.Get("/", auditController.GetAuditLog)
+	}
+
+	// Webhook routes
+	webhookGroup := app.Group("/api/webhooks", middleware.AuthRequired(cfg), middleware.RoleRequired("admin", cfg))
+	{
+		webhookGroup.Post("/", webhookController.CreateWebhook)
+		webhookGroup.Get("/", webhookController.GetWebhooks)
+		webhookGroup.Get("/:id", webhookController.GetWebhook)
+		webhookGroup.Put("/:id", webhookController.UpdateWebhook)
+		webhookGroup.Delete("/:id", webhookController.DeleteWebhook)
+		webhookGroup.Get("/:id/deliveries", webhookController.GetDeliveries)
+		webhookGroup.Post("/:id/deliveries/:deliveryId/redeliver", webhookController.Redeliver)
+	}
+
+	// Announcement routes
+	announcementGroup := app.Group("/api/announcements", middleware.AuthRequired(cfg))
+	{
+		announcementGroup.Post("/", announcementController.CreateAnnouncement, middleware.RoleRequired("admin", cfg))
+		announcementGroup.Get("/", announcementController.GetAnnouncements, middleware.RoleRequired("admin", cfg))
+		announcementGroup.Get("/me", announcementController.GetMyAnnouncements)
+		announcementGroup.Get("/board/:houseId", announcementController.GetBoard)
+		announcementGroup.Get("/:id", announcementController.GetAnnouncement, middleware.RoleRequired("admin", cfg))
+		announcementGroup.Put("/:id", announcementController.UpdateAnnouncement, middleware.RoleRequired("admin", cfg))
+		announcementGroup.Delete("/:id", announcementController.DeleteAnnouncement, middleware.RoleRequired("admin", cfg))
+		announcementGroup.Post("/:id/read", announcementController.MarkRead)
+		announcementGroup.Get("/:id/receipts", announcementController.GetReceipts, middleware.RoleRequired("admin", cfg))
+	}
+
+	// Messaging routes
+	messageGroup := app.Group("/api/messages", middleware.AuthRequired(cfg))
+	{
+		messageGroup.Get("/unread-count", messageController.GetUnreadCount)
+		messageGroup.Post("/conversations", messageController.StartConversation)
+		messageGroup.Get("/conversations", messageController.GetConversations)
+		messageGroup.Get("/conversations/:id", messageController.GetConversation)
+		messageGroup.Post("/conversations/:id/messages", messageController.SendMessage)
+		messageGroup.Post("/conversations/:id/read", messageController.MarkRead)
+		messageGroup.Patch("/conversations/:id/status", messageController.UpdateStatus)
+	}
 }
@@ -0,0 +1,399 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
+)
+
+// maxForecastMonths bounds the forecast horizon.
+const maxForecastMonths = 24
+
+var (
+	ErrInvalidForecastHorizon = errors.New("months must be between 1 and 24")
+	ErrInvalidAnalyticsFilter = errors.New("invalid filter")
+)
+
+// AnalyticsFilter restricts analytics to a YYYY-MM month range and
+// optionally a single house.
+type AnalyticsFilter struct {
+	HouseID string
+	From    string
+	To      string
+}
+
+type AnalyticsService struct {
+	analyticsRepo   *repositories.AnalyticsRepository
+	reportRepo      *repositories.ReportRepository
+	reservationRepo *repositories.ReservationRepository
+	cache           *utils.TTLCache
+}
+
+func NewAnalyticsService(analyticsRepo *repositories.AnalyticsRepository,
+	reportRepo *repositories.ReportRepository, reservationRepo *repositories.ReservationRepository,
+	cacheTTL time.Duration) *AnalyticsService {
+	return &AnalyticsService{
+		analyticsRepo:   analyticsRepo,
+		reportRepo:      reportRepo,
+		reservationRepo: reservationRepo,
+		cache:           utils.NewTTLCache(cacheTTL),
+	}
+}
+
+// Occupancy returns monthly occupancy rate, vacancy days and average length
+// of stay per house.
+func (s *AnalyticsService) Occupancy(filter AnalyticsFilter) ([]models.HouseOccupancy, error) {
+	value, err := s.cache.GetOrLoad(cacheKey("occupancy", filter), func() (interface{}, error) {
+		houseID, start, end, err := parseFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		houses, err := s.reportRepo.GetHouses(houseID)
+		if err != nil {
+			return nil, err
+		}
+
+		months := monthRanges(start, end)
+		capacity, err := s.analyticsRepo.GetBedCapacity(houseID)
+		if err != nil {
+			return nil, err
+		}
+		occupied, err := s.analyticsRepo.GetOccupiedDays(houseID, months)
+		if err != nil {
+			return nil, err
+		}
+		stays, err := s.analyticsRepo.GetStayStats(houseID, start, end)
+		if err != nil {
+			return nil, err
+		}
+
+		result := make([]models.HouseOccupancy, 0, len(houses))
+		for _, house := range houses {
+			h := models.HouseOccupancy{
+				HouseID:         house.ID,
+				HouseName:       house.Name,
+				AverageStayDays: roundCents(stays[house.ID].AverageStayDays),
+				CompletedStays:  stays[house.ID].CompletedStays,
+			}
+			totalBedDays, totalOccupied := 0, 0
+			for _, m := range months {
+				bedDays := capacity[house.ID] * int(m.End.Sub(m.Start).Hours()/24+0.5)
+				days := occupied[house.ID][m.Key]
+				point := models.OccupancyPoint{
+					Month:        m.Key,
+					BedDays:      bedDays,
+					OccupiedDays: days,
+					VacancyDays:  max(bedDays-days, 0),
+					Rate:         ratio(float64(days), float64(bedDays)),
+				}
+				h.Months = append(h.Months, point)
+				h.VacancyDays += point.VacancyDays
+				totalBedDays += bedDays
+				totalOccupied += days
+			}
+			h.AverageOccupancy = ratio(float64(totalOccupied), float64(totalBedDays))
+			result = append(result, h)
+		}
+		return result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]models.HouseOccupancy), nil
+}
+
+// Revenue compares rent billed with rent collected per house.
+func (s *AnalyticsService) Revenue(filter AnalyticsFilter) ([]models.HouseRevenue, error) {
+	value, err := s.cache.GetOrLoad(cacheKey("revenue", filter), func() (interface{}, error) {
+		houseID, start, end, err := parseFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		houses, err := s.reportRepo.GetHouses(houseID)
+		if err != nil {
+			return nil, err
+		}
+		billed, err := s.analyticsRepo.GetBilledByHouse(houseID, start, end)
+		if err != nil {
+			return nil, err
+		}
+		collected, err := s.analyticsRepo.GetCollectedByHouse(houseID, start, end)
+		if err != nil {
+			return nil, err
+		}
+
+		result := make([]models.HouseRevenue, 0, len(houses))
+		for _, house := range houses {
+			result = append(result, models.HouseRevenue{
+				HouseID:        house.ID,
+				HouseName:      house.Name,
+				Billed:         roundCents(billed[house.ID]),
+				Collected:      roundCents(collected[house.ID]),
+				CollectionRate: ratio(collected[house.ID], billed[house.ID]),
+			})
+		}
+		return result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]models.HouseRevenue), nil
+}
+
+// Maintenance returns request volume and average resolution time per house.
+func (s *AnalyticsService) Maintenance(filter AnalyticsFilter) ([]models.HouseMaintenanceStats, error) {
+	value, err := s.cache.GetOrLoad(cacheKey("maintenance", filter), func() (interface{}, error) {
+		houseID, start, end, err := parseFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		houses, err := s.reportRepo.GetHouses(houseID)
+		if err != nil {
+			return nil, err
+		}
+		counts, err := s.analyticsRepo.GetMaintenanceCounts(houseID, start, end)
+		if err != nil {
+			return nil, err
+		}
+
+		result := make([]models.HouseMaintenanceStats, 0, len(houses))
+		for _, house := range houses {
+			stats := models.HouseMaintenanceStats{
+				HouseID:    house.ID,
+				HouseName:  house.Name,
+				ByPriority: map[string]int{},
+			}
+			if c := counts[house.ID]; c != nil {
+				stats.Opened = c.Opened
+				stats.Completed = c.Completed
+				stats.ByPriority = c.ByPriority
+				stats.AverageResolutionHours = roundCents(c.AverageResolutionHours)
+			}
+			result = append(result, stats)
+		}
+		return result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]models.HouseMaintenanceStats), nil
+}
+
+// Summary combines all analytics for a dashboard overview.
+func (s *AnalyticsService) Summary(filter AnalyticsFilter) (*models.AnalyticsSummary, error) {
+	_, start, end, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	occupancy, err := s.Occupancy(filter)
+	if err != nil {
+		return nil, err
+	}
+	revenue, err := s.Revenue(filter)
+	if err != nil {
+		return nil, err
+	}
+	maintenance, err := s.Maintenance(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AnalyticsSummary{
+		From:        start.Format("2006-01"),
+		To:          end.AddDate(0, -1, 0).Format("2006-01"),
+		Occupancy:   occupancy,
+		Revenue:     revenue,
+		Maintenance: maintenance,
+	}, nil
+}
+
+// parseFilter reads a filter's house and month range. Malformed values are
+// reported as ErrInvalidAnalyticsFilter or ErrInvalidDateRange.
+func parseFilter(filter AnalyticsFilter) (int, time.Time, time.Time, error) {
+	houseID := 0
+	if filter.HouseID != "" {
+		parsed, err := strconv.Atoi(filter.HouseID)
+		if err != nil {
+			return 0, time.Time{}, time.Time{}, fmt.Errorf("%w: house_id must be a number", ErrInvalidAnalyticsFilter)
+		}
+		houseID = parsed
+	}
+	start, end, err := ParseMonthRange(filter.From, filter.To)
+	var parseErr *time.ParseError
+	if errors.As(err, &parseErr) {
+		return 0, time.Time{}, time.Time{}, fmt.Errorf("%w: from and to must be YYYY-MM months", ErrInvalidAnalyticsFilter)
+	}
+	return houseID, start, end, err
+}
+
+func monthRanges(start, end time.Time) []repositories.MonthRange {
+	var months []repositories.MonthRange
+	for m := start; m.Before(end); m = m.AddDate(0, 1, 0) {
+		months = append(months, repositories.MonthRange{
+			Key:   m.Format("2006-01"),
+			Start: m,
+			End:   m.AddDate(0, 1, 0),
+		})
+	}
+	return months
+}
+
+func cacheKey(metric string, filter AnalyticsFilter) string {
+	return strings.Join([]string{metric, filter.HouseID, filter.From, filter.To}, "|")
+}
+
+func ratio(numerator, denominator float64) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return roundRatio(numerator / denominator)
+}
+
+// roundRatio rounds a rate to four decimal places.
+func roundRatio(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
+
+// Forecast projects occupancy and expected rent per house for the next
+// months. Known move-outs end a tenancy; a lease expiry without a move-out
+// date is assumed to renew with the house's renewal rate over the past year.
+// Confirmed reservations are counted from their expected move-in date.
+func (s *AnalyticsService) Forecast(houseId string, months int) ([]models.HouseForecast, error) {
+	if months <= 0 || months > maxForecastMonths {
+		return nil, ErrInvalidForecastHorizon
+	}
+
+	key := strings.Join([]string{"forecast", houseId, strconv.Itoa(months)}, "|")
+	value, err := s.cache.GetOrLoad(key, func() (interface{}, error) {
+		houseID, _, _, err := parseFilter(AnalyticsFilter{HouseID: houseId})
+		if err != nil {
+			return nil, err
+		}
+		houses, err := s.reportRepo.GetHouses(houseID)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+		end := start.AddDate(0, months, 0)
+		ranges := monthRanges(start, end)
+
+		capacity, err := s.analyticsRepo.GetBedCapacity(houseID)
+		if err != nil {
+			return nil, err
+		}
+		tenancies, err := s.analyticsRepo.GetCurrentTenancies(houseID)
+		if err != nil {
+			return nil, err
+		}
+		renewals, err := s.analyticsRepo.GetRenewalRates(houseID, start.AddDate(-1, 0, 0), start)
+		if err != nil {
+			return nil, err
+		}
+		reservations, err := s.reservationRepo.GetConfirmedReservations(houseID, end)
+		if err != nil {
+			return nil, err
+		}
+
+		byHouse := make(map[int][]repositories.Tenancy)
+		for _, t := range tenancies {
+			byHouse[t.HouseID] = append(byHouse[t.HouseID], t)
+		}
+
+		result := make([]models.HouseForecast, 0, len(houses))
+		for _, house := range houses {
+			renewal := renewals[house.ID]
+			forecast := models.HouseForecast{
+				HouseID:           house.ID,
+				HouseName:         house.Name,
+				RenewalRate:       roundRatio(renewal),
+				Months:            make([]models.ForecastMonth, 0, len(ranges)),
+				UpcomingVacancies: []models.UpcomingVacancy{},
+			}
+
+			for _, t := range byHouse[house.ID] {
+				switch {
+				case t.MoveOutDate != nil && t.MoveOutDate.Before(end):
+					forecast.UpcomingVacancies = append(forecast.UpcomingVacancies, models.UpcomingVacancy{
+						RoomID: t.RoomID, RoomNumber: t.RoomNumber, TenantID: t.TenantID,
+						Date: *t.MoveOutDate, Reason: "move_out",
+					})
+				case t.MoveOutDate == nil && t.LeaseEndDate != nil &&
+					!t.LeaseEndDate.Before(start) && t.LeaseEndDate.Before(end):
+					forecast.UpcomingVacancies = append(forecast.UpcomingVacancies, models.UpcomingVacancy{
+						RoomID: t.RoomID, RoomNumber: t.RoomNumber, TenantID: t.TenantID,
+						Date: *t.LeaseEndDate, Reason: "lease_expiry",
+					})
+				}
+			}
+			sort.Slice(forecast.UpcomingVacancies, func(i, j int) bool {
+				return forecast.UpcomingVacancies[i].Date.Before(forecast.UpcomingVacancies[j].Date)
+			})
+
+			for _, m := range ranges {
+				occupied, rent := 0.0, 0.0
+				for _, t := range byHouse[house.ID] {
+					share := tenancyShare(t, renewal, start, m)
+					occupied += share
+					rent += share * t.PricePerMonth
+				}
+				for _, res := range reservations[house.ID] {
+					share := monthFraction(res.ExpectedMoveIn, res.ExpectedMoveOut, m)
+					occupied += share
+					rent += share * res.MonthlyRate
+				}
+
+				forecast.Months = append(forecast.Months, models.ForecastMonth{
+					Month:            m.Key,
+					Capacity:         capacity[house.ID],
+					ExpectedOccupied: roundCents(occupied),
+					OccupancyRate:    ratio(occupied, float64(capacity[house.ID])),
+					ExpectedRent:     roundCents(rent),
+				})
+			}
+			result = append(result, forecast)
+		}
+		return result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]models.HouseForecast), nil
+}
+
+// tenancyShare is the expected fraction of the month a current tenant
+// occupies their bed. Tenants already past their lease end without a
+// move-out date are treated as staying on.
+func tenancyShare(t repositories.Tenancy, renewalRate float64, forecastStart time.Time, m repositories.MonthRange) float64 {
+	if t.MoveOutDate != nil || t.LeaseEndDate == nil || t.LeaseEndDate.Before(forecastStart) {
+		return monthFraction(m.Start, t.MoveOutDate, m)
+	}
+	certain := monthFraction(m.Start, t.LeaseEndDate, m)
+	return certain + (1-certain)*renewalRate
+}
+
+// monthFraction returns the share of the month covered by [from, to), with
+// a nil end meaning the stay is open-ended.
+func monthFraction(from time.Time, to *time.Time, m repositories.MonthRange) float64 {
+	start, end := m.Start, m.End
+	if from.After(start) {
+		start = from
+	}
+	if to != nil && to.Before(end) {
+		end = *to
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start).Hours() / m.End.Sub(m.Start).Hours()
+}
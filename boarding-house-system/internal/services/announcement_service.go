@@ -0,0 +1,270 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/events"
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+)
+
+var (
+	ErrInvalidAnnouncement    = errors.New("invalid announcement")
+	ErrAnnouncementNotAllowed = errors.New("announcement board belongs to another house")
+)
+
+const maxAnnouncementTitle = 100
+
+// AnnouncementService broadcasts announcements to audiences. Scheduled
+// announcements are published by Run; publishing fixes the recipients and
+// each of them is sent an in-app notification.
+type AnnouncementService struct {
+	announcementRepo *repositories.AnnouncementRepository
+	notificationRepo *repositories.NotificationRepository
+}
+
+func NewAnnouncementService(announcementRepo *repositories.AnnouncementRepository,
+	notificationRepo *repositories.NotificationRepository) *AnnouncementService {
+	return &AnnouncementService{announcementRepo: announcementRepo, notificationRepo: notificationRepo}
+}
+
+// Subscribe notifies the recipients of published announcements.
+func (s *AnnouncementService) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.TypeAnnouncementPublished, "announcement_notifications", s.notifyRecipients)
+}
+
+// CreateAnnouncement saves an announcement and publishes it right away
+// unless it is scheduled for later.
+func (s *AnnouncementService) CreateAnnouncement(announcement *models.Announcement, createdBy int) (*models.Announcement, error) {
+	now := time.Now()
+	if announcement.PublishAt.IsZero() {
+		announcement.PublishAt = now
+	}
+	if err := validateAnnouncement(announcement); err != nil {
+		return nil, err
+	}
+	announcement.CreatedBy = &createdBy
+	if err := s.announcementRepo.CreateAnnouncement(announcement); err != nil {
+		return nil, err
+	}
+	return s.publishIfDue(announcement.ID, announcement.PublishAt, now)
+}
+
+func (s *AnnouncementService) GetAnnouncements() ([]models.Announcement, error) {
+	return s.announcementRepo.GetAnnouncements()
+}
+
+func (s *AnnouncementService) GetAnnouncement(id string) (*models.Announcement, error) {
+	announcementId, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.announcementRepo.GetAnnouncement(announcementId)
+}
+
+// UpdateAnnouncement changes an announcement. Once published its audience
+// and schedule are fixed, so only the text, pin and expiry change.
+func (s *AnnouncementService) UpdateAnnouncement(id string, input *models.Announcement) (*models.Announcement, error) {
+	announcementId, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+	announcement, err := s.announcementRepo.GetAnnouncement(announcementId)
+	if err != nil {
+		return nil, err
+	}
+
+	announcement.Title = input.Title
+	announcement.Body = input.Body
+	announcement.IsPinned = input.IsPinned
+	announcement.ExpiresAt = input.ExpiresAt
+	if announcement.PublishedAt == nil {
+		announcement.Audience = input.Audience
+		announcement.HouseID = input.HouseID
+		announcement.RoomIDs = input.RoomIDs
+		if !input.PublishAt.IsZero() {
+			announcement.PublishAt = input.PublishAt
+		}
+	}
+	if err := validateAnnouncement(announcement); err != nil {
+		return nil, err
+	}
+	if err := s.announcementRepo.UpdateAnnouncement(announcement); err != nil {
+		return nil, err
+	}
+	if announcement.PublishedAt != nil {
+		return s.announcementRepo.GetAnnouncement(announcementId)
+	}
+	return s.publishIfDue(announcementId, announcement.PublishAt, time.Now())
+}
+
+func (s *AnnouncementService) DeleteAnnouncement(id string) error {
+	announcementId, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	return s.announcementRepo.DeleteAnnouncement(announcementId)
+}
+
+// GetUserAnnouncements returns the current announcements a user received.
+func (s *AnnouncementService) GetUserAnnouncements(userID int) ([]models.Announcement, error) {
+	return s.announcementRepo.GetUserAnnouncements(userID, time.Now())
+}
+
+// GetBoard returns a house's pinned announcements. Tenants may only read
+// the board of the house they live in, and only see the room announcements
+// for their own room.
+func (s *AnnouncementService) GetBoard(houseId string, userID int, role string) ([]models.Announcement, error) {
+	houseID, err := strconv.Atoi(houseId)
+	if err != nil {
+		return nil, err
+	}
+	tenantID := 0
+	if !isStaffRole(role) {
+		ok, err := s.announcementRepo.IsTenantOfHouse(userID, houseID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrAnnouncementNotAllowed
+		}
+		tenantID = userID
+	}
+	return s.announcementRepo.GetBoard(houseID, tenantID, time.Now())
+}
+
+func (s *AnnouncementService) MarkRead(id string, userID int) error {
+	announcementId, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	return s.announcementRepo.MarkRead(announcementId, userID)
+}
+
+// GetReceipts returns who an announcement was sent to and who read it.
+func (s *AnnouncementService) GetReceipts(id string) ([]models.AnnouncementReceipt, error) {
+	announcementId, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.announcementRepo.GetAnnouncement(announcementId); err != nil {
+		return nil, err
+	}
+	return s.announcementRepo.GetReceipts(announcementId)
+}
+
+// Run publishes scheduled announcements every interval until the process
+// exits.
+func (s *AnnouncementService) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := s.PublishDue(time.Now()); err != nil {
+			log.Printf("Announcement publishing failed: %v", err)
+		}
+	}
+}
+
+// PublishDue publishes every announcement scheduled at or before asOf and
+// returns how many were published.
+func (s *AnnouncementService) PublishDue(asOf time.Time) (int, error) {
+	ids, err := s.announcementRepo.GetDueAnnouncements(asOf)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, id := range ids {
+		ok, err := s.announcementRepo.Publish(id, asOf)
+		if err != nil {
+			log.Printf("Failed to publish announcement %d: %v", id, err)
+			continue
+		}
+		if ok {
+			published++
+		}
+	}
+	return published, nil
+}
+
+func (s *AnnouncementService) publishIfDue(id int, publishAt, now time.Time) (*models.Announcement, error) {
+	if !publishAt.After(now) {
+		if _, err := s.announcementRepo.Publish(id, now); err != nil {
+			return nil, err
+		}
+	}
+	return s.announcementRepo.GetAnnouncement(id)
+}
+
+// notifyRecipients sends each recipient who has not been notified yet an
+// in-app notification, so that a retried event does not notify anyone
+// twice.
+func (s *AnnouncementService) notifyRecipients(envelope events.Envelope) error {
+	var event events.AnnouncementPublished
+	if err := envelope.Decode(&event); err != nil {
+		return err
+	}
+	announcement, err := s.announcementRepo.GetAnnouncement(event.AnnouncementID)
+	if err != nil {
+		return err
+	}
+	userIDs, err := s.announcementRepo.GetUnnotifiedRecipients(announcement.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		notification := &models.Notification{
+			UserID:   userID,
+			Title:    announcement.Title,
+			Message:  announcement.Body,
+			Link:     fmt.Sprintf("/announcements/%d", announcement.ID),
+			Category: CategoryAnnouncements,
+		}
+		if err := s.notificationRepo.CreateNotification(notification); err != nil {
+			return err
+		}
+		if err := s.announcementRepo.SetNotification(announcement.ID, userID, notification.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateAnnouncement(announcement *models.Announcement) error {
+	announcement.Title = strings.TrimSpace(announcement.Title)
+	if announcement.Title == "" || len(announcement.Title) > maxAnnouncementTitle {
+		return fmt.Errorf("%w: title is required and at most %d characters", ErrInvalidAnnouncement, maxAnnouncementTitle)
+	}
+	if strings.TrimSpace(announcement.Body) == "" {
+		return fmt.Errorf("%w: body is required", ErrInvalidAnnouncement)
+	}
+
+	switch announcement.Audience {
+	case "house":
+		if announcement.HouseID == nil {
+			return fmt.Errorf("%w: house_id is required for a house announcement", ErrInvalidAnnouncement)
+		}
+		announcement.RoomIDs = nil
+	case "rooms":
+		if len(announcement.RoomIDs) == 0 {
+			return fmt.Errorf("%w: room_ids are required for a rooms announcement", ErrInvalidAnnouncement)
+		}
+	case "staff", "everyone":
+		announcement.HouseID = nil
+		announcement.RoomIDs = nil
+	default:
+		return fmt.Errorf("%w: audience must be house, rooms, staff or everyone", ErrInvalidAnnouncement)
+	}
+
+	if announcement.ExpiresAt != nil && !announcement.ExpiresAt.After(announcement.PublishAt) {
+		return fmt.Errorf("%w: expires_at must be after publish_at", ErrInvalidAnnouncement)
+	}
+	return nil
+}
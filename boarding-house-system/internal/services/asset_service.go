@@ -0,0 +1,225 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+)
+
+var ErrInvalidAsset = errors.New("asset requires a room, name, valid category and condition, and non-negative cost and lifespan")
+
+// An asset becomes a replacement candidate once it has needed this many
+// repairs, or its repairs have cost this share of its purchase price.
+const (
+	replacementRepairCount = 3
+	replacementCostRatio   = 0.5
+)
+
+var assetCategories = map[string]bool{
+	"bed": true, "mattress": true, "aircon": true, "key": true,
+	"furniture": true, "appliance": true, "other": true,
+}
+
+var assetConditions = []string{"new", "good", "fair", "poor", "broken"}
+
+type AssetService struct {
+	assetRepo *repositories.AssetRepository
+}
+
+func NewAssetService(assetRepo *repositories.AssetRepository) *AssetService {
+	return &AssetService{assetRepo: assetRepo}
+}
+
+func (s *AssetService) CreateAsset(asset *models.Asset) error {
+	if err := validateAsset(asset); err != nil {
+		return err
+	}
+	if err := s.assetRepo.CreateAsset(asset); err != nil {
+		return err
+	}
+	created, err := s.assetRepo.GetAsset(asset.ID)
+	if err != nil {
+		return err
+	}
+	*asset = *created
+	return nil
+}
+
+func (s *AssetService) GetAsset(id string) (*models.Asset, error) {
+	assetID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.assetRepo.GetAsset(assetID)
+}
+
+func (s *AssetService) GetAssetsByRoom(roomId string, includeDisposed bool) ([]models.Asset, error) {
+	roomID, err := strconv.Atoi(roomId)
+	if err != nil {
+		return nil, err
+	}
+	return s.assetRepo.GetAssetsByRoom(roomID, includeDisposed)
+}
+
+func (s *AssetService) UpdateAsset(id string, asset *models.Asset) error {
+	existing, err := s.GetAsset(id)
+	if err != nil {
+		return err
+	}
+	if err := validateAsset(asset); err != nil {
+		return err
+	}
+	if err := s.assetRepo.UpdateAsset(existing.ID, asset); err != nil {
+		return err
+	}
+	updated, err := s.assetRepo.GetAsset(existing.ID)
+	if err != nil {
+		return err
+	}
+	*asset = *updated
+	return nil
+}
+
+func (s *AssetService) DisposeAsset(id string) error {
+	asset, err := s.GetAsset(id)
+	if err != nil {
+		return err
+	}
+	return s.assetRepo.DisposeAsset(asset.ID)
+}
+
+// RepairHistory returns an asset with every maintenance request raised
+// against it.
+func (s *AssetService) RepairHistory(id string) (*models.AssetRepairHistory, error) {
+	asset, err := s.GetAsset(id)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := s.assetRepo.GetRepairs(asset.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &models.AssetRepairHistory{Asset: *asset, Requests: requests}
+	for _, request := range requests {
+		if request.Status == "cancelled" {
+			continue
+		}
+		history.RepairCount++
+		if request.Cost != nil {
+			history.RepairCost += *request.Cost
+		}
+	}
+	history.RepairCost = roundCents(history.RepairCost)
+	return history, nil
+}
+
+// Report summarises a house's active assets by condition, with their repair
+// totals and the assets that are due for replacement.
+func (s *AssetService) Report(houseId string) (*models.AssetReport, error) {
+	houseID, err := strconv.Atoi(houseId)
+	if err != nil {
+		return nil, err
+	}
+	summaries, err := s.assetRepo.GetRepairSummaries(houseID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.AssetReport{
+		HouseID:     houseID,
+		AssetCount:  len(summaries),
+		ByCondition: make(map[string]int, len(assetConditions)),
+		Assets:      summaries,
+		Candidates:  []models.ReplacementCandidate{},
+	}
+	for _, condition := range assetConditions {
+		report.ByCondition[condition] = 0
+	}
+
+	now := time.Now()
+	for i := range summaries {
+		summary := &summaries[i]
+		summary.RepairCost = roundCents(summary.RepairCost)
+		report.ByCondition[summary.Asset.Condition]++
+		report.RepairCost += summary.RepairCost
+		if summary.Asset.PurchaseCost != nil {
+			report.TotalValue += *summary.Asset.PurchaseCost
+		}
+
+		if candidate, ok := replacementCandidate(*summary, now); ok {
+			report.Candidates = append(report.Candidates, candidate)
+		}
+	}
+	report.RepairCost = roundCents(report.RepairCost)
+	report.TotalValue = roundCents(report.TotalValue)
+	return report, nil
+}
+
+// replacementCandidate checks an asset against the replacement rules: poor
+// or broken condition, reaching its expected life, frequent repairs, or
+// repairs costing a large share of its price.
+func replacementCandidate(summary models.AssetRepairSummary, now time.Time) (models.ReplacementCandidate, bool) {
+	candidate := models.ReplacementCandidate{AssetRepairSummary: summary}
+	asset := summary.Asset
+
+	if asset.PurchaseDate != nil {
+		candidate.AgeMonths = monthsBetween(*asset.PurchaseDate, now)
+	}
+	if asset.Condition == "poor" || asset.Condition == "broken" {
+		candidate.Reasons = append(candidate.Reasons, "condition is "+asset.Condition)
+	}
+	if asset.ExpectedLifeMonths != nil && asset.PurchaseDate != nil && candidate.AgeMonths >= *asset.ExpectedLifeMonths {
+		candidate.Reasons = append(candidate.Reasons,
+			fmt.Sprintf("reached expected life of %d months", *asset.ExpectedLifeMonths))
+	}
+	if summary.RepairCount >= replacementRepairCount {
+		candidate.Reasons = append(candidate.Reasons, fmt.Sprintf("repaired %d times", summary.RepairCount))
+	}
+	if asset.PurchaseCost != nil && *asset.PurchaseCost > 0 && summary.RepairCost >= *asset.PurchaseCost*replacementCostRatio {
+		candidate.Reasons = append(candidate.Reasons,
+			fmt.Sprintf("repairs cost %s of %s purchase price", formatAmount(summary.RepairCost), formatAmount(*asset.PurchaseCost)))
+	}
+	return candidate, len(candidate.Reasons) > 0
+}
+
+func monthsBetween(from, to time.Time) int {
+	months := (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+	if to.Day() < from.Day() {
+		months--
+	}
+	if months < 0 {
+		return 0
+	}
+	return months
+}
+
+func validateAsset(asset *models.Asset) error {
+	if asset.RoomID == 0 || strings.TrimSpace(asset.Name) == "" || !assetCategories[asset.Category] {
+		return ErrInvalidAsset
+	}
+	if asset.Condition == "" {
+		asset.Condition = "good"
+	}
+	valid := false
+	for _, condition := range assetConditions {
+		if asset.Condition == condition {
+			valid = true
+		}
+	}
+	if !valid {
+		return ErrInvalidAsset
+	}
+	if asset.PurchaseCost != nil && *asset.PurchaseCost < 0 {
+		return ErrInvalidAsset
+	}
+	if asset.ExpectedLifeMonths != nil && *asset.ExpectedLifeMonths <= 0 {
+		return ErrInvalidAsset
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+)
+
+var (
+	ErrInvalidAssignmentRule = errors.New("assignment rule requires a name")
+	ErrInvalidStaffProfile   = errors.New("maximum open requests must not be negative")
+	ErrNotStaff              = errors.New("only staff members have a work queue")
+	ErrNoEligibleStaff       = errors.New("no matching rule or eligible staff member for this request")
+)
+
+// priorityRank orders the work queue, most urgent first.
+var priorityRank = map[string]int{"emergency": 0, "high": 1, "medium": 2, "low": 3}
+
+type AssignmentService struct {
+	assignmentRepo *repositories.AssignmentRepository
+	templates      *NotificationTemplateService
+	slaService     *SLAService
+}
+
+func NewAssignmentService(assignmentRepo *repositories.AssignmentRepository,
+	templates *NotificationTemplateService, slaService *SLAService) *AssignmentService {
+	return &AssignmentService{
+		assignmentRepo: assignmentRepo,
+		templates:      templates,
+		slaService:     slaService,
+	}
+}
+
+func (s *AssignmentService) GetRules() ([]models.AssignmentRule, error) {
+	return s.assignmentRepo.GetRules(false)
+}
+
+func (s *AssignmentService) CreateRule(rule *models.AssignmentRule) error {
+	if err := normalizeRule(rule); err != nil {
+		return err
+	}
+	return s.assignmentRepo.CreateRule(rule)
+}
+
+func (s *AssignmentService) UpdateRule(id string, rule *models.AssignmentRule) error {
+	ruleID, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	if err := normalizeRule(rule); err != nil {
+		return err
+	}
+	existing, err := s.assignmentRepo.GetRule(ruleID)
+	if err != nil {
+		return err
+	}
+	rule.ID = ruleID
+	rule.CreatedAt = existing.CreatedAt
+	return s.assignmentRepo.UpdateRule(ruleID, rule)
+}
+
+func (s *AssignmentService) DeleteRule(id string) error {
+	ruleID, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	return s.assignmentRepo.DeleteRule(ruleID)
+}
+
+func (s *AssignmentService) GetStaffProfiles() ([]models.StaffProfile, error) {
+	return s.assignmentRepo.GetStaffProfiles()
+}
+
+// UpdateStaffProfile sets a staff member's house, skill tags, shift status
+// and workload cap. Skills are stored lower case.
+func (s *AssignmentService) UpdateStaffProfile(userId string, profile *models.StaffProfile) (*models.StaffProfile, error) {
+	userID, err := strconv.Atoi(userId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.assignmentRepo.GetStaffProfile(userID); err != nil {
+		return nil, err
+	}
+	if profile.MaxOpenRequests < 0 {
+		return nil, ErrInvalidStaffProfile
+	}
+
+	skills := make([]string, 0, len(profile.Skills))
+	for _, skill := range profile.Skills {
+		if skill = normalizeSkill(skill); skill != "" {
+			skills = append(skills, skill)
+		}
+	}
+	profile.UserID = userID
+	profile.Skills = skills
+	if err := s.assignmentRepo.UpsertStaffProfile(profile); err != nil {
+		return nil, err
+	}
+	return s.assignmentRepo.GetStaffProfile(userID)
+}
+
+// SetOnShift lets a staff member start or end their shift.
+func (s *AssignmentService) SetOnShift(userID int, role string, onShift bool) error {
+	if role != "staff" {
+		return ErrNotStaff
+	}
+	return s.assignmentRepo.SetOnShift(userID, onShift)
+}
+
+// AutoAssign gives an unassigned request to the least-loaded staff member
+// eligible under the first matching rule. Rules are tried in order; a rule
+// matches when its house and issue type are empty or equal to the
+// request's, and a staff member is eligible when they cover the house, have
+// the rule's skill, are on shift if the rule requires it and are below their
+// workload cap. It returns the assigned staff member.
+func (s *AssignmentService) AutoAssign(request *models.MaintenanceRequest) (int, error) {
+	houseID, err := s.assignmentRepo.GetRequestHouse(request.ID)
+	if err != nil {
+		return 0, err
+	}
+	rules, err := s.assignmentRepo.GetRules(true)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, rule := range rules {
+		if rule.HouseID != nil && *rule.HouseID != houseID {
+			continue
+		}
+		if rule.IssueType != "" && !strings.EqualFold(rule.IssueType, request.IssueType) {
+			continue
+		}
+
+		staff, err := s.assignmentRepo.GetEligibleStaff(houseID, rule.RequiredSkill, rule.OnShiftOnly)
+		if err != nil {
+			return 0, err
+		}
+		for _, candidate := range staff {
+			if candidate.MaxOpenRequests > 0 && candidate.OpenRequests >= candidate.MaxOpenRequests {
+				continue
+			}
+			assigned, err := s.assignmentRepo.AutoAssign(request.ID, candidate.UserID, rule.Name)
+			if err != nil {
+				return 0, err
+			}
+			if !assigned {
+				return 0, nil
+			}
+			request.AssignedTo = &candidate.UserID
+			s.notifyAssignee(candidate.UserID, request)
+			return candidate.UserID, nil
+		}
+	}
+	return 0, ErrNoEligibleStaff
+}
+
+// AssignNew auto-assigns a newly created request that has no assignee.
+// Failures are logged so that they never block creating the request.
+func (s *AssignmentService) AssignNew(request *models.MaintenanceRequest) {
+	if request.AssignedTo != nil {
+		return
+	}
+	if _, err := s.AutoAssign(request); err != nil && !errors.Is(err, ErrNoEligibleStaff) {
+		log.Printf("maintenance request %d: auto-assignment failed: %v", request.ID, err)
+	}
+}
+
+// Queue returns a staff member's open requests, most urgent priority first
+// and then by the next SLA deadline: response until work starts, resolution
+// after that.
+func (s *AssignmentService) Queue(staffId string) ([]models.QueueItem, error) {
+	staffID, err := strconv.Atoi(staffId)
+	if err != nil {
+		return nil, err
+	}
+	return s.queue(staffID)
+}
+
+func (s *AssignmentService) MyQueue(userID int, role string) ([]models.QueueItem, error) {
+	if role != "staff" {
+		return nil, ErrNotStaff
+	}
+	return s.queue(userID)
+}
+
+func (s *AssignmentService) queue(staffID int) ([]models.QueueItem, error) {
+	queue, err := s.assignmentRepo.GetQueue(staffID)
+	if err != nil {
+		return nil, err
+	}
+	lookup, err := s.slaService.policyLookup(0)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range queue {
+		item := &queue[i]
+		policy := lookup(item.HouseID, item.Request.Priority)
+		reported := item.Request.ReportedDate
+		item.ResponseDue = reported.Add(time.Duration(policy.ResponseMinutes) * time.Minute)
+		item.ResolutionDue = reported.Add(time.Duration(policy.ResolutionMinutes) * time.Minute)
+		item.NextDeadline = item.ResolutionDue
+		if item.Request.StartedDate == nil {
+			item.NextDeadline = item.ResponseDue
+		}
+		item.Overdue = now.After(item.NextDeadline)
+	}
+
+	sort.SliceStable(queue, func(i, j int) bool {
+		pi, pj := priorityRank[queue[i].Request.Priority], priorityRank[queue[j].Request.Priority]
+		if pi != pj {
+			return pi < pj
+		}
+		return queue[i].NextDeadline.Before(queue[j].NextDeadline)
+	})
+	return queue, nil
+}
+
+func (s *AssignmentService) notifyAssignee(userID int, request *models.MaintenanceRequest) {
+	err := s.templates.Send(userID, TemplateMaintenanceAssigned, map[string]interface{}{
+		"request_id": request.ID,
+		"priority":   request.Priority,
+		"issue_type": request.IssueType,
+	})
+	if err != nil {
+		log.Printf("maintenance request %d: failed to notify user %d: %v", request.ID, userID, err)
+	}
+}
+
+func normalizeRule(rule *models.AssignmentRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return ErrInvalidAssignmentRule
+	}
+	rule.IssueType = strings.TrimSpace(rule.IssueType)
+	rule.RequiredSkill = normalizeSkill(rule.RequiredSkill)
+	return nil
+}
+
+// normalizeSkill lower-cases a skill tag; commas would break the stored list.
+func normalizeSkill(skill string) string {
+	return strings.ToLower(strings.TrimSpace(strings.ReplaceAll(skill, ",", " ")))
+}
@@ -1,12 +1,15 @@
 package services
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/Kimox23/boarding-house-app/internal/models"
 	"github.com/Kimox23/boarding-house-app/internal/repositories"
 )
 
+var ErrDocumentNotAllowed = errors.New("documents belong to another tenant")
+
 type DocumentService struct {
 	documentRepo *repositories.DocumentRepository
 }
@@ -15,15 +18,25 @@ func NewDocumentService(documentRepo *repositories.DocumentRepository) *Document
 	return &DocumentService{documentRepo: documentRepo}
 }
 
-func (s *DocumentService) UploadDocument(document *models.Document) error {
+// UploadDocument stores a document for a tenant. Tenants upload their own;
+// staff may upload for anyone.
+func (s *DocumentService) UploadDocument(document *models.Document, userID int, role string) error {
+	if err := s.checkAccess(document.TenantID, userID, role); err != nil {
+		return err
+	}
 	return s.documentRepo.UploadDocument(document)
 }
 
-func (s *DocumentService) GetTenantDocuments(tenantId string) ([]models.Document, error) {
+// GetTenantDocuments lists a tenant's documents to the tenant themselves
+// or to staff.
+func (s *DocumentService) GetTenantDocuments(tenantId string, userID int, role string) ([]models.Document, error) {
 	tenantID, err := strconv.Atoi(tenantId)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkAccess(tenantID, userID, role); err != nil {
+		return nil, err
+	}
 	return s.documentRepo.GetTenantDocuments(tenantID)
 }
 
@@ -42,3 +55,19 @@ func (s *DocumentService) DeleteDocument(id string) error {
 	}
 	return s.documentRepo.DeleteDocument(documentID)
 }
+
+// checkAccess lets staff reach any tenant's documents and tenants only
+// their own.
+func (s *DocumentService) checkAccess(tenantID, userID int, role string) error {
+	if isStaffRole(role) {
+		return nil
+	}
+	tenantUserID, err := s.documentRepo.GetTenantUserID(tenantID)
+	if err != nil {
+		return err
+	}
+	if tenantUserID != userID {
+		return ErrDocumentNotAllowed
+	}
+	return nil
+}
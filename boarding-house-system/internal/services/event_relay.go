@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/events"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+)
+
+const (
+	maxEventAttempts = 8
+	eventBackoff     = 30 * time.Second
+	eventLease       = 5 * time.Minute
+	eventBatchSize   = 100
+)
+
+// EventRelay delivers events from the outbox to the bus subscribers. Each
+// subscriber is recorded once it has handled an event, so a failing
+// subscriber is retried with exponential backoff without replaying the
+// event to the others.
+type EventRelay struct {
+	outboxRepo *repositories.OutboxRepository
+	bus        *events.Bus
+}
+
+func NewEventRelay(outboxRepo *repositories.OutboxRepository, bus *events.Bus) *EventRelay {
+	return &EventRelay{outboxRepo: outboxRepo, bus: bus}
+}
+
+// Run relays due events every interval until the process exits.
+func (r *EventRelay) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := r.RelayDue(time.Now()); err != nil {
+			log.Printf("Event relay failed: %v", err)
+		}
+	}
+}
+
+// RelayDue delivers every event due at asOf and returns how many were fully
+// processed.
+func (r *EventRelay) RelayDue(asOf time.Time) (int, error) {
+	pending, err := r.outboxRepo.GetDueEvents(asOf, eventBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, event := range pending {
+		claimed, err := r.outboxRepo.Claim(event.ID, asOf, eventLease)
+		if err != nil {
+			log.Printf("Failed to claim event %d: %v", event.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if r.relay(event, asOf) {
+			processed++
+		}
+	}
+	return processed, nil
+}
+
+func (r *EventRelay) relay(event repositories.OutboxEvent, asOf time.Time) bool {
+	handled, err := r.outboxRepo.GetHandled(event.ID)
+	if err != nil {
+		log.Printf("Failed to load handlers of event %d: %v", event.ID, err)
+		return false
+	}
+
+	var failures []error
+	for _, sub := range r.bus.Subscribers(event.Type) {
+		if handled[sub.Name] {
+			continue
+		}
+		if err := sub.Handler(event.Envelope); err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", sub.Name, err))
+			continue
+		}
+		if err := r.outboxRepo.MarkHandled(event.ID, sub.Name); err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", sub.Name, err))
+		}
+	}
+
+	if len(failures) == 0 {
+		if err := r.outboxRepo.MarkProcessed(event.ID); err != nil {
+			log.Printf("Failed to mark event %d processed: %v", event.ID, err)
+			return false
+		}
+		return true
+	}
+
+	reason := errors.Join(failures...)
+	var retryAt *time.Time
+	if attempt := event.Attempts + 1; attempt < maxEventAttempts {
+		next := asOf.Add(eventBackoff << (attempt - 1))
+		retryAt = &next
+	}
+	log.Printf("Event %d (%s) failed: %v", event.ID, event.Type, reason)
+	if err := r.outboxRepo.MarkAttemptFailed(event.ID, reason.Error(), retryAt); err != nil {
+		log.Printf("Failed to record failure of event %d: %v", event.ID, err)
+	}
+	return false
+}
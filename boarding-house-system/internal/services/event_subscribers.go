@@ -0,0 +1,109 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Kimox23/boarding-house-app/internal/events"
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+)
+
+const maxAuditEntries = 500
+
+// Subscribe sends the notifications that domain events call for.
+func (s *NotificationTemplateService) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.TypePaymentRecorded, "notifications", s.paymentRecorded)
+	bus.Subscribe(events.TypeDocumentVerified, "notifications", s.documentVerified)
+	bus.Subscribe(events.TypeMaintenanceStatusChanged, "notifications", s.maintenanceStatusChanged)
+}
+
+func (s *NotificationTemplateService) paymentRecorded(envelope events.Envelope) error {
+	var event events.PaymentRecorded
+	if err := envelope.Decode(&event); err != nil {
+		return err
+	}
+	return s.SendToTenant(event.TenantID, TemplatePaymentReceived, map[string]interface{}{
+		"amount":         formatAmount(event.Amount),
+		"month":          event.PaymentForMonth.Format("January 2006"),
+		"receipt_number": event.ReceiptNumber,
+		"payment_id":     event.PaymentID,
+	})
+}
+
+func (s *NotificationTemplateService) documentVerified(envelope events.Envelope) error {
+	var event events.DocumentVerified
+	if err := envelope.Decode(&event); err != nil {
+		return err
+	}
+	status := "verified"
+	if !event.Verified {
+		status = "rejected"
+	}
+	return s.SendToTenant(event.TenantID, TemplateDocumentVerified, map[string]interface{}{
+		"document_type": event.DocumentType,
+		"status":        status,
+		"notes":         event.Notes,
+	})
+}
+
+// maintenanceStatusChanged tells the tenant who reported a request about
+// its new status, unless they changed it themselves. Completion asks them
+// to rate the fix.
+func (s *NotificationTemplateService) maintenanceStatusChanged(envelope events.Envelope) error {
+	var event events.MaintenanceStatusChanged
+	if err := envelope.Decode(&event); err != nil {
+		return err
+	}
+	if event.ReportedBy == event.ActorID {
+		return nil
+	}
+	if event.ToStatus == "completed" {
+		return s.Send(event.ReportedBy, TemplateMaintenanceCompleted, map[string]interface{}{
+			"request_id": event.RequestID,
+		})
+	}
+	return s.Send(event.ReportedBy, TemplateMaintenanceUpdated, map[string]interface{}{
+		"request_id": event.RequestID,
+		"issue_type": event.IssueType,
+		"status":     strings.ReplaceAll(event.ToStatus, "_", " "),
+	})
+}
+
+// AuditService records every domain event in the audit log.
+type AuditService struct {
+	outboxRepo *repositories.OutboxRepository
+}
+
+func NewAuditService(outboxRepo *repositories.OutboxRepository) *AuditService {
+	return &AuditService{outboxRepo: outboxRepo}
+}
+
+func (s *AuditService) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.All, "audit_log", s.outboxRepo.RecordAudit)
+}
+
+// GetAuditLog returns the newest entries, optionally for one subject type
+// (payment, document, maintenance_request, tenant) or one subject.
+func (s *AuditService) GetAuditLog(subjectType, subjectId, limit string) ([]models.AuditEntry, error) {
+	subjectID := 0
+	if subjectId != "" {
+		parsed, err := strconv.Atoi(subjectId)
+		if err != nil {
+			return nil, err
+		}
+		subjectID = parsed
+	}
+	n := 100
+	if limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, err
+		}
+		n = parsed
+	}
+	if n < 1 || n > maxAuditEntries {
+		n = maxAuditEntries
+	}
+	return s.outboxRepo.GetAuditLog(subjectType, subjectID, n)
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+)
+
+var (
+	ErrInvalidExpenseAmount = errors.New("expense amount must be greater than zero")
+	ErrInvalidDayOfMonth    = errors.New("day of month must be between 1 and 28")
+)
+
+type ExpenseService struct {
+	expenseRepo *repositories.ExpenseRepository
+}
+
+func NewExpenseService(expenseRepo *repositories.ExpenseRepository) *ExpenseService {
+	return &ExpenseService{expenseRepo: expenseRepo}
+}
+
+func (s *ExpenseService) CreateExpense(expense *models.Expense) error {
+	if expense.Amount <= 0 {
+		return ErrInvalidExpenseAmount
+	}
+	if expense.ExpenseDate.IsZero() {
+		expense.ExpenseDate = time.Now()
+	}
+	return s.expenseRepo.CreateExpense(expense)
+}
+
+func (s *ExpenseService) GetExpense(id string) (*models.Expense, error) {
+	expenseID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.expenseRepo.GetExpense(expenseID)
+}
+
+// GetExpensesByHouse lists expenses between two YYYY-MM-DD dates. Missing
+// bounds default to the start of the current year and today.
+func (s *ExpenseService) GetExpensesByHouse(houseId, from, to, category string) ([]models.Expense, error) {
+	houseID, err := strconv.Atoi(houseId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	fromDate := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.Local)
+	toDate := now
+	if from != "" {
+		if fromDate, err = time.Parse("2006-01-02", from); err != nil {
+			return nil, err
+		}
+	}
+	if to != "" {
+		if toDate, err = time.Parse("2006-01-02", to); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.expenseRepo.GetExpensesByHouse(houseID, fromDate, toDate, category)
+}
+
+func (s *ExpenseService) UpdateExpense(id string, expense *models.Expense) error {
+	expenseID, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	if expense.Amount <= 0 {
+		return ErrInvalidExpenseAmount
+	}
+	return s.expenseRepo.UpdateExpense(expenseID, expense)
+}
+
+// SetReceipt replaces an expense's receipt and returns the previous one.
+func (s *ExpenseService) SetReceipt(id string, receipt string) (string, error) {
+	expenseID, err := strconv.Atoi(id)
+	if err != nil {
+		return "", err
+	}
+	return s.expenseRepo.SetReceipt(expenseID, receipt)
+}
+
+func (s *ExpenseService) DeleteExpense(id string) error {
+	expenseID, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	return s.expenseRepo.DeleteExpense(expenseID)
+}
+
+func (s *ExpenseService) CreateRecurringExpense(recurring *models.RecurringExpense) error {
+	if recurring.Amount <= 0 {
+		return ErrInvalidExpenseAmount
+	}
+	if recurring.DayOfMonth == 0 {
+		recurring.DayOfMonth = 1
+	}
+	if recurring.DayOfMonth < 1 || recurring.DayOfMonth > 28 {
+		return ErrInvalidDayOfMonth
+	}
+	if recurring.StartDate.IsZero() {
+		recurring.StartDate = time.Now()
+	}
+	return s.expenseRepo.CreateRecurringExpense(recurring)
+}
+
+func (s *ExpenseService) GetRecurringExpensesByHouse(houseId string) ([]models.RecurringExpense, error) {
+	houseID, err := strconv.Atoi(houseId)
+	if err != nil {
+		return nil, err
+	}
+	return s.expenseRepo.GetRecurringExpensesByHouse(houseID)
+}
+
+func (s *ExpenseService) DeactivateRecurringExpense(id string) error {
+	recurringID, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	return s.expenseRepo.DeactivateRecurringExpense(recurringID)
+}
+
+// Run posts recurring expenses that have fallen due every interval until the
+// process exits.
+func (s *ExpenseService) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := s.PostRecurringExpenses(time.Now()); err != nil {
+			log.Printf("Recurring expense posting failed: %v", err)
+		}
+	}
+}
+
+// PostRecurringExpenses records every occurrence of active recurring
+// expenses that has fallen due up to asOf and returns how many were posted.
+func (s *ExpenseService) PostRecurringExpenses(asOf time.Time) (int, error) {
+	schedules, err := s.expenseRepo.GetActiveRecurringExpenses()
+	if err != nil {
+		return 0, err
+	}
+
+	posted := 0
+	for i := range schedules {
+		schedule := &schedules[i]
+		for _, date := range dueOccurrences(schedule, asOf) {
+			ok, err := s.expenseRepo.PostRecurringExpense(schedule, date)
+			if err != nil {
+				return posted, err
+			}
+			if ok {
+				posted++
+			}
+		}
+	}
+	return posted, nil
+}
+
+// dueOccurrences lists the monthly posting dates after the last posting (or
+// from the start date) up to and including asOf.
+func dueOccurrences(schedule *models.RecurringExpense, asOf time.Time) []time.Time {
+	start := schedule.StartDate
+	month := time.Date(start.Year(), start.Month(), schedule.DayOfMonth, 0, 0, 0, 0, start.Location())
+	if month.Before(start) {
+		month = month.AddDate(0, 1, 0)
+	}
+	if schedule.LastPosted != nil {
+		for !month.After(*schedule.LastPosted) {
+			month = month.AddDate(0, 1, 0)
+		}
+	}
+
+	var dates []time.Time
+	for ; !month.After(asOf); month = month.AddDate(0, 1, 0) {
+		if schedule.EndDate != nil && month.After(*schedule.EndDate) {
+			break
+		}
+		dates = append(dates, month)
+	}
+	return dates
+}
@@ -0,0 +1,421 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+)
+
+var (
+	ErrInvalidChecklistItem   = errors.New("checklist item requires a valid room type, area and label")
+	ErrInvalidInspectionType  = errors.New("inspection type must be move_in or move_out")
+	ErrInspectionExists       = errors.New("tenant already has an inspection of this type")
+	ErrTenantHasNoRoom        = errors.New("tenant has no room to inspect")
+	ErrInvalidInspectionItem  = errors.New("inspection item requires an area, label, valid condition and non-negative cost")
+	ErrInspectionLocked       = errors.New("inspection has been completed and can no longer be edited")
+	ErrInspectionIncomplete   = errors.New("every inspection item needs a condition before completing")
+	ErrInspectionNotCompleted = errors.New("inspection has not been completed")
+	ErrInspectionForbidden    = errors.New("only the inspected tenant or staff can access this inspection")
+	ErrDisputeNeedsComment    = errors.New("a disputed inspection needs a comment")
+	ErrInspectionsMissing     = errors.New("tenant needs completed move-in and move-out inspections")
+)
+
+// conditionRank orders inspection conditions from best to worst so that a
+// move-out rating can be compared with the move-in rating.
+var conditionRank = map[string]int{
+	"excellent": 0,
+	"good":      1,
+	"fair":      2,
+	"poor":      3,
+	"damaged":   4,
+	"missing":   5,
+}
+
+type InspectionService struct {
+	inspectionRepo *repositories.InspectionRepository
+	templates      *NotificationTemplateService
+}
+
+func NewInspectionService(inspectionRepo *repositories.InspectionRepository,
+	templates *NotificationTemplateService) *InspectionService {
+	return &InspectionService{inspectionRepo: inspectionRepo, templates: templates}
+}
+
+func (s *InspectionService) GetChecklist(roomType string) ([]models.ChecklistItem, error) {
+	return s.inspectionRepo.GetChecklist(roomType)
+}
+
+func (s *InspectionService) CreateChecklistItem(item *models.ChecklistItem) error {
+	if err := validateChecklistItem(item); err != nil {
+		return err
+	}
+	return s.inspectionRepo.CreateChecklistItem(item)
+}
+
+func (s *InspectionService) UpdateChecklistItem(id string, item *models.ChecklistItem) error {
+	itemID, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(item.Area) == "" || strings.TrimSpace(item.Label) == "" {
+		return ErrInvalidChecklistItem
+	}
+	if _, err := s.inspectionRepo.GetChecklistItem(itemID); err != nil {
+		return err
+	}
+	item.ID = itemID
+	return s.inspectionRepo.UpdateChecklistItem(itemID, item)
+}
+
+func (s *InspectionService) DeactivateChecklistItem(id string) error {
+	itemID, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	if _, err := s.inspectionRepo.GetChecklistItem(itemID); err != nil {
+		return err
+	}
+	return s.inspectionRepo.DeactivateChecklistItem(itemID)
+}
+
+// StartInspection opens a draft move-in or move-out inspection of the
+// tenant's current room. Each tenancy has at most one of each.
+func (s *InspectionService) StartInspection(inspection *models.Inspection) error {
+	if inspection.InspectionType != "move_in" && inspection.InspectionType != "move_out" {
+		return ErrInvalidInspectionType
+	}
+	tenant, err := s.inspectionRepo.GetTenant(inspection.TenantID)
+	if err != nil {
+		return err
+	}
+	if tenant.RoomID == 0 {
+		return ErrTenantHasNoRoom
+	}
+
+	_, err = s.inspectionRepo.GetInspectionByType(tenant.ID, inspection.InspectionType)
+	if err == nil {
+		return ErrInspectionExists
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	inspection.RoomID = tenant.RoomID
+	if err := s.inspectionRepo.CreateInspection(inspection); err != nil {
+		return err
+	}
+	created, err := s.inspectionRepo.GetInspection(inspection.ID)
+	if err != nil {
+		return err
+	}
+	*inspection = *created
+	return nil
+}
+
+func (s *InspectionService) GetInspection(id string, userID int, role string) (*models.Inspection, error) {
+	inspection, err := s.getInspection(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(inspection.TenantID, userID, role); err != nil {
+		return nil, err
+	}
+	return inspection, nil
+}
+
+func (s *InspectionService) GetInspectionsByTenant(tenantId string, userID int, role string) ([]models.Inspection, error) {
+	tenantID, err := strconv.Atoi(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(tenantID, userID, role); err != nil {
+		return nil, err
+	}
+	return s.inspectionRepo.GetInspectionsByTenant(tenantID)
+}
+
+// AddItem adds an item that is not on the room type's checklist.
+func (s *InspectionService) AddItem(inspectionId string, item *models.InspectionItem) error {
+	inspection, err := s.getDraft(inspectionId)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(item.Area) == "" || strings.TrimSpace(item.Label) == "" {
+		return ErrInvalidInspectionItem
+	}
+	if err := validateInspectionRating(item); err != nil {
+		return err
+	}
+	item.InspectionID = inspection.ID
+	item.ChecklistItemID = nil
+	return s.inspectionRepo.AddItem(item)
+}
+
+func (s *InspectionService) UpdateItem(inspectionId, itemId string, item *models.InspectionItem) error {
+	inspection, err := s.getDraft(inspectionId)
+	if err != nil {
+		return err
+	}
+	itemID, err := strconv.Atoi(itemId)
+	if err != nil {
+		return err
+	}
+	if findInspectionItem(inspection, itemID) == nil {
+		return sql.ErrNoRows
+	}
+	if err := validateInspectionRating(item); err != nil {
+		return err
+	}
+	return s.inspectionRepo.UpdateItem(inspection.ID, itemID, item)
+}
+
+// AddPhotos attaches photo evidence to an item of a draft inspection.
+func (s *InspectionService) AddPhotos(inspectionId, itemId string, photos []models.InspectionPhoto) error {
+	inspection, err := s.getDraft(inspectionId)
+	if err != nil {
+		return err
+	}
+	itemID, err := strconv.Atoi(itemId)
+	if err != nil {
+		return err
+	}
+	if findInspectionItem(inspection, itemID) == nil {
+		return sql.ErrNoRows
+	}
+	return s.inspectionRepo.AddPhotos(itemID, photos)
+}
+
+// CompleteInspection locks a draft once every item has been rated and asks
+// the tenant to acknowledge it.
+func (s *InspectionService) CompleteInspection(id string) (*models.Inspection, error) {
+	inspection, err := s.getDraft(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range inspection.Items {
+		if item.Condition == "" {
+			return nil, ErrInspectionIncomplete
+		}
+	}
+	if err := s.inspectionRepo.UpdateStatus(inspection.ID, "draft", "completed", ""); err != nil {
+		return nil, err
+	}
+
+	if tenant, err := s.inspectionRepo.GetTenant(inspection.TenantID); err == nil {
+		s.notify(tenant.UserID, TemplateInspectionReady, inspection, nil)
+	}
+	return s.inspectionRepo.GetInspection(inspection.ID)
+}
+
+// Acknowledge records the tenant's sign-off on a completed inspection, or
+// their dispute of it with a comment.
+func (s *InspectionService) Acknowledge(id string, userID int, agree bool, comment string) (*models.Inspection, error) {
+	inspection, err := s.getInspection(id)
+	if err != nil {
+		return nil, err
+	}
+	tenant, err := s.inspectionRepo.GetTenant(inspection.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if tenant.UserID != userID {
+		return nil, ErrInspectionForbidden
+	}
+	if inspection.Status != "completed" {
+		return nil, ErrInspectionNotCompleted
+	}
+
+	status := "acknowledged"
+	comment = strings.TrimSpace(comment)
+	if !agree {
+		if comment == "" {
+			return nil, ErrDisputeNeedsComment
+		}
+		status = "disputed"
+	}
+	if err := s.inspectionRepo.UpdateStatus(inspection.ID, "completed", status, comment); err != nil {
+		return nil, err
+	}
+
+	s.notify(inspection.InspectedBy, TemplateInspectionAcknowledged, inspection,
+		map[string]interface{}{"status": status})
+	return s.inspectionRepo.GetInspection(inspection.ID)
+}
+
+// Diff compares a tenant's move-in and move-out inspections item by item.
+// Items that are in worse condition at move-out contribute their estimated
+// repair cost to the suggested deposit deduction, capped at the deposit.
+func (s *InspectionService) Diff(tenantId string, userID int, role string) (*models.InspectionDiff, error) {
+	tenantID, err := strconv.Atoi(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(tenantID, userID, role); err != nil {
+		return nil, err
+	}
+	tenant, err := s.inspectionRepo.GetTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	moveIn, err := s.completedInspection(tenantID, "move_in")
+	if err != nil {
+		return nil, err
+	}
+	moveOut, err := s.completedInspection(tenantID, "move_out")
+	if err != nil {
+		return nil, err
+	}
+
+	before := make(map[string]models.InspectionItem, len(moveIn.Items))
+	for _, item := range moveIn.Items {
+		before[inspectionItemKey(item)] = item
+	}
+
+	diff := &models.InspectionDiff{
+		TenantID:      tenantID,
+		MoveInID:      moveIn.ID,
+		MoveOutID:     moveOut.ID,
+		Items:         []models.InspectionItemDiff{},
+		DepositAmount: tenant.DepositAmount,
+	}
+	total := 0.0
+	for _, item := range moveOut.Items {
+		entry := models.InspectionItemDiff{
+			Area:             item.Area,
+			Label:            item.Label,
+			MoveOutCondition: item.Condition,
+			MoveOutNotes:     item.Notes,
+			MoveInPhotos:     []models.InspectionPhoto{},
+			MoveOutPhotos:    item.Photos,
+		}
+		if previous, ok := before[inspectionItemKey(item)]; ok {
+			entry.MoveInCondition = previous.Condition
+			entry.MoveInPhotos = previous.Photos
+			entry.Deteriorated = conditionRank[item.Condition] > conditionRank[previous.Condition]
+		} else {
+			// Not recorded at move-in, so only clear damage counts.
+			entry.Deteriorated = conditionRank[item.Condition] >= conditionRank["poor"]
+		}
+		if entry.Deteriorated && item.EstimatedCost != nil {
+			entry.EstimatedCost = roundCents(*item.EstimatedCost)
+			total += *item.EstimatedCost
+		}
+		diff.Items = append(diff.Items, entry)
+	}
+
+	diff.SuggestedDeduction = roundCents(total)
+	if diff.SuggestedDeduction > diff.DepositAmount {
+		diff.SuggestedDeduction = diff.DepositAmount
+	}
+	diff.RefundableDeposit = roundCents(diff.DepositAmount - diff.SuggestedDeduction)
+	return diff, nil
+}
+
+func (s *InspectionService) getInspection(id string) (*models.Inspection, error) {
+	inspectionID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.inspectionRepo.GetInspection(inspectionID)
+}
+
+func (s *InspectionService) getDraft(id string) (*models.Inspection, error) {
+	inspection, err := s.getInspection(id)
+	if err != nil {
+		return nil, err
+	}
+	if inspection.Status != "draft" {
+		return nil, ErrInspectionLocked
+	}
+	return inspection, nil
+}
+
+func (s *InspectionService) completedInspection(tenantID int, inspectionType string) (*models.Inspection, error) {
+	inspection, err := s.inspectionRepo.GetInspectionByType(tenantID, inspectionType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInspectionsMissing
+	}
+	if err != nil {
+		return nil, err
+	}
+	if inspection.Status == "draft" {
+		return nil, ErrInspectionsMissing
+	}
+	return inspection, nil
+}
+
+// checkAccess lets staff see any inspection and tenants only their own.
+func (s *InspectionService) checkAccess(tenantID, userID int, role string) error {
+	if isStaffRole(role) {
+		return nil
+	}
+	tenant, err := s.inspectionRepo.GetTenant(tenantID)
+	if err != nil {
+		return err
+	}
+	if tenant.UserID != userID {
+		return ErrInspectionForbidden
+	}
+	return nil
+}
+
+func (s *InspectionService) notify(userID int, name string, inspection *models.Inspection, vars map[string]interface{}) {
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+	vars["inspection"] = inspectionLabel(inspection.InspectionType)
+	vars["inspection_id"] = inspection.ID
+	if err := s.templates.Send(userID, name, vars); err != nil {
+		log.Printf("Failed to notify user %d about inspection %d: %v", userID, inspection.ID, err)
+	}
+}
+
+func validateChecklistItem(item *models.ChecklistItem) error {
+	switch item.RoomType {
+	case "single", "double", "dormitory", "suite":
+	default:
+		return ErrInvalidChecklistItem
+	}
+	if strings.TrimSpace(item.Area) == "" || strings.TrimSpace(item.Label) == "" {
+		return ErrInvalidChecklistItem
+	}
+	return nil
+}
+
+func validateInspectionRating(item *models.InspectionItem) error {
+	if _, ok := conditionRank[item.Condition]; !ok && item.Condition != "" {
+		return ErrInvalidInspectionItem
+	}
+	if item.EstimatedCost != nil && *item.EstimatedCost < 0 {
+		return ErrInvalidInspectionItem
+	}
+	return nil
+}
+
+func findInspectionItem(inspection *models.Inspection, itemID int) *models.InspectionItem {
+	for i := range inspection.Items {
+		if inspection.Items[i].ID == itemID {
+			return &inspection.Items[i]
+		}
+	}
+	return nil
+}
+
+// inspectionItemKey matches items across inspections by their checklist
+// entry, falling back to area and label for items added by hand.
+func inspectionItemKey(item models.InspectionItem) string {
+	if item.ChecklistItemID != nil {
+		return strconv.Itoa(*item.ChecklistItemID)
+	}
+	return strings.ToLower(strings.TrimSpace(item.Area)) + "/" + strings.ToLower(strings.TrimSpace(item.Label))
+}
+
+func inspectionLabel(inspectionType string) string {
+	return strings.ReplaceAll(inspectionType, "_", "-")
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+)
+
+var ErrInvalidDueDay = errors.New("due day must be between 1 and 28")
+
+type InvoiceService struct {
+	invoiceRepo *repositories.InvoiceRepository
+}
+
+func NewInvoiceService(invoiceRepo *repositories.InvoiceRepository) *InvoiceService {
+	return &InvoiceService{invoiceRepo: invoiceRepo}
+}
+
+// GenerateMonthlyInvoices issues rent invoices for the month given as
+// YYYY-MM, due on the given day of that month.
+func (s *InvoiceService) GenerateMonthlyInvoices(month string, dueDay int) (int, error) {
+	period, err := time.Parse("2006-01", month)
+	if err != nil {
+		return 0, err
+	}
+	if dueDay < 1 || dueDay > 28 {
+		return 0, ErrInvalidDueDay
+	}
+	dueDate := period.AddDate(0, 0, dueDay-1)
+	return s.invoiceRepo.GenerateMonthlyInvoices(period, dueDate)
+}
+
+func (s *InvoiceService) GetInvoicesByTenant(tenantId string) ([]models.Invoice, error) {
+	tenantID, err := strconv.Atoi(tenantId)
+	if err != nil {
+		return nil, err
+	}
+	return s.invoiceRepo.GetInvoicesByTenant(tenantID)
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+)
+
+var (
+	ErrInvalidMaintenancePlan = errors.New("plan requires a title, issue type, next due date, an interval of 1-60 months and a lead time of 0-90 days")
+	ErrInvalidPlanAsset       = errors.New("plan asset must be an active asset in the plan's house and room")
+)
+
+const (
+	maxPlanIntervalMonths = 60
+	maxPlanLeadDays       = 90
+)
+
+type MaintenancePlanService struct {
+	planRepo          *repositories.MaintenancePlanRepository
+	templates         *NotificationTemplateService
+	assignmentService *AssignmentService
+}
+
+func NewMaintenancePlanService(planRepo *repositories.MaintenancePlanRepository,
+	templates *NotificationTemplateService, assignmentService *AssignmentService) *MaintenancePlanService {
+	return &MaintenancePlanService{
+		planRepo:          planRepo,
+		templates:         templates,
+		assignmentService: assignmentService,
+	}
+}
+
+func (s *MaintenancePlanService) CreatePlan(plan *models.MaintenancePlan) error {
+	if err := validatePlan(plan); err != nil {
+		return err
+	}
+	if err := s.checkAsset(plan); err != nil {
+		return err
+	}
+	return s.planRepo.CreatePlan(plan)
+}
+
+func (s *MaintenancePlanService) GetPlan(id string) (*models.MaintenancePlan, error) {
+	planID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.planRepo.GetPlan(planID)
+}
+
+func (s *MaintenancePlanService) GetPlans(houseId string, activeOnly bool) ([]models.MaintenancePlan, error) {
+	houseID := 0
+	if houseId != "" {
+		parsed, err := strconv.Atoi(houseId)
+		if err != nil {
+			return nil, err
+		}
+		houseID = parsed
+	}
+	plans, err := s.planRepo.GetPlans(houseID, activeOnly)
+	if plans == nil && err == nil {
+		plans = []models.MaintenancePlan{}
+	}
+	return plans, err
+}
+
+// UpdatePlan changes a plan's schedule or target. The house and creator of
+// a plan cannot be changed.
+func (s *MaintenancePlanService) UpdatePlan(id string, plan *models.MaintenancePlan) error {
+	existing, err := s.GetPlan(id)
+	if err != nil {
+		return err
+	}
+	plan.ID = existing.ID
+	plan.HouseID = existing.HouseID
+	plan.CreatedBy = existing.CreatedBy
+	plan.CreatedAt = existing.CreatedAt
+	if err := validatePlan(plan); err != nil {
+		return err
+	}
+	if err := s.checkAsset(plan); err != nil {
+		return err
+	}
+	return s.planRepo.UpdatePlan(existing.ID, plan)
+}
+
+func (s *MaintenancePlanService) DeactivatePlan(id string) error {
+	plan, err := s.GetPlan(id)
+	if err != nil {
+		return err
+	}
+	return s.planRepo.DeactivatePlan(plan.ID)
+}
+
+// Run generates requests for due plans every interval until the process
+// exits.
+func (s *MaintenancePlanService) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := s.GenerateDue(time.Now()); err != nil {
+			log.Printf("Maintenance plan generation failed: %v", err)
+		}
+	}
+}
+
+// GenerateDue creates maintenance requests for every plan occurrence that
+// falls within its lead time of asOf, including occurrences missed while the
+// job was not running. It returns the number of requests created.
+func (s *MaintenancePlanService) GenerateDue(asOf time.Time) (int, error) {
+	plans, err := s.planRepo.GetDuePlans(asOf)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, plan := range plans {
+		due := plan.NextDueDate
+		for !due.AddDate(0, 0, -plan.LeadDays).After(asOf) {
+			next := due.AddDate(0, plan.IntervalMonths, 0)
+			requests, err := s.planRepo.GenerateOccurrence(plan.ID, due, next)
+			if err != nil {
+				log.Printf("Failed to generate maintenance plan %d for %s: %v", plan.ID, due.Format("2006-01-02"), err)
+				break
+			}
+			total += len(requests)
+			if len(requests) > 0 {
+				s.notifyAssignee(plan, due, len(requests))
+			}
+			for i := range requests {
+				s.assignmentService.AssignNew(&requests[i])
+			}
+			due = next
+		}
+	}
+	return total, nil
+}
+
+// Calendar lists plan occurrences due in a YYYY-MM range, defaulting to the
+// current month and the two after it. Occurrences that have already been
+// generated carry their request IDs; later ones are projected from each
+// active plan's interval.
+func (s *MaintenancePlanService) Calendar(filter AnalyticsFilter) ([]models.PlanOccurrence, error) {
+	now := time.Now()
+	if filter.From == "" {
+		filter.From = now.Format("2006-01")
+	}
+	if filter.To == "" {
+		filter.To = now.AddDate(0, 2, 0).Format("2006-01")
+	}
+	houseID, start, end, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err := s.planRepo.GetRuns(houseID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	plans, err := s.planRepo.GetPlans(houseID, false)
+	if err != nil {
+		return nil, err
+	}
+	planByID := make(map[int]models.MaintenancePlan, len(plans))
+	for _, plan := range plans {
+		planByID[plan.ID] = plan
+	}
+
+	occurrences := []models.PlanOccurrence{}
+	generated := make(map[string]int)
+	for _, run := range runs {
+		key := fmt.Sprintf("%d/%s", run.PlanID, run.DueDate.Format("2006-01-02"))
+		i, ok := generated[key]
+		if !ok {
+			i = len(occurrences)
+			generated[key] = i
+			occurrences = append(occurrences, models.PlanOccurrence{
+				PlanID:    run.PlanID,
+				Title:     run.PlanTitle,
+				HouseID:   planByID[run.PlanID].HouseID,
+				DueDate:   run.DueDate,
+				Generated: true,
+			})
+		}
+		occurrences[i].RequestIDs = append(occurrences[i].RequestIDs, run.RequestID)
+	}
+
+	for _, plan := range plans {
+		if !plan.IsActive {
+			continue
+		}
+		for due := plan.NextDueDate; due.Before(end); due = due.AddDate(0, plan.IntervalMonths, 0) {
+			if due.Before(start) {
+				continue
+			}
+			occurrences = append(occurrences, models.PlanOccurrence{
+				PlanID:     plan.ID,
+				Title:      plan.Title,
+				HouseID:    plan.HouseID,
+				DueDate:    due,
+				RequestIDs: []int{},
+			})
+		}
+	}
+
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return occurrences[i].DueDate.Before(occurrences[j].DueDate)
+	})
+	return occurrences, nil
+}
+
+// PlanHistory returns every generated occurrence of a plan with the status
+// and completion date of its requests.
+func (s *MaintenancePlanService) PlanHistory(planId string) ([]models.PlanRun, error) {
+	plan, err := s.GetPlan(planId)
+	if err != nil {
+		return nil, err
+	}
+	return s.planRepo.GetRunsByPlan(plan.ID)
+}
+
+func (s *MaintenancePlanService) RoomHistory(roomId string) ([]models.PlanRun, error) {
+	roomID, err := strconv.Atoi(roomId)
+	if err != nil {
+		return nil, err
+	}
+	return s.planRepo.GetRunsByRoom(roomID)
+}
+
+// AssetHistory returns the preventive maintenance carried out on an asset.
+func (s *MaintenancePlanService) AssetHistory(assetId string) ([]models.PlanRun, error) {
+	assetID, err := strconv.Atoi(assetId)
+	if err != nil {
+		return nil, err
+	}
+	return s.planRepo.GetRunsByAsset(assetID)
+}
+
+// checkAsset makes sure a plan for one asset targets an active asset in the
+// plan's house, and in its room when one is given. The plan then covers the
+// asset's room, wherever the asset is moved later.
+func (s *MaintenancePlanService) checkAsset(plan *models.MaintenancePlan) error {
+	if plan.AssetID == nil {
+		return nil
+	}
+	roomID, houseID, err := s.planRepo.GetAssetRoom(*plan.AssetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInvalidPlanAsset
+	}
+	if err != nil {
+		return err
+	}
+	if houseID != plan.HouseID || (plan.RoomID != nil && *plan.RoomID != roomID) || plan.RoomType != "" {
+		return ErrInvalidPlanAsset
+	}
+	plan.RoomID = &roomID
+	return nil
+}
+
+func (s *MaintenancePlanService) notifyAssignee(plan models.MaintenancePlan, due time.Time, created int) {
+	if plan.AssignedTo == nil {
+		return
+	}
+	err := s.templates.Send(*plan.AssignedTo, TemplatePreventiveMaintenance, map[string]interface{}{
+		"plan":     plan.Title,
+		"due_date": due.Format("2006-01-02"),
+		"count":    created,
+	})
+	if err != nil {
+		log.Printf("Failed to notify user %d of maintenance plan %d: %v", *plan.AssignedTo, plan.ID, err)
+	}
+}
+
+func validatePlan(plan *models.MaintenancePlan) error {
+	if strings.TrimSpace(plan.Title) == "" || strings.TrimSpace(plan.IssueType) == "" || plan.NextDueDate.IsZero() {
+		return ErrInvalidMaintenancePlan
+	}
+	if plan.IntervalMonths < 1 || plan.IntervalMonths > maxPlanIntervalMonths {
+		return ErrInvalidMaintenancePlan
+	}
+	if plan.LeadDays < 0 || plan.LeadDays > maxPlanLeadDays {
+		return ErrInvalidMaintenancePlan
+	}
+	switch plan.RoomType {
+	case "", "single", "double", "dormitory", "suite":
+	default:
+		return ErrInvalidMaintenancePlan
+	}
+	switch plan.Priority {
+	case "":
+		plan.Priority = "low"
+	case "low", "medium", "high", "emergency":
+	default:
+		return ErrInvalidMaintenancePlan
+	}
+	return nil
+}
@@ -1,25 +1,96 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"strconv"
+	"time"
 
 	"github.com/Kimox23/boarding-house-app/internal/models"
 	"github.com/Kimox23/boarding-house-app/internal/repositories"
 )
 
+var (
+	ErrInvalidStatusTransition = errors.New("maintenance request cannot move to the requested status")
+	ErrReopenWindowExpired     = errors.New("maintenance request was closed too long ago to reopen")
+	ErrEmptyComment            = errors.New("comment needs a message or an attachment")
+	ErrInternalNoteForbidden   = errors.New("only staff can write internal notes")
+	ErrQuoteApprovalRequired   = errors.New("vendor work cannot start before a quote is approved")
+	ErrAssetNotInRoom          = errors.New("asset is not in the request's room")
+	ErrMaintenanceNotAllowed   = errors.New("maintenance request belongs to another tenant")
+)
+
+// maintenanceTransitions lists the statuses each status may move to.
+// Moving a closed request back to pending reopens it.
+var maintenanceTransitions = map[string][]string{
+	"pending":     {"in_progress", "cancelled"},
+	"in_progress": {"completed", "cancelled"},
+	"completed":   {"pending"},
+	"cancelled":   {"pending"},
+}
+
 type MaintenanceService struct {
-	maintenanceRepo *repositories.MaintenanceRepository
+	maintenanceRepo   *repositories.MaintenanceRepository
+	templates         *NotificationTemplateService
+	assignmentService *AssignmentService
+	reopenWindow      time.Duration
+	statusListeners   []StatusListener
 }
 
-func NewMaintenanceService(maintenanceRepo *repositories.MaintenanceRepository) *MaintenanceService {
-	return &MaintenanceService{maintenanceRepo: maintenanceRepo}
+// StatusListener is called after a request's status or assignee changes.
+type StatusListener func(request *models.MaintenanceRequest)
+
+func NewMaintenanceService(maintenanceRepo *repositories.MaintenanceRepository,
+	templates *NotificationTemplateService, assignmentService *AssignmentService,
+	reopenDays int) *MaintenanceService {
+	return &MaintenanceService{
+		maintenanceRepo:   maintenanceRepo,
+		templates:         templates,
+		assignmentService: assignmentService,
+		reopenWindow:      time.Duration(reopenDays) * 24 * time.Hour,
+	}
 }
 
-func (s *MaintenanceService) CreateRequest(request *models.MaintenanceRequest) error {
-	return s.maintenanceRepo.CreateRequest(request)
+// CreateRequest files a request. Staff may file one on behalf of a tenant;
+// anyone else reports it themselves and only for a room they rent.
+func (s *MaintenanceService) CreateRequest(request *models.MaintenanceRequest, userID int, role string) error {
+	if !isStaffRole(role) || request.ReportedBy == 0 {
+		request.ReportedBy = userID
+	}
+	if !isStaffRole(role) {
+		ok, err := s.maintenanceRepo.IsTenantOfRoom(userID, request.RoomID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrMaintenanceNotAllowed
+		}
+	}
+	if err := s.checkAsset(request); err != nil {
+		return err
+	}
+	if err := s.maintenanceRepo.CreateRequest(request); err != nil {
+		return err
+	}
+	s.assignmentService.AssignNew(request)
+	return nil
 }
 
-func (s *MaintenanceService) GetRequest(id string) (*models.MaintenanceRequest, error) {
+// GetRequest returns a request to staff, its reporter or a tenant of its room.
+func (s *MaintenanceService) GetRequest(id string, userID int, role string) (*models.MaintenanceRequest, error) {
+	request, err := s.request(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(request, userID, role); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// request looks up a request without checking who is asking.
+func (s *MaintenanceService) request(id string) (*models.MaintenanceRequest, error) {
 	requestID, err := strconv.Atoi(id)
 	if err != nil {
 		return nil, err
@@ -27,28 +98,124 @@ func (s *MaintenanceService) GetRequest(id string) (*models.MaintenanceRequest,
 	return s.maintenanceRepo.GetRequest(requestID)
 }
 
-func (s *MaintenanceService) GetRequestsByRoom(roomId string) ([]models.MaintenanceRequest, error) {
+// GetRequestsByRoom returns the requests for a room to staff or to a tenant
+// of the room.
+func (s *MaintenanceService) GetRequestsByRoom(roomId string, userID int, role string) ([]models.MaintenanceRequest, error) {
 	roomID, err := strconv.Atoi(roomId)
 	if err != nil {
 		return nil, err
 	}
+	if !isStaffRole(role) {
+		ok, err := s.maintenanceRepo.IsTenantOfRoom(userID, roomID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrMaintenanceNotAllowed
+		}
+	}
 	return s.maintenanceRepo.GetRequestsByRoom(roomID)
 }
 
-func (s *MaintenanceService) UpdateRequest(id string, request *models.MaintenanceRequest) error {
+func (s *MaintenanceService) UpdateRequest(id string, request *models.MaintenanceRequest, actorID int) error {
 	requestID, err := strconv.Atoi(id)
 	if err != nil {
 		return err
 	}
-	return s.maintenanceRepo.UpdateRequest(requestID, request)
+	if err := s.checkAsset(request); err != nil {
+		return err
+	}
+	return s.maintenanceRepo.UpdateRequest(requestID, request, actorID)
 }
 
-func (s *MaintenanceService) UpdateRequestStatus(id string, status string, assignedTo *int) error {
-	requestID, err := strconv.Atoi(id)
+// checkAsset makes sure a request only links to an asset in its own room.
+func (s *MaintenanceService) checkAsset(request *models.MaintenanceRequest) error {
+	if request.AssetID == nil {
+		return nil
+	}
+	inRoom, err := s.maintenanceRepo.AssetInRoom(*request.AssetID, request.RoomID)
 	if err != nil {
 		return err
 	}
-	return s.maintenanceRepo.UpdateRequestStatus(requestID, status, assignedTo)
+	if !inRoom {
+		return ErrAssetNotInRoom
+	}
+	return nil
+}
+
+// UpdateRequestStatus validates and applies a status change. An empty
+// status leaves the status alone and only applies the assignment.
+func (s *MaintenanceService) UpdateRequestStatus(id string, status string, assignedTo *int, actorID int, note string) (*models.MaintenanceRequest, error) {
+	request, err := s.request(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var to string
+	if status != "" && status != request.Status {
+		if err := s.checkTransition(request, status); err != nil {
+			return nil, err
+		}
+		if status == "in_progress" && request.VendorID != nil {
+			approved, err := s.maintenanceRepo.HasApprovedQuote(request.ID, *request.VendorID)
+			if err != nil {
+				return nil, err
+			}
+			if !approved {
+				return nil, ErrQuoteApprovalRequired
+			}
+		}
+		to = status
+	}
+
+	var assignee *int
+	if assignedTo != nil && (request.AssignedTo == nil || *request.AssignedTo != *assignedTo) {
+		assignee = assignedTo
+	}
+
+	if to != "" || assignee != nil {
+		if err := s.maintenanceRepo.ChangeStatusAndAssignee(request.ID, request.Status, to, assignee, actorID, note); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.statusChanged(request)
+}
+
+// statusChanged reloads a request after a possible status or assignee
+// change and tells the listeners when either differs from before.
+func (s *MaintenanceService) statusChanged(before *models.MaintenanceRequest) (*models.MaintenanceRequest, error) {
+	updated, err := s.maintenanceRepo.GetRequest(before.ID)
+	if err != nil {
+		return nil, err
+	}
+	if updated.Status != before.Status || !sameAssignee(updated.AssignedTo, before.AssignedTo) {
+		for _, listener := range s.statusListeners {
+			listener(updated)
+		}
+	}
+	return updated, nil
+}
+
+// OnStatusChange registers a listener for status and assignment changes.
+// Listeners must be registered before the service is used.
+func (s *MaintenanceService) OnStatusChange(listener StatusListener) {
+	s.statusListeners = append(s.statusListeners, listener)
+}
+
+func sameAssignee(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func (s *MaintenanceService) GetTimeline(id string, userID int, role string) ([]models.MaintenanceEvent, error) {
+	request, err := s.GetRequest(id, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	return s.maintenanceRepo.GetEvents(request.ID)
 }
 
 func (s *MaintenanceService) DeleteRequest(id string) error {
@@ -58,3 +225,155 @@ func (s *MaintenanceService) DeleteRequest(id string) error {
 	}
 	return s.maintenanceRepo.DeleteRequest(requestID)
 }
+
+// AddComment posts a comment on a request and notifies the other
+// participants. Internal notes are only visible to, and only notify, staff.
+func (s *MaintenanceService) AddComment(requestId string, comment *models.MaintenanceComment, role string) error {
+	request, err := s.request(requestId)
+	if err != nil {
+		return err
+	}
+	if err := s.checkAccess(request, comment.AuthorID, role); err != nil {
+		return err
+	}
+	if comment.Body == "" && len(comment.Attachments) == 0 {
+		return ErrEmptyComment
+	}
+	if comment.IsInternal && !isStaffRole(role) {
+		return ErrInternalNoteForbidden
+	}
+
+	comment.RequestID = request.ID
+	comment.AuthorRole = role
+	if comment.Attachments == nil {
+		comment.Attachments = []models.MaintenanceAttachment{}
+	}
+	comment.Replies = []models.MaintenanceComment{}
+	if err := s.maintenanceRepo.CreateComment(comment); err != nil {
+		return err
+	}
+
+	participants, err := s.maintenanceRepo.GetParticipants(request.ID, comment.IsInternal)
+	if err != nil {
+		log.Printf("maintenance request %d: failed to load participants: %v", request.ID, err)
+		return nil
+	}
+	for _, userID := range participants {
+		if userID == comment.AuthorID {
+			continue
+		}
+		err := s.templates.Send(userID, TemplateMaintenanceComment, map[string]interface{}{
+			"request_id": request.ID,
+			"preview":    previewText(comment.Body, len(comment.Attachments)),
+		})
+		if err != nil {
+			log.Printf("maintenance request %d: failed to notify user %d: %v", request.ID, userID, err)
+		}
+	}
+	return nil
+}
+
+// GetComments returns the comment thread for a request with replies nested
+// under their parent. Tenants do not see internal notes.
+func (s *MaintenanceService) GetComments(requestId string, userID int, role string) ([]models.MaintenanceComment, error) {
+	request, err := s.request(requestId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(request, userID, role); err != nil {
+		return nil, err
+	}
+	comments, err := s.maintenanceRepo.GetComments(request.ID, isStaffRole(role))
+	if err != nil {
+		return nil, err
+	}
+	return threadComments(comments), nil
+}
+
+// checkAccess lets staff into every request and tenants only into the
+// requests they reported or that concern the room they rent.
+func (s *MaintenanceService) checkAccess(request *models.MaintenanceRequest, userID int, role string) error {
+	if isStaffRole(role) || request.ReportedBy == userID {
+		return nil
+	}
+	ok, err := s.maintenanceRepo.IsTenantOfRoom(userID, request.RoomID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrMaintenanceNotAllowed
+	}
+	return nil
+}
+
+func (s *MaintenanceService) checkTransition(request *models.MaintenanceRequest, status string) error {
+	allowed := false
+	for _, next := range maintenanceTransitions[request.Status] {
+		if next == status {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return ErrInvalidStatusTransition
+	}
+
+	closedAt := request.CompletedDate
+	if request.Status == "cancelled" {
+		closedAt = request.CancelledDate
+	}
+	if status == "pending" && closedAt != nil && time.Since(*closedAt) > s.reopenWindow {
+		return ErrReopenWindowExpired
+	}
+	return nil
+}
+
+func isStaffRole(role string) bool {
+	return role == "staff" || role == "manager" || role == "admin"
+}
+
+// threadComments nests replies under their parents. Comments arrive oldest
+// first, so every parent is seen before its replies.
+func threadComments(comments []models.MaintenanceComment) []models.MaintenanceComment {
+	children := make(map[int][]int)
+	var roots []int
+	seen := make(map[int]bool)
+	for i, comment := range comments {
+		seen[comment.ID] = true
+		if comment.ParentID != nil && seen[*comment.ParentID] {
+			children[*comment.ParentID] = append(children[*comment.ParentID], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	var build func(i int) models.MaintenanceComment
+	build = func(i int) models.MaintenanceComment {
+		comment := comments[i]
+		comment.Replies = []models.MaintenanceComment{}
+		for _, child := range children[comment.ID] {
+			comment.Replies = append(comment.Replies, build(child))
+		}
+		return comment
+	}
+
+	thread := make([]models.MaintenanceComment, 0, len(roots))
+	for _, i := range roots {
+		thread = append(thread, build(i))
+	}
+	return thread
+}
+
+// previewText shortens a comment or message body for a notification, or
+// counts its attachments when it has no text.
+func previewText(body string, attachments int) string {
+	const limit = 140
+	runes := []rune(body)
+	switch {
+	case len(runes) > limit:
+		return string(runes[:limit]) + "..."
+	case len(runes) == 0:
+		return fmt.Sprintf("%d new attachment(s)", attachments)
+	default:
+		return body
+	}
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+)
+
+var (
+	ErrEmptyMessage              = errors.New("message needs a body or an attachment")
+	ErrInvalidSubject            = errors.New("subject is required and at most 150 characters")
+	ErrNoTenancy                 = errors.New("tenant has no active tenancy")
+	ErrMessageNotAllowed         = errors.New("conversation belongs to another tenant or house")
+	ErrInvalidConversationStatus = errors.New("status must be open or closed")
+)
+
+const maxSubjectLength = 150
+
+// MessageService carries conversations between tenants and the staff of
+// their house. Staff share one inbox per house, so any of them can reply,
+// and each side is notified of the other's messages. Staff and managers
+// only see the houses they manage or are assigned to; admins see every
+// house.
+type MessageService struct {
+	messageRepo *repositories.MessageRepository
+	templates   *NotificationTemplateService
+}
+
+func NewMessageService(messageRepo *repositories.MessageRepository, templates *NotificationTemplateService) *MessageService {
+	return &MessageService{messageRepo: messageRepo, templates: templates}
+}
+
+// StartConversation opens a conversation with a first message. Tenants
+// write to the staff of the house they live in; staff write to the tenant
+// in conversation.TenantUserID, if the tenant lives in one of their houses.
+func (s *MessageService) StartConversation(conversation *models.Conversation, message *models.Message, role string) error {
+	conversation.Subject = strings.TrimSpace(conversation.Subject)
+	if conversation.Subject == "" || len([]rune(conversation.Subject)) > maxSubjectLength {
+		return ErrInvalidSubject
+	}
+	if message.Body == "" && len(message.Attachments) == 0 {
+		return ErrEmptyMessage
+	}
+	if !isStaffRole(role) {
+		conversation.TenantUserID = message.SenderID
+	}
+
+	houseID, err := s.messageRepo.GetTenantHouse(conversation.TenantUserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNoTenancy
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.checkHouse(message.SenderID, role, houseID); err != nil {
+		return err
+	}
+
+	conversation.HouseID = houseID
+	conversation.StartedBy = message.SenderID
+	message.SenderRole = role
+	if message.Attachments == nil {
+		message.Attachments = []models.MessageAttachment{}
+	}
+	if err := s.messageRepo.CreateConversation(conversation, message); err != nil {
+		return err
+	}
+	conversation.Messages = []models.Message{*message}
+
+	s.notify(conversation, message)
+	return nil
+}
+
+// GetConversations lists a tenant's own conversations or, for staff, the
+// conversations of their houses or of the house in houseId.
+func (s *MessageService) GetConversations(userID int, role, houseId, status string) ([]models.Conversation, error) {
+	filter, err := conversationFilter(userID, role, houseId)
+	if err != nil {
+		return nil, err
+	}
+	if status != "" && status != "open" && status != "closed" {
+		return nil, ErrInvalidConversationStatus
+	}
+	filter.Status = status
+	return s.messageRepo.GetConversations(filter, isStaffRole(role))
+}
+
+// GetConversation returns a conversation with its messages and marks the
+// messages the caller's side received as read.
+func (s *MessageService) GetConversation(id string, userID int, role string) (*models.Conversation, error) {
+	conversation, err := s.conversation(id, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.messageRepo.MarkRead(conversation.ID, isStaffRole(role)); err != nil {
+		return nil, err
+	}
+
+	messages, err := s.messageRepo.GetMessages(conversation.ID)
+	if err != nil {
+		return nil, err
+	}
+	conversation.Messages = messages
+	conversation.UnreadCount = 0
+	return conversation, nil
+}
+
+// SendMessage replies in a conversation.
+func (s *MessageService) SendMessage(id string, message *models.Message, role string) error {
+	conversation, err := s.conversation(id, message.SenderID, role)
+	if err != nil {
+		return err
+	}
+	if message.Body == "" && len(message.Attachments) == 0 {
+		return ErrEmptyMessage
+	}
+
+	message.ConversationID = conversation.ID
+	message.SenderRole = role
+	if message.Attachments == nil {
+		message.Attachments = []models.MessageAttachment{}
+	}
+	if err := s.messageRepo.CreateMessage(message); err != nil {
+		return err
+	}
+
+	s.notify(conversation, message)
+	return nil
+}
+
+func (s *MessageService) MarkRead(id string, userID int, role string) error {
+	conversation, err := s.conversation(id, userID, role)
+	if err != nil {
+		return err
+	}
+	_, err = s.messageRepo.MarkRead(conversation.ID, isStaffRole(role))
+	return err
+}
+
+// UnreadCount returns how many messages the caller's side has not read.
+func (s *MessageService) UnreadCount(userID int, role, houseId string) (int, error) {
+	filter, err := conversationFilter(userID, role, houseId)
+	if err != nil {
+		return 0, err
+	}
+	return s.messageRepo.CountUnread(filter, isStaffRole(role))
+}
+
+// UpdateStatus closes or reopens a conversation. A new message reopens a
+// closed conversation.
+func (s *MessageService) UpdateStatus(id string, userID int, role, status string) error {
+	if status != "open" && status != "closed" {
+		return ErrInvalidConversationStatus
+	}
+	conversation, err := s.conversation(id, userID, role)
+	if err != nil {
+		return err
+	}
+	return s.messageRepo.UpdateStatus(conversation.ID, status)
+}
+
+// conversation loads a conversation the caller may take part in: tenants
+// only their own, staff those of their houses.
+func (s *MessageService) conversation(id string, userID int, role string) (*models.Conversation, error) {
+	conversationId, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+	conversation, err := s.messageRepo.GetConversation(conversationId, isStaffRole(role))
+	if err != nil {
+		return nil, err
+	}
+	if !isStaffRole(role) && conversation.TenantUserID != userID {
+		return nil, ErrMessageNotAllowed
+	}
+	if err := s.checkHouse(userID, role, conversation.HouseID); err != nil {
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// checkHouse allows admins into every house and other staff into the houses
+// they work in. Tenants are checked against the conversation instead.
+func (s *MessageService) checkHouse(userID int, role string, houseID int) error {
+	if !isStaffRole(role) || role == "admin" {
+		return nil
+	}
+	allowed, err := s.messageRepo.WorksInHouse(userID, houseID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrMessageNotAllowed
+	}
+	return nil
+}
+
+// notify tells the other side about a new message: the tenant about staff
+// messages, and the house manager and the staff already in the
+// conversation about tenant messages.
+func (s *MessageService) notify(conversation *models.Conversation, message *models.Message) {
+	var recipients []int
+	if message.SenderID == conversation.TenantUserID {
+		staff, err := s.messageRepo.GetStaffRecipients(conversation.ID)
+		if err != nil {
+			log.Printf("conversation %d: failed to load staff recipients: %v", conversation.ID, err)
+			return
+		}
+		recipients = staff
+	} else {
+		recipients = []int{conversation.TenantUserID}
+	}
+
+	vars := map[string]interface{}{
+		"conversation_id": conversation.ID,
+		"subject":         conversation.Subject,
+		"preview":         previewText(message.Body, len(message.Attachments)),
+	}
+	for _, userID := range recipients {
+		if userID == message.SenderID {
+			continue
+		}
+		if err := s.templates.Send(userID, TemplateNewMessage, vars); err != nil {
+			log.Printf("conversation %d: failed to notify user %d: %v", conversation.ID, userID, err)
+		}
+	}
+}
+
+// conversationFilter limits tenants to their own conversations and staff
+// other than admins to the houses they work in, and lets staff narrow
+// theirs to one house.
+func conversationFilter(userID int, role, houseId string) (repositories.ConversationFilter, error) {
+	if !isStaffRole(role) {
+		return repositories.ConversationFilter{TenantUserID: userID}, nil
+	}
+	filter := repositories.ConversationFilter{}
+	if role != "admin" {
+		filter.StaffUserID = userID
+	}
+	if houseId != "" {
+		houseID, err := strconv.Atoi(houseId)
+		if err != nil {
+			return filter, err
+		}
+		filter.HouseID = houseID
+	}
+	return filter, nil
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/notify"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+)
+
+var (
+	ErrInvalidQuietHours      = errors.New("quiet hours need both a start and an end in HH:MM format")
+	ErrNotificationNotAllowed = errors.New("notification belongs to another user")
+)
+
+const (
+	maxDeliveryAttempts = 5
+	deliveryBackoff     = time.Minute
+	deliveryLease       = 5 * time.Minute
+	deliveryBatchSize   = 50
+)
+
+// NotificationDispatcher delivers every notification through the in-app
+// inbox and, depending on the user's preferences, email and SMS. External
+// channels are queued and sent by Run, retrying failures with exponential
+// backoff.
+type NotificationDispatcher struct {
+	deliveryRepo     *repositories.NotificationDeliveryRepository
+	notificationRepo *repositories.NotificationRepository
+	email            notify.EmailSender
+	sms              notify.SMSProvider
+	appURL           string
+}
+
+// NewNotificationDispatcher queues deliveries for every notification created
+// through notificationRepo.
+func NewNotificationDispatcher(deliveryRepo *repositories.NotificationDeliveryRepository,
+	notificationRepo *repositories.NotificationRepository, email notify.EmailSender,
+	sms notify.SMSProvider, appURL string) *NotificationDispatcher {
+	d := &NotificationDispatcher{
+		deliveryRepo:     deliveryRepo,
+		notificationRepo: notificationRepo,
+		email:            email,
+		sms:              sms,
+		appURL:           appURL,
+	}
+	notificationRepo.Listen(d.enqueue)
+	return d
+}
+
+func (d *NotificationDispatcher) GetPreferences(userID int) (*models.NotificationPreferences, error) {
+	return d.deliveryRepo.GetPreferences(userID)
+}
+
+func (d *NotificationDispatcher) UpdatePreferences(userID int, prefs *models.NotificationPreferences) error {
+	if (prefs.QuietHoursStart == "") != (prefs.QuietHoursEnd == "") {
+		return ErrInvalidQuietHours
+	}
+	if prefs.QuietHoursStart != "" {
+		if _, err := time.Parse("15:04", prefs.QuietHoursStart); err != nil {
+			return ErrInvalidQuietHours
+		}
+		if _, err := time.Parse("15:04", prefs.QuietHoursEnd); err != nil {
+			return ErrInvalidQuietHours
+		}
+	}
+	if prefs.Locale == "" {
+		prefs.Locale = repositories.DefaultLocale
+	}
+	locale, ok := normalizeLocale(prefs.Locale)
+	if !ok {
+		return ErrInvalidLocale
+	}
+	prefs.Locale = locale
+	prefs.UserID = userID
+	return d.deliveryRepo.UpsertPreferences(prefs)
+}
+
+// GetDeliveries returns the per-channel delivery status of a notification to
+// its recipient or to an admin.
+func (d *NotificationDispatcher) GetDeliveries(notificationId string, userID int, role string) ([]models.NotificationDelivery, error) {
+	notificationID, err := strconv.Atoi(notificationId)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := d.notificationRepo.GetOwner(notificationID)
+	if err != nil {
+		return nil, err
+	}
+	if owner != userID && role != "admin" {
+		return nil, ErrNotificationNotAllowed
+	}
+	return d.deliveryRepo.GetDeliveries(notificationID)
+}
+
+// Run sends due deliveries every interval until the process exits.
+func (d *NotificationDispatcher) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := d.DispatchDue(time.Now()); err != nil {
+			log.Printf("Notification dispatch failed: %v", err)
+		}
+	}
+}
+
+// DispatchDue attempts every delivery due at asOf and returns how many were
+// sent.
+func (d *NotificationDispatcher) DispatchDue(asOf time.Time) (int, error) {
+	jobs, err := d.deliveryRepo.GetDueDeliveries(asOf, deliveryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, job := range jobs {
+		claimed, err := d.deliveryRepo.Claim(job.Delivery.ID, asOf, deliveryLease)
+		if err != nil {
+			log.Printf("Failed to claim notification delivery %d: %v", job.Delivery.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if d.deliver(job, asOf) {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// enqueue records the in-app delivery and queues the external channels the
+// user has enabled. Email and SMS created during quiet hours wait until the
+// quiet hours end.
+func (d *NotificationDispatcher) enqueue(userID int, notification *models.Notification) {
+	if notification == nil {
+		return
+	}
+
+	now := time.Now()
+	inApp := &models.NotificationDelivery{
+		NotificationID: notification.ID,
+		Channel:        "in_app",
+		Status:         "sent",
+		SentAt:         &now,
+	}
+	if err := d.deliveryRepo.CreateDelivery(inApp); err != nil {
+		log.Printf("Failed to record in-app delivery of notification %d: %v", notification.ID, err)
+	}
+
+	prefs, err := d.deliveryRepo.GetPreferences(userID)
+	if err != nil {
+		log.Printf("Failed to load notification preferences for user %d: %v", userID, err)
+		return
+	}
+
+	nextAttempt := now
+	if until, quiet := quietUntil(prefs, now); quiet {
+		nextAttempt = until
+	}
+	channels := []struct {
+		name    string
+		enabled bool
+	}{{"email", prefs.EmailEnabled}, {"sms", prefs.SMSEnabled}}
+	for _, channel := range channels {
+		if !channel.enabled {
+			continue
+		}
+		delivery := &models.NotificationDelivery{
+			NotificationID: notification.ID,
+			Channel:        channel.name,
+			Status:         "pending",
+			NextAttemptAt:  &nextAttempt,
+		}
+		if err := d.deliveryRepo.CreateDelivery(delivery); err != nil {
+			log.Printf("Failed to queue %s delivery of notification %d: %v", channel.name, notification.ID, err)
+		}
+	}
+}
+
+// deliver sends one claimed delivery and records the outcome. Preferences
+// are checked again because they may have changed since it was queued.
+func (d *NotificationDispatcher) deliver(job repositories.DeliveryJob, asOf time.Time) bool {
+	delivery := job.Delivery
+	prefs, err := d.deliveryRepo.GetPreferences(job.UserID)
+	if err != nil {
+		log.Printf("Failed to load notification preferences for user %d: %v", job.UserID, err)
+		return false
+	}
+	if until, quiet := quietUntil(prefs, asOf); quiet {
+		d.record(delivery.ID, d.deliveryRepo.Reschedule(delivery.ID, until))
+		return false
+	}
+
+	var sendErr error
+	switch delivery.Channel {
+	case "email":
+		if !prefs.EmailEnabled {
+			d.record(delivery.ID, d.deliveryRepo.Skip(delivery.ID, "email disabled"))
+			return false
+		}
+		sendErr = d.email.SendEmail(job.Email, job.Title, d.body(job))
+	case "sms":
+		if !prefs.SMSEnabled {
+			d.record(delivery.ID, d.deliveryRepo.Skip(delivery.ID, "sms disabled"))
+			return false
+		}
+		if job.Phone == "" {
+			d.record(delivery.ID, d.deliveryRepo.Skip(delivery.ID, "no phone number"))
+			return false
+		}
+		sendErr = d.sms.SendSMS(job.Phone, job.Title+": "+job.Message)
+	default:
+		d.record(delivery.ID, d.deliveryRepo.Skip(delivery.ID, "unknown channel"))
+		return false
+	}
+
+	if sendErr == nil {
+		d.record(delivery.ID, d.deliveryRepo.MarkSent(delivery.ID))
+		return true
+	}
+
+	var retryAt *time.Time
+	if attempt := delivery.Attempts + 1; attempt < maxDeliveryAttempts {
+		next := asOf.Add(deliveryBackoff << (attempt - 1))
+		retryAt = &next
+	}
+	log.Printf("Notification delivery %d via %s failed: %v", delivery.ID, delivery.Channel, sendErr)
+	d.record(delivery.ID, d.deliveryRepo.MarkAttemptFailed(delivery.ID, sendErr.Error(), retryAt))
+	return false
+}
+
+func (d *NotificationDispatcher) body(job repositories.DeliveryJob) string {
+	if job.Link == "" {
+		return job.Message
+	}
+	return fmt.Sprintf("%s\n\n%s%s", job.Message, d.appURL, job.Link)
+}
+
+func (d *NotificationDispatcher) record(deliveryID int, err error) {
+	if err != nil {
+		log.Printf("Failed to update notification delivery %d: %v", deliveryID, err)
+	}
+}
+
+// quietUntil reports whether t falls in the user's quiet hours and, if so,
+// when they end. Quiet hours may span midnight.
+func quietUntil(prefs *models.NotificationPreferences, t time.Time) (time.Time, bool) {
+	if prefs.QuietHoursStart == "" || prefs.QuietHoursEnd == "" {
+		return time.Time{}, false
+	}
+	start, err := time.Parse("15:04", prefs.QuietHoursStart)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := time.Parse("15:04", prefs.QuietHoursEnd)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	startAt := day.Add(time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute)
+	endAt := day.Add(time.Duration(end.Hour())*time.Hour + time.Duration(end.Minute())*time.Minute)
+
+	switch {
+	case startAt.Equal(endAt):
+		return time.Time{}, false
+	case startAt.Before(endAt):
+		if !t.Before(startAt) && t.Before(endAt) {
+			return endAt, true
+		}
+	default:
+		if !t.Before(startAt) {
+			return endAt.AddDate(0, 0, 1), true
+		}
+		if t.Before(endAt) {
+			return endAt, true
+		}
+	}
+	return time.Time{}, false
+}
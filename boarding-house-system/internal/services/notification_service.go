@@ -1,44 +1,178 @@
 package services
 
 import (
+	"errors"
+	"log"
 	"strconv"
+	"time"
 
 	"github.com/Kimox23/boarding-house-app/internal/models"
 	"github.com/Kimox23/boarding-house-app/internal/repositories"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
 )
 
+var (
+	ErrInvalidCategory        = errors.New("unknown notification category")
+	ErrInvalidNotificationIDs = errors.New("ids must list between 1 and 500 notifications")
+)
+
+const maxBulkNotificationDeletion = 500
+
+// Notification categories the inbox can be filtered by.
+const (
+	CategoryGeneral       = repositories.DefaultCategory
+	CategoryBilling       = "billing"
+	CategoryMaintenance   = "maintenance"
+	CategoryDocuments     = "documents"
+	CategoryLease         = "lease"
+	CategoryInspections   = "inspections"
+	CategoryAnnouncements = "announcements"
+	CategoryMessages      = "messages"
+)
+
+var NotificationCategories = []string{
+	CategoryGeneral,
+	CategoryBilling,
+	CategoryMaintenance,
+	CategoryDocuments,
+	CategoryLease,
+	CategoryInspections,
+	CategoryAnnouncements,
+	CategoryMessages,
+}
+
 type NotificationService struct {
 	notificationRepo *repositories.NotificationRepository
+	retentionDays    int
 }
 
-func NewNotificationService(notificationRepo *repositories.NotificationRepository) *NotificationService {
-	return &NotificationService{notificationRepo: notificationRepo}
+// NewNotificationService purges read notifications older than retentionDays
+// when Run is started. Zero or less keeps them forever.
+func NewNotificationService(notificationRepo *repositories.NotificationRepository, retentionDays int) *NotificationService {
+	return &NotificationService{notificationRepo: notificationRepo, retentionDays: retentionDays}
 }
 
 func (s *NotificationService) CreateNotification(notification *models.Notification) error {
+	if notification.Category != "" && !isNotificationCategory(notification.Category) {
+		return ErrInvalidCategory
+	}
 	return s.notificationRepo.CreateNotification(notification)
 }
 
-func (s *NotificationService) GetUserNotifications(userId string) ([]models.Notification, error) {
+// GetUserNotifications returns a user's notifications to that user or to an
+// admin.
+func (s *NotificationService) GetUserNotifications(userId string, callerID int, role string) ([]models.Notification, error) {
 	userID, err := strconv.Atoi(userId)
 	if err != nil {
 		return nil, err
 	}
+	if role != "admin" && userID != callerID {
+		return nil, ErrNotificationNotAllowed
+	}
 	return s.notificationRepo.GetUserNotifications(userID)
 }
 
-func (s *NotificationService) MarkAsRead(id string) error {
-	notificationID, err := strconv.Atoi(id)
+// GetInbox returns a page of the user's notifications and the total that
+// match the filter.
+func (s *NotificationService) GetInbox(userID int, filter repositories.InboxFilter, pagination utils.Pagination) ([]models.Notification, int, error) {
+	if filter.Category != "" && !isNotificationCategory(filter.Category) {
+		return nil, 0, ErrInvalidCategory
+	}
+	return s.notificationRepo.GetInbox(userID, filter, pagination.Page, pagination.PageSize)
+}
+
+// UnreadCount returns the user's unread total and the unread count per
+// category.
+func (s *NotificationService) UnreadCount(userID int) (int, map[string]int, error) {
+	counts, err := s.notificationRepo.CountUnreadByCategory(userID)
+	if err != nil {
+		return 0, nil, err
+	}
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	return total, counts, nil
+}
+
+func (s *NotificationService) MarkAsRead(id string, userID int) error {
+	notificationID, err := s.ownNotification(id, userID)
 	if err != nil {
 		return err
 	}
 	return s.notificationRepo.MarkAsRead(notificationID)
 }
 
-func (s *NotificationService) DeleteNotification(id string) error {
-	notificationID, err := strconv.Atoi(id)
+// MarkAllRead marks the user's notifications read, optionally only those of
+// one category.
+func (s *NotificationService) MarkAllRead(userID int, category string) (int64, error) {
+	if category != "" && !isNotificationCategory(category) {
+		return 0, ErrInvalidCategory
+	}
+	return s.notificationRepo.MarkAllRead(userID, category)
+}
+
+func (s *NotificationService) DeleteNotification(id string, userID int) error {
+	notificationID, err := s.ownNotification(id, userID)
 	if err != nil {
 		return err
 	}
 	return s.notificationRepo.DeleteNotification(notificationID)
 }
+
+// DeleteNotifications deletes several of the user's notifications at once.
+// IDs of other users' notifications are ignored.
+func (s *NotificationService) DeleteNotifications(userID int, ids []int) (int64, error) {
+	if len(ids) == 0 || len(ids) > maxBulkNotificationDeletion {
+		return 0, ErrInvalidNotificationIDs
+	}
+	return s.notificationRepo.DeleteNotifications(userID, ids)
+}
+
+// Run purges expired read notifications every interval until the process
+// exits.
+func (s *NotificationService) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := s.PurgeRead(time.Now()); err != nil {
+			log.Printf("Notification retention failed: %v", err)
+		}
+	}
+}
+
+// PurgeRead deletes read notifications older than the retention period and
+// returns how many were deleted.
+func (s *NotificationService) PurgeRead(asOf time.Time) (int64, error) {
+	if s.retentionDays <= 0 {
+		return 0, nil
+	}
+	return s.notificationRepo.PurgeRead(asOf.AddDate(0, 0, -s.retentionDays))
+}
+
+// ownNotification parses a notification ID and checks that it belongs to
+// the user.
+func (s *NotificationService) ownNotification(id string, userID int) (int, error) {
+	notificationID, err := strconv.Atoi(id)
+	if err != nil {
+		return 0, err
+	}
+	owner, err := s.notificationRepo.GetOwner(notificationID)
+	if err != nil {
+		return 0, err
+	}
+	if owner != userID {
+		return 0, ErrNotificationNotAllowed
+	}
+	return notificationID, nil
+}
+
+func isNotificationCategory(category string) bool {
+	for _, known := range NotificationCategories {
+		if category == known {
+			return true
+		}
+	}
+	return false
+}
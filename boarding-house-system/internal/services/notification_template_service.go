@@ -0,0 +1,340 @@
+package services
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+)
+
+var (
+	ErrUnknownTemplate  = errors.New("unknown notification template")
+	ErrInvalidTemplate  = errors.New("template needs a title and a message that parse as Go templates")
+	ErrInvalidLocale    = errors.New("locale must be a language code such as en or pt-br")
+	ErrTemplateVariable = errors.New("template could not be rendered with the given variables")
+)
+
+// Names of the system notification templates.
+const (
+	TemplateRentDue                = "rent_due"
+	TemplatePaymentReceived        = "payment_received"
+	TemplateLeaseExpiring          = "lease_expiring"
+	TemplateDocumentVerified       = "document_verified"
+	TemplateMaintenanceUpdated     = "maintenance_updated"
+	TemplateMaintenanceCompleted   = "maintenance_completed"
+	TemplateMaintenanceAssigned    = "maintenance_assigned"
+	TemplateMaintenanceComment     = "maintenance_comment"
+	TemplateMaintenanceSLABreach   = "maintenance_sla_breach"
+	TemplatePreventiveMaintenance  = "preventive_maintenance_due"
+	TemplateInspectionReady        = "inspection_ready"
+	TemplateInspectionAcknowledged = "inspection_acknowledged"
+	TemplateNewMessage             = "new_message"
+)
+
+// templateCategories files each template's notifications under an inbox
+// category.
+var templateCategories = map[string]string{
+	TemplateRentDue:                CategoryBilling,
+	TemplatePaymentReceived:        CategoryBilling,
+	TemplateLeaseExpiring:          CategoryLease,
+	TemplateDocumentVerified:       CategoryDocuments,
+	TemplateMaintenanceUpdated:     CategoryMaintenance,
+	TemplateMaintenanceCompleted:   CategoryMaintenance,
+	TemplateMaintenanceAssigned:    CategoryMaintenance,
+	TemplateMaintenanceComment:     CategoryMaintenance,
+	TemplateMaintenanceSLABreach:   CategoryMaintenance,
+	TemplatePreventiveMaintenance:  CategoryMaintenance,
+	TemplateInspectionReady:        CategoryInspections,
+	TemplateInspectionAcknowledged: CategoryInspections,
+	TemplateNewMessage:             CategoryMessages,
+}
+
+// builtinTemplates are the English variants used until an admin stores a
+// customised or translated variant.
+var builtinTemplates = map[string]models.NotificationTemplate{
+	TemplateRentDue: {
+		Title:     "Rent due for {{.month}}",
+		Message:   "Your rent of {{.amount}} for {{.month}} is due on {{.due_date}}.",
+		Link:      "/invoices",
+		Variables: []string{"amount", "month", "due_date"},
+	},
+	TemplatePaymentReceived: {
+		Title:     "Payment received",
+		Message:   "We received your payment of {{.amount}} for {{.month}}. Receipt number: {{.receipt_number}}.",
+		Link:      "/payments/{{.payment_id}}",
+		Variables: []string{"amount", "month", "receipt_number", "payment_id"},
+	},
+	TemplateLeaseExpiring: {
+		Title:     "Your lease is expiring",
+		Message:   "Your lease ends on {{.lease_end}}. Please contact the house manager about renewing.",
+		Link:      "/tenants/me",
+		Variables: []string{"lease_end"},
+	},
+	TemplateDocumentVerified: {
+		Title:     "Document {{.status}}",
+		Message:   "Your {{.document_type}} has been {{.status}}.{{if .notes}} Notes: {{.notes}}{{end}}",
+		Link:      "/documents",
+		Variables: []string{"document_type", "status", "notes"},
+	},
+	TemplateMaintenanceUpdated: {
+		Title:     "Maintenance request #{{.request_id}} updated",
+		Message:   "Your {{.issue_type}} request is now {{.status}}.",
+		Link:      "/maintenance/{{.request_id}}",
+		Variables: []string{"request_id", "issue_type", "status"},
+	},
+	TemplateMaintenanceCompleted: {
+		Title:     "Maintenance request #{{.request_id}} completed",
+		Message:   "How did we do? Rate the fix and let us know if the problem is not solved.",
+		Link:      "/maintenance/{{.request_id}}/rating",
+		Variables: []string{"request_id"},
+	},
+	TemplateMaintenanceAssigned: {
+		Title:     "New maintenance request assigned",
+		Message:   "You have been assigned a {{.priority}} priority {{.issue_type}} request.",
+		Link:      "/maintenance/{{.request_id}}",
+		Variables: []string{"request_id", "priority", "issue_type"},
+	},
+	TemplateMaintenanceComment: {
+		Title:     "New comment on maintenance request #{{.request_id}}",
+		Message:   "{{.preview}}",
+		Link:      "/maintenance/{{.request_id}}",
+		Variables: []string{"request_id", "preview"},
+	},
+	TemplateMaintenanceSLABreach: {
+		Title:     "SLA breach on maintenance request #{{.request_id}}",
+		Message:   "The {{.breach_type}} time target for this {{.priority}} priority request at {{.house}} has been missed.",
+		Link:      "/maintenance/{{.request_id}}",
+		Variables: []string{"request_id", "breach_type", "priority", "house"},
+	},
+	TemplatePreventiveMaintenance: {
+		Title:     "Preventive maintenance scheduled",
+		Message:   "{{.plan}} is due on {{.due_date}} ({{.count}} request(s))",
+		Link:      "/maintenance",
+		Variables: []string{"plan", "due_date", "count"},
+	},
+	TemplateInspectionReady: {
+		Title:     "Inspection ready for sign-off",
+		Message:   "Your {{.inspection}} inspection is complete. Please review and acknowledge it.",
+		Link:      "/inspections/{{.inspection_id}}",
+		Variables: []string{"inspection", "inspection_id"},
+	},
+	TemplateInspectionAcknowledged: {
+		Title:     "Inspection {{.status}}",
+		Message:   "The tenant has {{.status}} the {{.inspection}} inspection.",
+		Link:      "/inspections/{{.inspection_id}}",
+		Variables: []string{"status", "inspection", "inspection_id"},
+	},
+	TemplateNewMessage: {
+		Title:     "New message: {{.subject}}",
+		Message:   "{{.preview}}",
+		Link:      "/messages/{{.conversation_id}}",
+		Variables: []string{"conversation_id", "subject", "preview"},
+	},
+}
+
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})?$`)
+
+// NotificationTemplateService renders system notifications from named
+// templates in each recipient's language.
+type NotificationTemplateService struct {
+	templateRepo     *repositories.NotificationTemplateRepository
+	notificationRepo *repositories.NotificationRepository
+	deliveryRepo     *repositories.NotificationDeliveryRepository
+}
+
+func NewNotificationTemplateService(templateRepo *repositories.NotificationTemplateRepository,
+	notificationRepo *repositories.NotificationRepository,
+	deliveryRepo *repositories.NotificationDeliveryRepository) *NotificationTemplateService {
+	return &NotificationTemplateService{
+		templateRepo:     templateRepo,
+		notificationRepo: notificationRepo,
+		deliveryRepo:     deliveryRepo,
+	}
+}
+
+// Send renders a template in the user's language and creates the
+// notification.
+func (s *NotificationTemplateService) Send(userID int, name string, vars map[string]interface{}) error {
+	prefs, err := s.deliveryRepo.GetPreferences(userID)
+	if err != nil {
+		return err
+	}
+	notification, err := s.Render(name, prefs.Locale, vars)
+	if err != nil {
+		return err
+	}
+	notification.UserID = userID
+	return s.notificationRepo.CreateNotification(notification)
+}
+
+// SendToTenant sends a template to a tenant's user account.
+func (s *NotificationTemplateService) SendToTenant(tenantID int, name string, vars map[string]interface{}) error {
+	userID, err := s.templateRepo.GetTenantUserID(tenantID)
+	if err != nil {
+		return err
+	}
+	return s.Send(userID, name, vars)
+}
+
+// Render renders the best variant of a template for locale without
+// creating a notification.
+func (s *NotificationTemplateService) Render(name, locale string, vars map[string]interface{}) (*models.Notification, error) {
+	tmpl, err := s.resolve(name, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	notification := &models.Notification{Category: templateCategories[name]}
+	for _, field := range []struct {
+		text string
+		out  *string
+	}{
+		{tmpl.Title, &notification.Title},
+		{tmpl.Message, &notification.Message},
+		{tmpl.Link, &notification.Link},
+	} {
+		rendered, err := renderTemplate(field.text, vars)
+		if err != nil {
+			return nil, err
+		}
+		*field.out = rendered
+	}
+	return notification, nil
+}
+
+// GetTemplates lists every template variant: the built-in English variants
+// and the stored variants, which replace built-in ones of the same locale.
+func (s *NotificationTemplateService) GetTemplates() ([]models.NotificationTemplate, error) {
+	stored, err := s.templateRepo.GetTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	customised := make(map[string]bool, len(stored))
+	templates := make([]models.NotificationTemplate, 0, len(stored)+len(builtinTemplates))
+	for _, tmpl := range stored {
+		tmpl.Variables = builtinTemplates[tmpl.Name].Variables
+		customised[tmpl.Name+"/"+tmpl.Locale] = true
+		templates = append(templates, tmpl)
+	}
+	for name, builtin := range builtinTemplates {
+		if customised[name+"/"+repositories.DefaultLocale] {
+			continue
+		}
+		builtin.Name = name
+		builtin.Locale = repositories.DefaultLocale
+		builtin.BuiltIn = true
+		templates = append(templates, builtin)
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Name != templates[j].Name {
+			return templates[i].Name < templates[j].Name
+		}
+		return templates[i].Locale < templates[j].Locale
+	})
+	return templates, nil
+}
+
+// SaveTemplate stores a customised or translated variant of a system
+// template.
+func (s *NotificationTemplateService) SaveTemplate(name, locale string, tmpl *models.NotificationTemplate, updatedBy int) error {
+	builtin, ok := builtinTemplates[name]
+	if !ok {
+		return ErrUnknownTemplate
+	}
+	locale, ok = normalizeLocale(locale)
+	if !ok {
+		return ErrInvalidLocale
+	}
+	if strings.TrimSpace(tmpl.Title) == "" || strings.TrimSpace(tmpl.Message) == "" {
+		return ErrInvalidTemplate
+	}
+	for _, text := range []string{tmpl.Title, tmpl.Message, tmpl.Link} {
+		if _, err := template.New(name).Parse(text); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+		}
+	}
+
+	tmpl.Name = name
+	tmpl.Locale = locale
+	tmpl.UpdatedBy = &updatedBy
+	if err := s.templateRepo.UpsertTemplate(tmpl); err != nil {
+		return err
+	}
+	tmpl.Variables = builtin.Variables
+	return nil
+}
+
+// DeleteTemplate removes a stored variant. Deleting the English variant of
+// a template restores the built-in text.
+func (s *NotificationTemplateService) DeleteTemplate(name, locale string) error {
+	if _, ok := builtinTemplates[name]; !ok {
+		return ErrUnknownTemplate
+	}
+	locale, ok := normalizeLocale(locale)
+	if !ok {
+		return ErrInvalidLocale
+	}
+	return s.templateRepo.DeleteTemplate(name, locale)
+}
+
+// resolve picks the variant for locale, falling back to the base language
+// (pt for pt-br), then to the default locale and finally to the built-in
+// text.
+func (s *NotificationTemplateService) resolve(name, locale string) (*models.NotificationTemplate, error) {
+	builtin, ok := builtinTemplates[name]
+	if !ok {
+		return nil, ErrUnknownTemplate
+	}
+
+	candidates := []string{}
+	if normalized, ok := normalizeLocale(locale); ok {
+		candidates = append(candidates, normalized)
+		if base, _, found := strings.Cut(normalized, "-"); found {
+			candidates = append(candidates, base)
+		}
+	}
+	candidates = append(candidates, repositories.DefaultLocale)
+
+	for _, candidate := range candidates {
+		tmpl, err := s.templateRepo.GetTemplate(name, candidate)
+		if err == nil {
+			return tmpl, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	}
+
+	builtin.Name = name
+	builtin.Locale = repositories.DefaultLocale
+	builtin.BuiltIn = true
+	return &builtin, nil
+}
+
+func renderTemplate(text string, vars map[string]interface{}) (string, error) {
+	tmpl, err := template.New("notification").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrTemplateVariable, err)
+	}
+	return buf.String(), nil
+}
+
+// normalizeLocale lower-cases a locale and uses a hyphen as separator, so
+// pt_BR and pt-BR are both stored as pt-br.
+func normalizeLocale(locale string) (string, bool) {
+	locale = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(locale)), "_", "-")
+	return locale, localePattern.MatchString(locale)
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
+)
+
+var (
+	ErrInvalidManagementFee = errors.New("management fee must be between 0 and 100 percent")
+	ErrInvalidPayoutAmount  = errors.New("payout amount must be greater than zero")
+)
+
+type OwnerService struct {
+	ownerRepo  *repositories.OwnerRepository
+	reportRepo *repositories.ReportRepository
+}
+
+func NewOwnerService(ownerRepo *repositories.OwnerRepository, reportRepo *repositories.ReportRepository) *OwnerService {
+	return &OwnerService{ownerRepo: ownerRepo, reportRepo: reportRepo}
+}
+
+func (s *OwnerService) CreateOwner(owner *models.Owner) error {
+	if owner.ManagementFeePercent < 0 || owner.ManagementFeePercent > 100 {
+		return ErrInvalidManagementFee
+	}
+	return s.ownerRepo.CreateOwner(owner)
+}
+
+func (s *OwnerService) GetOwner(id string) (*models.Owner, error) {
+	ownerID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.ownerRepo.GetOwner(ownerID)
+}
+
+func (s *OwnerService) GetAllOwners() ([]models.Owner, error) {
+	return s.ownerRepo.GetAllOwners()
+}
+
+func (s *OwnerService) UpdateOwner(id string, owner *models.Owner) error {
+	ownerID, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	if owner.ManagementFeePercent < 0 || owner.ManagementFeePercent > 100 {
+		return ErrInvalidManagementFee
+	}
+	return s.ownerRepo.UpdateOwner(ownerID, owner)
+}
+
+func (s *OwnerService) AssignHouse(ownerId string, houseId int) error {
+	ownerID, err := strconv.Atoi(ownerId)
+	if err != nil {
+		return err
+	}
+	return s.ownerRepo.AssignHouse(ownerID, houseId)
+}
+
+func (s *OwnerService) UnassignHouse(ownerId, houseId string) error {
+	ownerID, err := strconv.Atoi(ownerId)
+	if err != nil {
+		return err
+	}
+	houseID, err := strconv.Atoi(houseId)
+	if err != nil {
+		return err
+	}
+	return s.ownerRepo.UnassignHouse(ownerID, houseID)
+}
+
+// GenerateStatement computes an owner's statement for a YYYY-MM month from
+// rent collected, expenses and maintenance costs of each of their houses.
+// The management fee is charged on income collected.
+func (s *OwnerService) GenerateStatement(ownerId, month string) (*models.OwnerStatement, error) {
+	owner, err := s.GetOwner(ownerId)
+	if err != nil {
+		return nil, err
+	}
+	period, err := time.ParseInLocation("2006-01", month, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	end := period.AddDate(0, 1, 0)
+	key := period.Format("2006-01")
+
+	statement := &models.OwnerStatement{
+		OwnerID:     owner.ID,
+		PeriodMonth: period,
+		Houses:      []models.OwnerStatementLine{},
+		Payouts:     []models.OwnerPayout{},
+	}
+	for _, houseID := range owner.HouseIDs {
+		houses, err := s.reportRepo.GetHouses(houseID)
+		if err != nil {
+			return nil, err
+		}
+		if len(houses) == 0 {
+			continue
+		}
+
+		income, err := s.reportRepo.GetMonthlyRentIncome(houseID, period, end)
+		if err != nil {
+			return nil, err
+		}
+		expenses, err := s.reportRepo.GetMonthlyExpenses(houseID, period, end)
+		if err != nil {
+			return nil, err
+		}
+		maintenance, err := s.reportRepo.GetMonthlyMaintenanceCost(houseID, period, end)
+		if err != nil {
+			return nil, err
+		}
+
+		line := models.OwnerStatementLine{
+			HouseID:         houseID,
+			HouseName:       houses[0].Name,
+			Income:          income[key],
+			MaintenanceCost: maintenance[key],
+		}
+		for _, amount := range expenses[key] {
+			line.Expenses += amount
+		}
+
+		statement.Houses = append(statement.Houses, line)
+		statement.Income += line.Income
+		statement.Expenses += line.Expenses
+		statement.MaintenanceCost += line.MaintenanceCost
+	}
+
+	statement.ManagementFee = roundCents(statement.Income * owner.ManagementFeePercent / 100)
+	statement.NetPayout = roundCents(statement.Income - statement.Expenses -
+		statement.MaintenanceCost - statement.ManagementFee)
+
+	if err := s.ownerRepo.SaveStatement(statement); err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
+
+func (s *OwnerService) GetStatement(id string) (*models.OwnerStatement, error) {
+	statementID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.ownerRepo.GetStatement(statementID)
+}
+
+func (s *OwnerService) GetStatementsByOwner(ownerId string) ([]models.OwnerStatement, error) {
+	ownerID, err := strconv.Atoi(ownerId)
+	if err != nil {
+		return nil, err
+	}
+	return s.ownerRepo.GetStatementsByOwner(ownerID)
+}
+
+// RecordPayout records money paid to the owner against a statement, up to
+// the statement's unpaid balance.
+func (s *OwnerService) RecordPayout(statementId string, payout *models.OwnerPayout) error {
+	statementID, err := strconv.Atoi(statementId)
+	if err != nil {
+		return err
+	}
+	if payout.Amount <= 0 {
+		return ErrInvalidPayoutAmount
+	}
+
+	payout.StatementID = statementID
+	return s.ownerRepo.CreatePayout(payout)
+}
+
+// StatementPDF renders an owner statement for sending to the investor.
+func (s *OwnerService) StatementPDF(owner *models.Owner, statement *models.OwnerStatement) []byte {
+	widths := []int{24, 14, 14, 14}
+	doc := utils.NewPDFDocument(fmt.Sprintf("Owner Statement - %s - %s",
+		owner.Name, statement.PeriodMonth.Format("January 2006")))
+
+	doc.AddRow(widths, "House", "Income", "Expenses", "Maintenance")
+	for _, line := range statement.Houses {
+		doc.AddRow(widths, line.HouseName, formatAmount(line.Income),
+			formatAmount(line.Expenses), formatAmount(line.MaintenanceCost))
+	}
+	doc.AddLine("")
+	doc.AddRow(widths, "Income collected", formatAmount(statement.Income))
+	doc.AddRow(widths, "Expenses", formatAmount(-statement.Expenses))
+	doc.AddRow(widths, "Maintenance", formatAmount(-statement.MaintenanceCost))
+	doc.AddRow(widths, fmt.Sprintf("Management fee (%.2f%%)", owner.ManagementFeePercent),
+		formatAmount(-statement.ManagementFee))
+	doc.AddRow(widths, "Net payout", formatAmount(statement.NetPayout))
+
+	if len(statement.Payouts) > 0 {
+		doc.AddLine("")
+		doc.AddLine("Payouts")
+		for _, payout := range statement.Payouts {
+			doc.AddRow(widths, payout.PaidAt.Format("2006-01-02"), formatAmount(payout.Amount),
+				payout.Method, payout.Reference)
+		}
+	}
+
+	return doc.Bytes()
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
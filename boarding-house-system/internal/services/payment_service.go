@@ -1,12 +1,18 @@
 package services
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/Kimox23/boarding-house-app/internal/models"
 	"github.com/Kimox23/boarding-house-app/internal/repositories"
 )
 
+var (
+	ErrReversalReasonRequired = errors.New("a reason is required to reverse a payment")
+	ErrInvalidRefundAmount    = errors.New("refund amount must be greater than zero")
+)
+
 type PaymentService struct {
 	paymentRepo *repositories.PaymentRepository
 }
@@ -19,12 +25,25 @@ func (s *PaymentService) CreatePayment(payment *models.Payment) error {
 	return s.paymentRepo.CreatePayment(payment)
 }
 
+// GetPayment returns a payment together with any void or refund entries
+// recorded against it.
 func (s *PaymentService) GetPayment(id string) (*models.Payment, error) {
 	paymentID, err := strconv.Atoi(id)
 	if err != nil {
 		return nil, err
 	}
-	return s.paymentRepo.GetPayment(paymentID)
+
+	payment, err := s.paymentRepo.GetPayment(paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	reversals, err := s.paymentRepo.GetReversals(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	payment.Reversals = reversals
+	return payment, nil
 }
 
 func (s *PaymentService) GetPaymentsByTenant(tenantId string) ([]models.Payment, error) {
@@ -35,18 +54,40 @@ func (s *PaymentService) GetPaymentsByTenant(tenantId string) ([]models.Payment,
 	return s.paymentRepo.GetPaymentsByTenant(tenantID)
 }
 
-func (s *PaymentService) UpdatePayment(id string, payment *models.Payment) error {
-	paymentID, err := strconv.Atoi(id)
-	if err != nil {
-		return err
+// VoidPayment cancels a payment in full by recording a linked reversing entry.
+func (s *PaymentService) VoidPayment(id string, reason string, recordedBy int) (*models.Payment, error) {
+	return s.reverse(id, &models.Payment{
+		EntryType:  "void",
+		Reason:     reason,
+		RecordedBy: recordedBy,
+	})
+}
+
+// RefundPayment returns part or all of a payment by recording a linked
+// reversing entry for the refunded amount.
+func (s *PaymentService) RefundPayment(id string, amount float64, reason string, recordedBy int) (*models.Payment, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidRefundAmount
 	}
-	return s.paymentRepo.UpdatePayment(paymentID, payment)
+	return s.reverse(id, &models.Payment{
+		EntryType:  "refund",
+		Amount:     amount,
+		Reason:     reason,
+		RecordedBy: recordedBy,
+	})
 }
 
-func (s *PaymentService) DeletePayment(id string) error {
+func (s *PaymentService) reverse(id string, reversal *models.Payment) (*models.Payment, error) {
 	paymentID, err := strconv.Atoi(id)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if reversal.Reason == "" {
+		return nil, ErrReversalReasonRequired
+	}
+
+	if err := s.paymentRepo.CreateReversal(paymentID, reversal); err != nil {
+		return nil, err
 	}
-	return s.paymentRepo.DeletePayment(paymentID)
+	return reversal, nil
 }
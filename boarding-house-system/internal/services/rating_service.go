@@ -0,0 +1,167 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+)
+
+var (
+	ErrInvalidRatingScore = errors.New("rating score must be between 1 and 5")
+	ErrRatingNotAllowed   = errors.New("only the tenant who reported the request can rate it")
+	ErrRequestNotComplete = errors.New("only completed requests can be rated")
+)
+
+type RatingService struct {
+	ratingRepo         *repositories.RatingRepository
+	maintenanceService *MaintenanceService
+}
+
+func NewRatingService(ratingRepo *repositories.RatingRepository, maintenanceService *MaintenanceService) *RatingService {
+	return &RatingService{ratingRepo: ratingRepo, maintenanceService: maintenanceService}
+}
+
+// Rate records the reporter's rating of a completed request. When reopen is
+// set and the request is still within its reopen window, the request goes
+// back to pending with the feedback as the timeline note.
+func (s *RatingService) Rate(requestId string, userID int, rating *models.MaintenanceRating, reopen bool) (*models.MaintenanceRequest, error) {
+	request, err := s.maintenanceService.request(requestId)
+	if err != nil {
+		return nil, err
+	}
+	if request.ReportedBy != userID {
+		return nil, ErrRatingNotAllowed
+	}
+	if request.Status != "completed" || request.CompletedDate == nil {
+		return nil, ErrRequestNotComplete
+	}
+	if rating.Score < 1 || rating.Score > 5 {
+		return nil, ErrInvalidRatingScore
+	}
+	if reopen {
+		if err := s.maintenanceService.checkTransition(request, "pending"); err != nil {
+			return nil, err
+		}
+	}
+
+	rating.RequestID = request.ID
+	rating.RatedBy = userID
+	rating.Feedback = strings.TrimSpace(rating.Feedback)
+	rating.Reopened = reopen
+	rating.CompletedDate = *request.CompletedDate
+
+	var note string
+	if reopen {
+		note = "reopened by tenant"
+		if rating.Feedback != "" {
+			note += ": " + rating.Feedback
+		}
+	}
+	if err := s.ratingRepo.CreateRating(rating, note); err != nil {
+		return nil, err
+	}
+
+	if !reopen {
+		return request, nil
+	}
+	return s.maintenanceService.statusChanged(request)
+}
+
+// GetRatings returns a request's ratings to its reporter or to staff.
+func (s *RatingService) GetRatings(requestId string, userID int, role string) ([]models.MaintenanceRating, error) {
+	request, err := s.maintenanceService.request(requestId)
+	if err != nil {
+		return nil, err
+	}
+	if !isStaffRole(role) && request.ReportedBy != userID {
+		return nil, ErrRatingNotAllowed
+	}
+	return s.ratingRepo.GetRatings(request.ID)
+}
+
+// Report aggregates ratings given in a YYYY-MM range overall and by house,
+// issue type and assignee.
+func (s *RatingService) Report(filter AnalyticsFilter) (*models.SatisfactionReport, error) {
+	houseID, start, end, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.ratingRepo.GetRatingsInRange(houseID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	overall := newSatisfactionAccumulator()
+	byHouse := newSatisfactionAccumulator()
+	byIssueType := newSatisfactionAccumulator()
+	byAssignee := newSatisfactionAccumulator()
+	for _, row := range rows {
+		overall.add("all", "All requests", row)
+		byHouse.add(strconv.Itoa(row.HouseID), row.HouseName, row)
+		issueType := strings.ToLower(strings.TrimSpace(row.IssueType))
+		byIssueType.add(issueType, issueType, row)
+		if row.AssignedTo != 0 {
+			byAssignee.add(strconv.Itoa(row.AssignedTo), row.AssigneeName, row)
+		}
+	}
+
+	report := &models.SatisfactionReport{
+		From:        start.Format("2006-01"),
+		To:          end.AddDate(0, -1, 0).Format("2006-01"),
+		Overall:     models.SatisfactionScore{Key: "all", Name: "All requests"},
+		ByHouse:     byHouse.results(),
+		ByIssueType: byIssueType.results(),
+		ByAssignee:  byAssignee.results(),
+	}
+	if results := overall.results(); len(results) > 0 {
+		report.Overall = results[0]
+	}
+	return report, nil
+}
+
+type satisfactionAccumulator struct {
+	scores map[string]*models.SatisfactionScore
+	totals map[string]int
+}
+
+func newSatisfactionAccumulator() *satisfactionAccumulator {
+	return &satisfactionAccumulator{
+		scores: make(map[string]*models.SatisfactionScore),
+		totals: make(map[string]int),
+	}
+}
+
+func (a *satisfactionAccumulator) add(key, name string, row repositories.RatingRow) {
+	score := a.scores[key]
+	if score == nil {
+		score = &models.SatisfactionScore{Key: key, Name: name}
+		a.scores[key] = score
+	}
+	score.Ratings++
+	a.totals[key] += row.Score
+	if row.Reopened {
+		score.Reopened++
+	}
+}
+
+// results returns the groups with their averages, lowest average first so
+// that the weakest areas stand out.
+func (a *satisfactionAccumulator) results() []models.SatisfactionScore {
+	results := make([]models.SatisfactionScore, 0, len(a.scores))
+	for key, score := range a.scores {
+		score.AverageScore = roundCents(float64(a.totals[key]) / float64(score.Ratings))
+		score.ReopenRate = ratio(float64(score.Reopened), float64(score.Ratings))
+		results = append(results, *score)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].AverageScore != results[j].AverageScore {
+			return results[i].AverageScore < results[j].AverageScore
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"log"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/realtime"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+)
+
+// RealtimeService pushes notification and maintenance changes to connected
+// clients through the hub.
+type RealtimeService struct {
+	hub              *realtime.Hub
+	notificationRepo *repositories.NotificationRepository
+}
+
+// NewRealtimeService subscribes the hub to notification changes and to
+// maintenance status changes.
+func NewRealtimeService(hub *realtime.Hub, notificationRepo *repositories.NotificationRepository,
+	maintenanceService *MaintenanceService) *RealtimeService {
+	s := &RealtimeService{hub: hub, notificationRepo: notificationRepo}
+	notificationRepo.Listen(s.notificationChanged)
+	maintenanceService.OnStatusChange(s.maintenanceChanged)
+	return s
+}
+
+// Subscribe connects a client and returns the subscription with the user's
+// current unread count, so the client can render it before the first event.
+func (s *RealtimeService) Subscribe(userID int) (*realtime.Subscription, int, error) {
+	unread, err := s.notificationRepo.CountUnread(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.hub.Subscribe(userID), unread, nil
+}
+
+func (s *RealtimeService) notificationChanged(userID int, notification *models.Notification) {
+	if notification != nil {
+		s.hub.Publish(userID, realtime.EventNotification, notification)
+	}
+	unread, err := s.notificationRepo.CountUnread(userID)
+	if err != nil {
+		log.Printf("realtime: failed to count unread notifications for user %d: %v", userID, err)
+		return
+	}
+	s.hub.Publish(userID, realtime.EventUnreadCount, map[string]int{"unread": unread})
+}
+
+// maintenanceChanged tells the reporter and the assignee of a request about
+// its new status.
+func (s *RealtimeService) maintenanceChanged(request *models.MaintenanceRequest) {
+	update := map[string]interface{}{
+		"request_id":  request.ID,
+		"status":      request.Status,
+		"assigned_to": request.AssignedTo,
+	}
+	s.hub.Publish(request.ReportedBy, realtime.EventMaintenanceStatus, update)
+	if request.AssignedTo != nil && *request.AssignedTo != request.ReportedBy {
+		s.hub.Publish(*request.AssignedTo, realtime.EventMaintenanceStatus, update)
+	}
+}
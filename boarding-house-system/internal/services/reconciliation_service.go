@@ -0,0 +1,208 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
+)
+
+var (
+	ErrUnsupportedStatementFormat = errors.New("statement format must be csv or ofx")
+	ErrNoMatchesToConfirm         = errors.New("no statement lines selected for confirmation")
+	ErrLineNotMatched             = errors.New("statement line has no tenant to confirm against")
+)
+
+// Matches below minimumMatchScore are discarded; matches at or above
+// suggestThreshold are proposed to staff for confirmation.
+const (
+	minimumMatchScore = 0.3
+	suggestThreshold  = 0.5
+)
+
+var tenantReference = regexp.MustCompile(`(?i)\b(?:tenant|tnt|t)[-#: ]?(\d+)\b`)
+
+// LineConfirmation selects a statement line to turn into a payment. When
+// TenantID is zero the suggested match is used.
+type LineConfirmation struct {
+	LineID   int `json:"line_id"`
+	TenantID int `json:"tenant_id"`
+}
+
+type ReconciliationService struct {
+	reconciliationRepo *repositories.ReconciliationRepository
+}
+
+func NewReconciliationService(reconciliationRepo *repositories.ReconciliationRepository) *ReconciliationService {
+	return &ReconciliationService{reconciliationRepo: reconciliationRepo}
+}
+
+// ImportStatement parses a bank statement, scores every incoming transfer
+// against active tenants and stores the result for review.
+func (s *ReconciliationService) ImportStatement(fileName, format string, file io.Reader,
+	mapping utils.CSVColumnMapping, uploadedBy int) (*models.BankStatementImport, error) {
+	var parsed []utils.StatementLine
+	var err error
+	switch format {
+	case "csv":
+		parsed, err = utils.ParseCSVStatement(file, mapping)
+	case "ofx":
+		parsed, err = utils.ParseOFXStatement(file)
+	default:
+		return nil, ErrUnsupportedStatementFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.reconciliationRepo.GetMatchCandidates()
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &models.BankStatementImport{
+		FileName:   fileName,
+		Format:     format,
+		UploadedBy: uploadedBy,
+	}
+	for _, p := range parsed {
+		line := models.BankStatementLine{
+			TransactionDate: p.Date,
+			Amount:          p.Amount,
+			Reference:       p.Reference,
+			Description:     p.Description,
+			Status:          "unmatched",
+		}
+		if p.Amount > 0 {
+			if tenantID, confidence := bestMatch(p, candidates); tenantID != 0 {
+				line.MatchedTenantID = &tenantID
+				line.Confidence = confidence
+				if confidence >= suggestThreshold {
+					line.Status = "suggested"
+				}
+			}
+		}
+		statement.Lines = append(statement.Lines, line)
+	}
+
+	if err := s.reconciliationRepo.CreateImport(statement); err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
+
+func (s *ReconciliationService) GetImport(id string) (*models.BankStatementImport, error) {
+	importID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.reconciliationRepo.GetImport(importID)
+}
+
+func (s *ReconciliationService) IgnoreLine(importId, lineId string) error {
+	importID, err := strconv.Atoi(importId)
+	if err != nil {
+		return err
+	}
+	lineID, err := strconv.Atoi(lineId)
+	if err != nil {
+		return err
+	}
+	return s.reconciliationRepo.IgnoreLine(importID, lineID)
+}
+
+// ConfirmMatches records a payment for each selected line in one batch.
+func (s *ReconciliationService) ConfirmMatches(importId string, confirmations []LineConfirmation,
+	recordedBy int) ([]models.Payment, error) {
+	importID, err := strconv.Atoi(importId)
+	if err != nil {
+		return nil, err
+	}
+	if len(confirmations) == 0 {
+		return nil, ErrNoMatchesToConfirm
+	}
+
+	lines, err := s.reconciliationRepo.GetImportLines(importID)
+	if err != nil {
+		return nil, err
+	}
+	suggested := make(map[int]*int, len(lines))
+	for _, line := range lines {
+		suggested[line.ID] = line.MatchedTenantID
+	}
+
+	matches := make(map[int]int, len(confirmations))
+	for _, c := range confirmations {
+		tenantID := c.TenantID
+		if tenantID == 0 {
+			match, ok := suggested[c.LineID]
+			if !ok || match == nil {
+				return nil, fmt.Errorf("line %d: %w", c.LineID, ErrLineNotMatched)
+			}
+			tenantID = *match
+		}
+		matches[c.LineID] = tenantID
+	}
+
+	return s.reconciliationRepo.ConfirmMatches(importID, matches, recordedBy)
+}
+
+// bestMatch scores a transfer against every candidate using the payment
+// reference, the amount compared to the room rent and the day of month
+// compared to the tenant's move-in anniversary.
+func bestMatch(line utils.StatementLine, candidates []repositories.MatchCandidate) (int, float64) {
+	text := strings.ToLower(line.Reference + " " + line.Description)
+
+	referencedTenant := 0
+	if m := tenantReference.FindStringSubmatch(text); m != nil {
+		referencedTenant, _ = strconv.Atoi(m[1])
+	}
+
+	bestID, bestScore := 0, 0.0
+	for _, c := range candidates {
+		score := 0.0
+
+		switch {
+		case c.TenantID == referencedTenant:
+			score += 0.5
+		case c.FullName != "" && strings.Contains(text, strings.ToLower(c.FullName)):
+			score += 0.35
+		case c.Username != "" && strings.Contains(text, strings.ToLower(c.Username)):
+			score += 0.25
+		}
+
+		if c.PricePerMonth > 0 {
+			diff := math.Abs(line.Amount-c.PricePerMonth) / c.PricePerMonth
+			switch {
+			case diff < 0.005:
+				score += 0.3
+			case diff <= 0.05:
+				score += 0.15
+			}
+		}
+
+		dayDiff := line.Date.Day() - c.MoveInDate.Day()
+		if dayDiff < 0 {
+			dayDiff = -dayDiff
+		}
+		if dayDiff <= 5 {
+			score += 0.2
+		}
+
+		if score > bestScore {
+			bestID, bestScore = c.TenantID, score
+		}
+	}
+
+	if bestScore < minimumMatchScore {
+		return 0, 0
+	}
+	return bestID, math.Min(math.Round(bestScore*100)/100, 1)
+}
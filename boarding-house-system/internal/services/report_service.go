@@ -0,0 +1,305 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+	"github.com/Kimox23/boarding-house-app/internal/utils"
+)
+
+var ErrInvalidDateRange = errors.New("end of range is before its start")
+
+type ReportService struct {
+	reportRepo *repositories.ReportRepository
+}
+
+func NewReportService(reportRepo *repositories.ReportRepository) *ReportService {
+	return &ReportService{reportRepo: reportRepo}
+}
+
+// AgingReport buckets each tenant's unpaid invoices by days past due as of
+// the given date (YYYY-MM-DD, defaulting to today). Payments are applied to
+// the oldest invoices first. An empty houseId covers every house.
+func (s *ReportService) AgingReport(asOf string, houseId string) (*models.AgingReport, error) {
+	date := time.Now()
+	if asOf != "" {
+		parsed, err := time.Parse("2006-01-02", asOf)
+		if err != nil {
+			return nil, err
+		}
+		date = parsed
+	}
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+
+	houseID := 0
+	if houseId != "" {
+		parsed, err := strconv.Atoi(houseId)
+		if err != nil {
+			return nil, err
+		}
+		houseID = parsed
+	}
+
+	invoices, err := s.reportRepo.GetReceivableInvoices(date, houseID)
+	if err != nil {
+		return nil, err
+	}
+	paid, err := s.reportRepo.GetPaidTotals(date)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.AgingReport{AsOf: date, Houses: []models.HouseAging{}}
+	var house *models.HouseAging
+	var room *models.RoomAging
+	var tenant *models.TenantAging
+
+	for _, invoice := range invoices {
+		if house == nil || house.HouseID != invoice.HouseID {
+			report.Houses = append(report.Houses, models.HouseAging{
+				HouseID:   invoice.HouseID,
+				HouseName: invoice.HouseName,
+			})
+			house = &report.Houses[len(report.Houses)-1]
+			room = nil
+		}
+		if room == nil || room.RoomID != invoice.RoomID {
+			house.Rooms = append(house.Rooms, models.RoomAging{
+				RoomID:     invoice.RoomID,
+				RoomNumber: invoice.RoomNumber,
+			})
+			room = &house.Rooms[len(house.Rooms)-1]
+			tenant = nil
+		}
+		if tenant == nil || tenant.TenantID != invoice.TenantID {
+			room.Tenants = append(room.Tenants, models.TenantAging{
+				TenantID: invoice.TenantID,
+				Username: invoice.Username,
+			})
+			tenant = &room.Tenants[len(room.Tenants)-1]
+		}
+
+		// Apply the tenant's remaining payments to this invoice before
+		// treating any of it as outstanding.
+		applied := math.Min(paid[invoice.TenantID], invoice.Amount)
+		if applied < 0 {
+			applied = 0
+		}
+		paid[invoice.TenantID] -= applied
+		outstanding := invoice.Amount - applied
+		if outstanding < 0.005 {
+			continue
+		}
+
+		daysPastDue := int(date.Sub(invoice.DueDate).Hours() / 24)
+		addToBucket(&tenant.Balances, daysPastDue, outstanding)
+		addToBucket(&room.Totals, daysPastDue, outstanding)
+		addToBucket(&house.Totals, daysPastDue, outstanding)
+		addToBucket(&report.Totals, daysPastDue, outstanding)
+	}
+
+	return report, nil
+}
+
+func addToBucket(b *models.AgingBuckets, daysPastDue int, amount float64) {
+	switch {
+	case daysPastDue <= 0:
+		b.Current += amount
+	case daysPastDue <= 30:
+		b.Days1To30 += amount
+	case daysPastDue <= 60:
+		b.Days31To60 += amount
+	case daysPastDue <= 90:
+		b.Days61To90 += amount
+	default:
+		b.Over90 += amount
+	}
+	b.Total += amount
+}
+
+var agingHeader = []string{"House", "Room", "Tenant", "Current", "1-30", "31-60", "61-90", "90+", "Total"}
+
+func agingColumns(b models.AgingBuckets) []string {
+	return []string{
+		formatAmount(b.Current), formatAmount(b.Days1To30), formatAmount(b.Days31To60),
+		formatAmount(b.Days61To90), formatAmount(b.Over90), formatAmount(b.Total),
+	}
+}
+
+// AgingReportCSV renders one row per tenant followed by room, house and
+// grand totals.
+func (s *ReportService) AgingReportCSV(report *models.AgingReport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(agingHeader); err != nil {
+		return nil, err
+	}
+	for _, house := range report.Houses {
+		for _, room := range house.Rooms {
+			for _, tenant := range room.Tenants {
+				w.Write(append([]string{house.HouseName, room.RoomNumber, tenant.Username},
+					agingColumns(tenant.Balances)...))
+			}
+			w.Write(append([]string{house.HouseName, room.RoomNumber, "Room total"},
+				agingColumns(room.Totals)...))
+		}
+		w.Write(append([]string{house.HouseName, "", "House total"}, agingColumns(house.Totals)...))
+	}
+	w.Write(append([]string{"", "", "Grand total"}, agingColumns(report.Totals)...))
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// AgingReportPDF renders the report as a printable table.
+func (s *ReportService) AgingReportPDF(report *models.AgingReport) []byte {
+	widths := []int{8, 16, 10, 10, 10, 10, 10, 11}
+	doc := utils.NewPDFDocument("Accounts Receivable Aging as of " + report.AsOf.Format("2006-01-02"))
+
+	for _, house := range report.Houses {
+		doc.AddLine(house.HouseName)
+		doc.AddRow(widths, agingHeader[1:]...)
+		for _, room := range house.Rooms {
+			for _, tenant := range room.Tenants {
+				doc.AddRow(widths, append([]string{room.RoomNumber, tenant.Username},
+					agingColumns(tenant.Balances)...)...)
+			}
+			doc.AddRow(widths, append([]string{room.RoomNumber, "Room total"},
+				agingColumns(room.Totals)...)...)
+		}
+		doc.AddRow(widths, append([]string{"", "House total"}, agingColumns(house.Totals)...)...)
+		doc.AddLine("")
+	}
+	doc.AddRow(widths, append([]string{"", "Grand total"}, agingColumns(report.Totals)...)...)
+
+	return doc.Bytes()
+}
+
+func formatAmount(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+// ParseMonthRange converts inclusive YYYY-MM bounds into a half-open date
+// range. Missing bounds default to the last twelve months.
+func ParseMonthRange(from, to string) (time.Time, time.Time, error) {
+	now := time.Now()
+	end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	if to != "" {
+		parsed, err := time.ParseInLocation("2006-01", to, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		end = parsed
+	}
+	start := end.AddDate(0, -11, 0)
+	if from != "" {
+		parsed, err := time.ParseInLocation("2006-01", from, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		start = parsed
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, ErrInvalidDateRange
+	}
+	return start, end.AddDate(0, 1, 0), nil
+}
+
+// ProfitAndLoss combines rent income with operating expenses and maintenance
+// costs for each month in the range, per house. An empty houseId reports on
+// every house.
+func (s *ReportService) ProfitAndLoss(houseId, from, to string) ([]models.ProfitAndLossReport, error) {
+	houseID := 0
+	if houseId != "" {
+		parsed, err := strconv.Atoi(houseId)
+		if err != nil {
+			return nil, err
+		}
+		houseID = parsed
+	}
+	start, end, err := ParseMonthRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	houses, err := s.reportRepo.GetHouses(houseID)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]models.ProfitAndLossReport, 0, len(houses))
+	for _, house := range houses {
+		income, err := s.reportRepo.GetMonthlyRentIncome(house.ID, start, end)
+		if err != nil {
+			return nil, err
+		}
+		expenses, err := s.reportRepo.GetMonthlyExpenses(house.ID, start, end)
+		if err != nil {
+			return nil, err
+		}
+		maintenance, err := s.reportRepo.GetMonthlyMaintenanceCost(house.ID, start, end)
+		if err != nil {
+			return nil, err
+		}
+
+		report := models.ProfitAndLossReport{
+			HouseID:   house.ID,
+			HouseName: house.Name,
+			From:      start.Format("2006-01"),
+			To:        end.AddDate(0, -1, 0).Format("2006-01"),
+			Totals:    models.ProfitAndLossMonth{Month: "total", Expenses: map[string]float64{}},
+		}
+		for month := start; month.Before(end); month = month.AddDate(0, 1, 0) {
+			key := month.Format("2006-01")
+			row := models.ProfitAndLossMonth{
+				Month:           key,
+				RentIncome:      income[key],
+				Expenses:        map[string]float64{},
+				MaintenanceCost: maintenance[key],
+			}
+			for category, amount := range expenses[key] {
+				row.Expenses[category] = amount
+				row.TotalExpenses += amount
+				report.Totals.Expenses[category] += amount
+			}
+			row.TotalExpenses += row.MaintenanceCost
+			row.NetIncome = row.RentIncome - row.TotalExpenses
+
+			report.Totals.RentIncome += row.RentIncome
+			report.Totals.MaintenanceCost += row.MaintenanceCost
+			report.Totals.TotalExpenses += row.TotalExpenses
+			report.Totals.NetIncome += row.NetIncome
+			report.Months = append(report.Months, row)
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// ProfitAndLossPDF renders one section per house with a row per month.
+func (s *ReportService) ProfitAndLossPDF(reports []models.ProfitAndLossReport) []byte {
+	widths := []int{8, 12, 12, 12, 12, 12}
+	doc := utils.NewPDFDocument("Profit and Loss")
+
+	for _, report := range reports {
+		doc.AddLine(fmt.Sprintf("%s (%s to %s)", report.HouseName, report.From, report.To))
+		doc.AddRow(widths, "Month", "Income", "Expenses", "Maintenance", "Total cost", "Net")
+		for _, row := range append(report.Months, report.Totals) {
+			doc.AddRow(widths, row.Month, formatAmount(row.RentIncome),
+				formatAmount(row.TotalExpenses-row.MaintenanceCost), formatAmount(row.MaintenanceCost),
+				formatAmount(row.TotalExpenses), formatAmount(row.NetIncome))
+		}
+		doc.AddLine("")
+	}
+
+	return doc.Bytes()
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+)
+
+var (
+	ErrInvalidReservation      = errors.New("reservation requires a room, guest name and move-in date")
+	ErrInvalidReservationDates = errors.New("expected move-out must be after move-in")
+	ErrReservationTransition   = errors.New("reservation cannot change to the requested status")
+)
+
+type ReservationService struct {
+	repo *repositories.ReservationRepository
+}
+
+func NewReservationService(repo *repositories.ReservationRepository) *ReservationService {
+	return &ReservationService{repo: repo}
+}
+
+func (s *ReservationService) CreateReservation(reservation *models.Reservation) error {
+	if reservation.RoomID == 0 || reservation.GuestName == "" || reservation.ExpectedMoveIn.IsZero() {
+		return ErrInvalidReservation
+	}
+	if reservation.ExpectedMoveOut != nil && !reservation.ExpectedMoveOut.After(reservation.ExpectedMoveIn) {
+		return ErrInvalidReservationDates
+	}
+	reservation.Status = "pending"
+	return s.repo.CreateReservation(reservation)
+}
+
+func (s *ReservationService) GetReservation(id string) (*models.Reservation, error) {
+	reservationID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetReservation(reservationID)
+}
+
+func (s *ReservationService) GetReservationsByHouse(houseId, status string) ([]models.Reservation, error) {
+	houseID, err := strconv.Atoi(houseId)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetReservationsByHouse(houseID, status)
+}
+
+// ConfirmReservation marks a pending reservation as confirmed so that it
+// counts towards the occupancy forecast.
+func (s *ReservationService) ConfirmReservation(id string) (*models.Reservation, error) {
+	return s.transition(id, "confirmed", "pending")
+}
+
+// CancelReservation cancels a pending or confirmed reservation.
+func (s *ReservationService) CancelReservation(id string) (*models.Reservation, error) {
+	return s.transition(id, "cancelled", "pending", "confirmed")
+}
+
+// ConvertReservation records that the guest moved in as a tenant.
+func (s *ReservationService) ConvertReservation(id string) (*models.Reservation, error) {
+	return s.transition(id, "converted", "confirmed")
+}
+
+func (s *ReservationService) transition(id, status string, from ...string) (*models.Reservation, error) {
+	reservation, err := s.GetReservation(id)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := false
+	for _, f := range from {
+		if reservation.Status == f {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return nil, ErrReservationTransition
+	}
+
+	if err := s.repo.UpdateReservationStatus(reservation.ID, status); err != nil {
+		return nil, err
+	}
+	reservation.Status = status
+	return reservation, nil
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+)
+
+var ErrInvalidSLAPolicy = errors.New("SLA targets must be positive and response must not exceed resolution")
+
+// defaultSLAPolicies apply to every house unless it has its own targets.
+var defaultSLAPolicies = map[string]models.SLAPolicy{
+	"emergency": {Priority: "emergency", ResponseMinutes: 60, ResolutionMinutes: 24 * 60},
+	"high":      {Priority: "high", ResponseMinutes: 4 * 60, ResolutionMinutes: 3 * 24 * 60},
+	"medium":    {Priority: "medium", ResponseMinutes: 24 * 60, ResolutionMinutes: 7 * 24 * 60},
+	"low":       {Priority: "low", ResponseMinutes: 3 * 24 * 60, ResolutionMinutes: 14 * 24 * 60},
+}
+
+var slaPriorities = []string{"emergency", "high", "medium", "low"}
+
+// Escalation levels: a breach first goes to the house manager, then to the
+// administrators once the request is overdue by the same target again.
+const (
+	escalateToManager = 1
+	escalateToAdmin   = 2
+)
+
+type SLAService struct {
+	slaRepo   *repositories.SLARepository
+	templates *NotificationTemplateService
+}
+
+func NewSLAService(slaRepo *repositories.SLARepository, templates *NotificationTemplateService) *SLAService {
+	return &SLAService{slaRepo: slaRepo, templates: templates}
+}
+
+// GetPolicies returns the effective targets for each priority in a house.
+func (s *SLAService) GetPolicies(houseId string) ([]models.SLAPolicy, error) {
+	houseID, err := strconv.Atoi(houseId)
+	if err != nil {
+		return nil, err
+	}
+	lookup, err := s.policyLookup(houseID)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]models.SLAPolicy, 0, len(slaPriorities))
+	for _, priority := range slaPriorities {
+		policy := lookup(houseID, priority)
+		policy.HouseID = houseID
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+func (s *SLAService) SetPolicy(houseId string, policy *models.SLAPolicy) error {
+	houseID, err := strconv.Atoi(houseId)
+	if err != nil {
+		return err
+	}
+	if _, ok := defaultSLAPolicies[policy.Priority]; !ok {
+		return ErrInvalidSLAPolicy
+	}
+	if policy.ResponseMinutes <= 0 || policy.ResolutionMinutes < policy.ResponseMinutes {
+		return ErrInvalidSLAPolicy
+	}
+	policy.HouseID = houseID
+	policy.IsDefault = false
+	return s.slaRepo.UpsertPolicy(policy)
+}
+
+func (s *SLAService) ResetPolicy(houseId, priority string) error {
+	houseID, err := strconv.Atoi(houseId)
+	if err != nil {
+		return err
+	}
+	return s.slaRepo.DeletePolicy(houseID, priority)
+}
+
+// Run checks for SLA breaches every interval for the life of the process.
+func (s *SLAService) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.CheckBreaches(time.Now()); err != nil {
+			log.Printf("SLA check failed: %v", err)
+		}
+	}
+}
+
+// CheckBreaches escalates open requests that have missed their response or
+// resolution target. Each escalation level is only notified once.
+func (s *SLAService) CheckBreaches(now time.Time) error {
+	requests, err := s.slaRepo.GetOpenRequests()
+	if err != nil {
+		return err
+	}
+	lookup, err := s.policyLookup(0)
+	if err != nil {
+		return err
+	}
+
+	for _, req := range requests {
+		policy := lookup(req.HouseID, req.Priority)
+		elapsed := now.Sub(req.ReportedDate)
+
+		if req.StartedDate == nil {
+			s.escalate(req, "response", breachLevel(elapsed, policy.ResponseMinutes))
+		}
+		s.escalate(req, "resolution", breachLevel(elapsed, policy.ResolutionMinutes))
+	}
+	return nil
+}
+
+// Metrics reports SLA compliance for requests reported in a YYYY-MM range,
+// grouped by house and by assignee.
+func (s *SLAService) Metrics(filter AnalyticsFilter) (*models.SLAReport, error) {
+	houseID, start, end, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := s.slaRepo.GetRequestsReported(houseID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	lookup, err := s.policyLookup(houseID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	byHouse := newSLAAccumulator()
+	byAssignee := newSLAAccumulator()
+	for _, req := range requests {
+		policy := lookup(req.HouseID, req.Priority)
+		byHouse.add(req.HouseID, req.HouseName, req, policy, now)
+		if req.AssignedTo != 0 {
+			byAssignee.add(req.AssignedTo, req.AssigneeName, req, policy, now)
+		}
+	}
+
+	return &models.SLAReport{
+		From:       start.Format("2006-01"),
+		To:         end.AddDate(0, -1, 0).Format("2006-01"),
+		ByHouse:    byHouse.results(),
+		ByAssignee: byAssignee.results(),
+	}, nil
+}
+
+// policyLookup loads house overrides once and resolves the target for a
+// house and priority, falling back to the defaults.
+func (s *SLAService) policyLookup(houseID int) (func(house int, priority string) models.SLAPolicy, error) {
+	overrides, err := s.slaRepo.GetPolicies(houseID)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]models.SLAPolicy, len(overrides))
+	for _, policy := range overrides {
+		byKey[fmt.Sprintf("%d|%s", policy.HouseID, policy.Priority)] = policy
+	}
+
+	return func(house int, priority string) models.SLAPolicy {
+		if policy, ok := byKey[fmt.Sprintf("%d|%s", house, priority)]; ok {
+			return policy
+		}
+		policy := defaultSLAPolicies[priority]
+		policy.IsDefault = true
+		return policy
+	}, nil
+}
+
+func (s *SLAService) escalate(req repositories.SLARequest, breachType string, level int) {
+	for l := escalateToManager; l <= level; l++ {
+		recorded, err := s.slaRepo.RecordEscalation(req.RequestID, breachType, l)
+		if err != nil {
+			log.Printf("maintenance request %d: failed to record escalation: %v", req.RequestID, err)
+			return
+		}
+		if !recorded {
+			continue
+		}
+
+		var recipients []int
+		if l == escalateToManager && req.ManagerID != 0 {
+			recipients = []int{req.ManagerID}
+		} else if recipients, err = s.slaRepo.GetAdminIDs(); err != nil {
+			log.Printf("maintenance request %d: failed to load administrators: %v", req.RequestID, err)
+			return
+		}
+
+		for _, userID := range recipients {
+			err := s.templates.Send(userID, TemplateMaintenanceSLABreach, map[string]interface{}{
+				"request_id":  req.RequestID,
+				"breach_type": breachType,
+				"priority":    req.Priority,
+				"house":       req.HouseName,
+			})
+			if err != nil {
+				log.Printf("maintenance request %d: failed to notify user %d: %v", req.RequestID, userID, err)
+			}
+		}
+	}
+}
+
+// breachLevel returns how far past its target a request is: zero when on
+// time, one once the target is missed and two at twice the target.
+func breachLevel(elapsed time.Duration, targetMinutes int) int {
+	target := time.Duration(targetMinutes) * time.Minute
+	switch {
+	case elapsed > 2*target:
+		return escalateToAdmin
+	case elapsed > target:
+		return escalateToManager
+	default:
+		return 0
+	}
+}
+
+type slaAccumulator struct {
+	metrics         map[int]*models.SLAMetrics
+	responseHours   map[int][]float64
+	resolutionHours map[int][]float64
+}
+
+func newSLAAccumulator() *slaAccumulator {
+	return &slaAccumulator{
+		metrics:         make(map[int]*models.SLAMetrics),
+		responseHours:   make(map[int][]float64),
+		resolutionHours: make(map[int][]float64),
+	}
+}
+
+// add counts a request towards a group. Targets are met or breached once
+// the request has responded or resolved; an open request only counts once
+// it is already past its target.
+func (a *slaAccumulator) add(id int, name string, req repositories.SLARequest, policy models.SLAPolicy, now time.Time) {
+	m := a.metrics[id]
+	if m == nil {
+		m = &models.SLAMetrics{ID: id, Name: name}
+		a.metrics[id] = m
+	}
+	m.Requests++
+
+	responded := req.StartedDate
+	if responded == nil {
+		responded = req.CompletedDate
+	}
+	if met, known, hours := measureSLA(req.ReportedDate, responded, policy.ResponseMinutes, now); known {
+		if met {
+			m.ResponseMet++
+		} else {
+			m.ResponseBreached++
+		}
+		if responded != nil {
+			a.responseHours[id] = append(a.responseHours[id], hours)
+		}
+	}
+
+	if met, known, hours := measureSLA(req.ReportedDate, req.CompletedDate, policy.ResolutionMinutes, now); known {
+		if met {
+			m.ResolutionMet++
+		} else {
+			m.ResolutionBreached++
+		}
+		if req.CompletedDate != nil {
+			a.resolutionHours[id] = append(a.resolutionHours[id], hours)
+		}
+	}
+}
+
+func (a *slaAccumulator) results() []models.SLAMetrics {
+	results := make([]models.SLAMetrics, 0, len(a.metrics))
+	for id, m := range a.metrics {
+		m.ResponseCompliance = ratio(float64(m.ResponseMet), float64(m.ResponseMet+m.ResponseBreached))
+		m.ResolutionCompliance = ratio(float64(m.ResolutionMet), float64(m.ResolutionMet+m.ResolutionBreached))
+		m.AverageResponseHours = roundCents(average(a.responseHours[id]))
+		m.AverageResolutionHours = roundCents(average(a.resolutionHours[id]))
+		results = append(results, *m)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+	return results
+}
+
+func measureSLA(reported time.Time, done *time.Time, targetMinutes int, now time.Time) (met, known bool, hours float64) {
+	target := time.Duration(targetMinutes) * time.Minute
+	if done == nil {
+		return false, now.Sub(reported) > target, 0
+	}
+	elapsed := done.Sub(reported)
+	return elapsed <= target, true, elapsed.Hours()
+}
+
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total / float64(len(values))
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/Kimox23/boarding-house-app/internal/models"
@@ -8,6 +9,8 @@ import (
 	"github.com/Kimox23/boarding-house-app/internal/utils"
 )
 
+var ErrProfileNotAllowed = errors.New("profile belongs to another user")
+
 type UserService struct {
 	userRepo *repositories.UserRepository
 }
@@ -78,3 +81,17 @@ func (s *UserService) GetProfile(userId string) (*models.UserProfile, error) {
 	}
 	return s.userRepo.GetProfile(userID)
 }
+
+// SetProfilePicture stores the key of a user's new profile picture and
+// returns the key of the one it replaced. Users change their own picture;
+// admins may change anyone's.
+func (s *UserService) SetProfilePicture(userId string, callerID int, role, key string) (string, error) {
+	userID, err := strconv.Atoi(userId)
+	if err != nil {
+		return "", err
+	}
+	if userID != callerID && role != "admin" {
+		return "", ErrProfileNotAllowed
+	}
+	return s.userRepo.SetProfilePicture(userID, key)
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+)
+
+var (
+	ErrInvalidVendor           = errors.New("vendor requires a name and trade")
+	ErrInvalidVendorDocument   = errors.New("document type must be insurance, licence or other")
+	ErrVendorInactive          = errors.New("vendor is inactive")
+	ErrVendorComplianceExpired = errors.New("vendor compliance documents have expired")
+	ErrInvalidQuoteAmount      = errors.New("quote amount must be greater than zero")
+	ErrInvalidVendorInvoice    = errors.New("vendor invoice requires a number, date and positive amount")
+	ErrQuoteNotForRequest      = errors.New("quote does not belong to this request")
+	ErrNoVendorAssigned        = errors.New("request has no vendor assigned")
+)
+
+type VendorService struct {
+	vendorRepo      *repositories.VendorRepository
+	maintenanceRepo *repositories.MaintenanceRepository
+}
+
+func NewVendorService(vendorRepo *repositories.VendorRepository,
+	maintenanceRepo *repositories.MaintenanceRepository) *VendorService {
+	return &VendorService{vendorRepo: vendorRepo, maintenanceRepo: maintenanceRepo}
+}
+
+func (s *VendorService) CreateVendor(vendor *models.Vendor) error {
+	if strings.TrimSpace(vendor.Name) == "" || strings.TrimSpace(vendor.Trade) == "" {
+		return ErrInvalidVendor
+	}
+	return s.vendorRepo.CreateVendor(vendor)
+}
+
+func (s *VendorService) GetVendor(id string) (*models.Vendor, error) {
+	vendorID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.vendorRepo.GetVendor(vendorID)
+}
+
+func (s *VendorService) GetVendors(trade string) ([]models.Vendor, error) {
+	return s.vendorRepo.GetVendors(trade)
+}
+
+func (s *VendorService) UpdateVendor(id string, vendor *models.Vendor) error {
+	vendorID, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(vendor.Name) == "" || strings.TrimSpace(vendor.Trade) == "" {
+		return ErrInvalidVendor
+	}
+	return s.vendorRepo.UpdateVendor(vendorID, vendor)
+}
+
+func (s *VendorService) AddDocument(vendorId string, document *models.VendorDocument) error {
+	vendor, err := s.GetVendor(vendorId)
+	if err != nil {
+		return err
+	}
+	switch document.DocumentType {
+	case "insurance", "licence", "other":
+	default:
+		return ErrInvalidVendorDocument
+	}
+	document.VendorID = vendor.ID
+	return s.vendorRepo.AddDocument(document)
+}
+
+// AssignVendor puts a vendor on a request. Only active vendors whose
+// insurance and licence are current can be assigned; a nil vendor clears
+// the assignment.
+func (s *VendorService) AssignVendor(requestId string, vendorId *int, actorID int) error {
+	request, err := s.getRequest(requestId)
+	if err != nil {
+		return err
+	}
+	if vendorId != nil {
+		if err := s.checkVendorUsable(*vendorId); err != nil {
+			return err
+		}
+	}
+	return s.vendorRepo.AssignVendor(request.ID, vendorId, actorID)
+}
+
+func (s *VendorService) SubmitQuote(requestId string, quote *models.VendorQuote) error {
+	request, err := s.getRequest(requestId)
+	if err != nil {
+		return err
+	}
+	if quote.Amount <= 0 {
+		return ErrInvalidQuoteAmount
+	}
+	if err := s.checkVendorUsable(quote.VendorID); err != nil {
+		return err
+	}
+	quote.RequestID = request.ID
+	return s.vendorRepo.CreateQuote(quote)
+}
+
+func (s *VendorService) GetQuotes(requestId string) ([]models.VendorQuote, error) {
+	request, err := s.getRequest(requestId)
+	if err != nil {
+		return nil, err
+	}
+	return s.vendorRepo.GetQuotesByRequest(request.ID)
+}
+
+// ApproveQuote approves a quote, which assigns its vendor and allows work
+// on the request to start.
+func (s *VendorService) ApproveQuote(quoteId string, decidedBy int) (*models.VendorQuote, error) {
+	return s.decideQuote(quoteId, "approved", decidedBy)
+}
+
+func (s *VendorService) RejectQuote(quoteId string, decidedBy int) (*models.VendorQuote, error) {
+	return s.decideQuote(quoteId, "rejected", decidedBy)
+}
+
+// RecordInvoice stores a vendor invoice against a request. The request cost
+// becomes the total invoiced by vendors for it.
+func (s *VendorService) RecordInvoice(requestId string, invoice *models.VendorInvoice) error {
+	request, err := s.getRequest(requestId)
+	if err != nil {
+		return err
+	}
+	if invoice.Amount <= 0 || strings.TrimSpace(invoice.InvoiceNumber) == "" || invoice.InvoiceDate.IsZero() {
+		return ErrInvalidVendorInvoice
+	}
+
+	if invoice.VendorID == 0 {
+		if request.VendorID == nil {
+			return ErrNoVendorAssigned
+		}
+		invoice.VendorID = *request.VendorID
+	}
+	if invoice.QuoteID != nil {
+		quote, err := s.vendorRepo.GetQuote(*invoice.QuoteID)
+		if err != nil {
+			return err
+		}
+		if quote.RequestID != request.ID || quote.VendorID != invoice.VendorID {
+			return ErrQuoteNotForRequest
+		}
+	}
+
+	invoice.RequestID = request.ID
+	return s.vendorRepo.CreateInvoice(invoice)
+}
+
+func (s *VendorService) GetInvoices(requestId string) ([]models.VendorInvoice, error) {
+	request, err := s.getRequest(requestId)
+	if err != nil {
+		return nil, err
+	}
+	return s.vendorRepo.GetInvoicesByRequest(request.ID)
+}
+
+func (s *VendorService) MarkInvoicePaid(invoiceId string) error {
+	invoiceID, err := strconv.Atoi(invoiceId)
+	if err != nil {
+		return err
+	}
+	return s.vendorRepo.MarkInvoicePaid(invoiceID)
+}
+
+// Performance summarises each vendor's jobs, resolution time, quotes and
+// invoiced amounts for work reported in a YYYY-MM range.
+func (s *VendorService) Performance(vendorId string, filter AnalyticsFilter) ([]models.VendorPerformance, error) {
+	vendorID := 0
+	if vendorId != "" {
+		parsed, err := strconv.Atoi(vendorId)
+		if err != nil {
+			return nil, err
+		}
+		vendorID = parsed
+	}
+	start, end, err := ParseMonthRange(filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := s.vendorRepo.GetPerformance(vendorID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		p := &results[i]
+		p.AverageResolutionHours = roundCents(p.AverageResolutionHours)
+		p.TotalQuoted = roundCents(p.TotalQuoted)
+		p.TotalInvoiced = roundCents(p.TotalInvoiced)
+		p.InvoiceVariance = roundCents(p.TotalInvoiced - p.TotalQuoted)
+	}
+	if results == nil {
+		results = []models.VendorPerformance{}
+	}
+	return results, nil
+}
+
+func (s *VendorService) decideQuote(quoteId, status string, decidedBy int) (*models.VendorQuote, error) {
+	quoteID, err := strconv.Atoi(quoteId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.vendorRepo.DecideQuote(quoteID, status, decidedBy); err != nil {
+		return nil, err
+	}
+	return s.vendorRepo.GetQuote(quoteID)
+}
+
+func (s *VendorService) checkVendorUsable(vendorId int) error {
+	vendor, err := s.vendorRepo.GetVendor(vendorId)
+	if err != nil {
+		return err
+	}
+	if !vendor.IsActive {
+		return ErrVendorInactive
+	}
+	expired, err := s.vendorRepo.GetExpiredCompliance(vendor.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(expired) > 0 {
+		return fmt.Errorf("%w: %s", ErrVendorComplianceExpired, strings.Join(expired, ", "))
+	}
+	return nil
+}
+
+func (s *VendorService) getRequest(requestId string) (*models.MaintenanceRequest, error) {
+	requestID, err := strconv.Atoi(requestId)
+	if err != nil {
+		return nil, err
+	}
+	return s.maintenanceRepo.GetRequest(requestID)
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/events"
+	"github.com/Kimox23/boarding-house-app/internal/models"
+	"github.com/Kimox23/boarding-house-app/internal/repositories"
+)
+
+var (
+	ErrInvalidWebhookURL = errors.New("webhook url must be an absolute http or https url")
+	ErrInvalidEventType  = errors.New("unknown event type")
+)
+
+const (
+	maxWebhookAttempts   = 6
+	webhookBackoff       = time.Minute
+	webhookLease         = 2 * time.Minute
+	webhookBatchSize     = 50
+	webhookTimeout       = 10 * time.Second
+	webhookDisableAfter  = 15
+	webhookResponseLimit = 500
+	maxWebhookDeliveries = 200
+)
+
+// Headers sent with every webhook request. The signature is the hex HMAC
+// SHA-256 of "<timestamp>.<body>" keyed with the webhook's secret, so that
+// receivers can verify the sender and reject replays.
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// WebhookService sends domain events to the endpoints admins register.
+// Events are queued per webhook as they are relayed from the outbox and
+// posted by Run, retrying failures with exponential backoff. A webhook
+// that keeps failing is disabled until an admin activates it again.
+type WebhookService struct {
+	webhookRepo *repositories.WebhookRepository
+	client      *http.Client
+}
+
+func NewWebhookService(webhookRepo *repositories.WebhookRepository) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		client:      &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+// Subscribe queues every event for the webhooks subscribed to it.
+func (s *WebhookService) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.All, "webhooks", s.webhookRepo.EnqueueEvent)
+}
+
+// CreateWebhook registers an active webhook with a new secret, which is
+// returned only this once.
+func (s *WebhookService) CreateWebhook(webhook *models.Webhook, createdBy int) error {
+	if err := validateWebhook(webhook); err != nil {
+		return err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	webhook.Secret = hex.EncodeToString(secret)
+	webhook.IsActive = true
+	webhook.CreatedBy = &createdBy
+	return s.webhookRepo.CreateWebhook(webhook)
+}
+
+func (s *WebhookService) GetWebhooks() ([]models.Webhook, error) {
+	return s.webhookRepo.GetWebhooks()
+}
+
+func (s *WebhookService) GetWebhook(id string) (*models.Webhook, error) {
+	webhookId, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.webhookRepo.GetWebhook(webhookId)
+}
+
+// UpdateWebhook changes a webhook. Activating a disabled webhook resets its
+// failure count and resumes its pending deliveries.
+func (s *WebhookService) UpdateWebhook(id string, webhook *models.Webhook) error {
+	webhookId, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	if _, err := s.webhookRepo.GetWebhook(webhookId); err != nil {
+		return err
+	}
+	if err := validateWebhook(webhook); err != nil {
+		return err
+	}
+	webhook.ID = webhookId
+	return s.webhookRepo.UpdateWebhook(webhook)
+}
+
+func (s *WebhookService) DeleteWebhook(id string) error {
+	webhookId, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	return s.webhookRepo.DeleteWebhook(webhookId)
+}
+
+// GetDeliveries returns a webhook's delivery log, newest first.
+func (s *WebhookService) GetDeliveries(id, limit string) ([]models.WebhookDelivery, error) {
+	webhookId, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.webhookRepo.GetWebhook(webhookId); err != nil {
+		return nil, err
+	}
+	n := 50
+	if limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, err
+		}
+		n = parsed
+	}
+	if n < 1 || n > maxWebhookDeliveries {
+		n = maxWebhookDeliveries
+	}
+	return s.webhookRepo.GetDeliveries(webhookId, n)
+}
+
+// Redeliver queues an earlier delivery to be sent again with the same
+// payload.
+func (s *WebhookService) Redeliver(id, deliveryId string) (*models.WebhookDelivery, error) {
+	webhookId, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+	deliveryID, err := strconv.ParseInt(deliveryId, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return s.webhookRepo.Redeliver(webhookId, deliveryID)
+}
+
+// Run sends due deliveries every interval until the process exits.
+func (s *WebhookService) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := s.DeliverDue(time.Now()); err != nil {
+			log.Printf("Webhook delivery failed: %v", err)
+		}
+	}
+}
+
+// DeliverDue sends every delivery due at asOf and returns how many the
+// receivers accepted.
+func (s *WebhookService) DeliverDue(asOf time.Time) (int, error) {
+	jobs, err := s.webhookRepo.GetDueDeliveries(asOf, webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, job := range jobs {
+		claimed, err := s.webhookRepo.Claim(job.Delivery.ID, asOf, webhookLease)
+		if err != nil {
+			log.Printf("Failed to claim webhook delivery %d: %v", job.Delivery.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if s.deliver(job, asOf) {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+func (s *WebhookService) deliver(job repositories.WebhookJob, asOf time.Time) bool {
+	delivery := &job.Delivery
+	code, body, err := s.post(job)
+	if err == nil {
+		if err := s.webhookRepo.MarkSucceeded(delivery, code, body); err != nil {
+			log.Printf("Failed to mark webhook delivery %d succeeded: %v", delivery.ID, err)
+		}
+		return true
+	}
+
+	var responseCode *int
+	if code != 0 {
+		responseCode = &code
+	}
+	var retryAt *time.Time
+	if attempt := delivery.Attempts + 1; attempt < maxWebhookAttempts {
+		next := asOf.Add(webhookBackoff << (attempt - 1))
+		retryAt = &next
+	}
+	reason := fmt.Sprintf("disabled after %d consecutive failed deliveries", webhookDisableAfter)
+	disabled, markErr := s.webhookRepo.MarkAttemptFailed(delivery, responseCode, body, err.Error(),
+		retryAt, webhookDisableAfter, reason)
+	if markErr != nil {
+		log.Printf("Failed to record failure of webhook delivery %d: %v", delivery.ID, markErr)
+	}
+	if disabled {
+		log.Printf("Webhook %d %s", delivery.WebhookID, reason)
+	}
+	return false
+}
+
+// post sends a delivery and returns the response code and the start of the
+// response body. Any status other than 2xx is an error.
+func (s *WebhookService) post(job repositories.WebhookJob) (int, string, error) {
+	payload := []byte(job.Delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, job.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "boarding-house-webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, job.Delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(job.Delivery.ID, 10))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhook(job.Secret, timestamp, payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	text := strings.ToValidUTF8(string(body), "")
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, text, fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return resp.StatusCode, text, nil
+}
+
+// SignWebhook returns the hex signature of a payload sent at timestamp.
+func SignWebhook(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateWebhook checks the url and normalizes the event types, which must
+// be known types or "*" for every event.
+func validateWebhook(webhook *models.Webhook) error {
+	parsed, err := url.Parse(webhook.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+
+	seen := make(map[string]bool)
+	var eventTypes []string
+	for _, eventType := range webhook.EventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if eventType == events.All {
+			webhook.EventTypes = []string{events.All}
+			return nil
+		}
+		if !isEventType(eventType) {
+			return fmt.Errorf("%w: %q", ErrInvalidEventType, eventType)
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	if len(eventTypes) == 0 {
+		return fmt.Errorf("%w: at least one event type is required", ErrInvalidEventType)
+	}
+	webhook.EventTypes = eventTypes
+	return nil
+}
+
+func isEventType(eventType string) bool {
+	for _, known := range events.Types {
+		if eventType == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocalStorage(t *testing.T) *LocalStorage {
+	t.Helper()
+	store, err := NewLocalStorage(t.TempDir(), "http://localhost:8080/uploads", "test-secret")
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	return store
+}
+
+// signedQuery returns the expires and signature values of a signed URL.
+func signedQuery(t *testing.T, store *LocalStorage, key string, expires time.Duration) (string, string) {
+	t.Helper()
+	signedURL, err := store.SignedURL(key, expires)
+	if err != nil {
+		t.Fatalf("SignedURL(%q): %v", key, err)
+	}
+	parsed, err := url.Parse(signedURL)
+	if err != nil {
+		t.Fatalf("signed url %q: %v", signedURL, err)
+	}
+	if want := "/uploads/" + key; parsed.Path != want {
+		t.Errorf("signed url path = %q, want %q", parsed.Path, want)
+	}
+	return parsed.Query().Get("expires"), parsed.Query().Get("signature")
+}
+
+func TestLocalVerify(t *testing.T) {
+	store := newTestLocalStorage(t)
+	expires, signature := signedQuery(t, store, "2026/receipt.pdf", time.Minute)
+
+	if err := store.Verify("2026/receipt.pdf", expires, signature); err != nil {
+		t.Fatalf("Verify of a fresh signed url: %v", err)
+	}
+
+	tampered := []struct {
+		name                    string
+		key, expires, signature string
+	}{
+		{"other key", "2026/other.pdf", expires, signature},
+		{"later expiry", "2026/receipt.pdf", expires + "0", signature},
+		{"changed signature", "2026/receipt.pdf", expires, strings.Repeat("0", len(signature))},
+		{"missing signature", "2026/receipt.pdf", expires, ""},
+		{"missing expiry", "2026/receipt.pdf", "", signature},
+	}
+	for _, tc := range tampered {
+		if err := store.Verify(tc.key, tc.expires, tc.signature); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: Verify error = %v, want ErrInvalidSignature", tc.name, err)
+		}
+	}
+
+	other, err := NewLocalStorage(t.TempDir(), "http://localhost:8080/uploads", "other-secret")
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	if err := other.Verify("2026/receipt.pdf", expires, signature); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify with another secret error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestLocalVerifyExpired(t *testing.T) {
+	store := newTestLocalStorage(t)
+	expired := strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10)
+	if err := store.Verify("receipt.pdf", expired, store.sign("receipt.pdf", expired)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify of an expired url error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestLocalRejectsInvalidKeys(t *testing.T) {
+	store := newTestLocalStorage(t)
+	for _, key := range []string{"", "../secret", "/etc/passwd"} {
+		if _, err := store.SignedURL(key, time.Minute); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("SignedURL(%q) error = %v, want ErrInvalidKey", key, err)
+		}
+		if err := store.Put(key, strings.NewReader("x"), 1, "text/plain"); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q) error = %v, want ErrInvalidKey", key, err)
+		}
+		if _, _, err := store.Get(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Get(%q) error = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
+
+func TestLocalPutGetStatDelete(t *testing.T) {
+	store := newTestLocalStorage(t)
+	body := "hello, tenant"
+	if err := store.Put("2026/note.txt", strings.NewReader(body), int64(len(body)), "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	object, err := store.Stat("2026/note.txt")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if object.Size != int64(len(body)) {
+		t.Errorf("Stat size = %d, want %d", object.Size, len(body))
+	}
+
+	file, _, err := store.Get("2026/note.txt")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil || string(data) != body {
+		t.Errorf("Get read %q, %v; want %q", data, err, body)
+	}
+
+	if err := store.Delete("2026/note.txt"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Stat("2026/note.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat after Delete error = %v, want ErrNotFound", err)
+	}
+}
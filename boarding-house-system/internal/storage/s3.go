@@ -0,0 +1,335 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unsignedPayload lets uploads stream without hashing the body first.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Options configures an S3-compatible bucket. Endpoint is the service
+// root, such as https://s3.eu-west-1.amazonaws.com or, for a local MinIO,
+// http://localhost:9000 with UsePathStyle set.
+type S3Options struct {
+	Endpoint     string
+	Region       string
+	Bucket       string
+	AccessKey    string
+	SecretKey    string
+	UsePathStyle bool
+}
+
+// S3Storage keeps files in an S3-compatible bucket. Requests are signed
+// with AWS Signature Version 4 and signed URLs are presigned GET requests
+// straight to the bucket.
+type S3Storage struct {
+	endpoint     *url.URL
+	region       string
+	bucket       string
+	accessKey    string
+	secretKey    string
+	usePathStyle bool
+	client       *http.Client
+}
+
+func NewS3Storage(options S3Options) (*S3Storage, error) {
+	if options.Bucket == "" || options.AccessKey == "" || options.SecretKey == "" {
+		return nil, errors.New("s3 storage needs a bucket, an access key and a secret key")
+	}
+	if options.Region == "" {
+		options.Region = "us-east-1"
+	}
+	if options.Endpoint == "" {
+		options.Endpoint = "https://s3." + options.Region + ".amazonaws.com"
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(options.Endpoint, "/"))
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", options.Endpoint)
+	}
+
+	return &S3Storage{
+		endpoint:     endpoint,
+		region:       options.Region,
+		bucket:       options.Bucket,
+		accessKey:    options.AccessKey,
+		secretKey:    options.SecretKey,
+		usePathStyle: options.UsePathStyle,
+		client:       &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+func (s *S3Storage) Put(key string, body io.Reader, size int64, contentType string) error {
+	req, err := s.request(http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3Storage) Get(key string) (io.ReadCloser, *Object, error) {
+	req, err := s.request(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Body, s3Object(key, resp), nil
+}
+
+// Delete removes a file. Deleting a missing file is not an error.
+func (s *S3Storage) Delete(key string) error {
+	req, err := s.request(http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3Storage) Stat(key string) (*Object, error) {
+	req, err := s.request(http.MethodHead, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return s3Object(key, resp), nil
+}
+
+// SignedURL presigns a GET request for the file. S3 caps expiry at seven
+// days.
+func (s *S3Storage) SignedURL(key string, expires time.Duration) (string, error) {
+	return s.presign(key, expires, time.Now().UTC())
+}
+
+func (s *S3Storage) presign(key string, expires time.Duration, now time.Time) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	seconds := int64(expires / time.Second)
+	if seconds < 1 || seconds > 7*24*60*60 {
+		return "", fmt.Errorf("signed url expiry must be between 1s and 7 days, got %s", expires)
+	}
+
+	objectURL := s.objectURL(key)
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.accessKey+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	query.Set("X-Amz-Expires", strconv.FormatInt(seconds, 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		objectURL.EscapedPath(),
+		canonicalQuery(query),
+		"host:" + objectURL.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+	query.Set("X-Amz-Signature", s.signature(now, canonical))
+
+	objectURL.RawQuery = canonicalQuery(query)
+	return objectURL.String(), nil
+}
+
+// request builds a request for an object signed with an Authorization
+// header.
+func (s *S3Storage) request(method, key string, body io.Reader) (*http.Request, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	objectURL := s.objectURL(key)
+	req, err := http.NewRequest(method, objectURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	return req, nil
+}
+
+// do signs and sends a request. Error responses are returned as errors,
+// with 404s mapped to ErrNotFound.
+func (s *S3Storage) do(req *http.Request) (*http.Response, error) {
+	s.authorize(req)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(message)))
+}
+
+// authorize signs the host, content type, range and x-amz-* headers of a
+// request.
+func (s *S3Storage) authorize(req *http.Request) {
+	now, _ := time.Parse("20060102T150405Z", req.Header.Get("X-Amz-Date"))
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || lower == "range" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, s.scope(now), signedHeaders, s.signature(now, canonical)))
+}
+
+func (s *S3Storage) signature(now time.Time, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format("20060102T150405Z"),
+		s.scope(now),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func (s *S3Storage) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+}
+
+// objectURL addresses an object as endpoint/bucket/key in path style or
+// bucket.endpoint/key otherwise.
+func (s *S3Storage) objectURL(key string) *url.URL {
+	objectURL := *s.endpoint
+	if s.usePathStyle {
+		objectURL.Path = s.endpoint.Path + "/" + s.bucket + "/" + key
+	} else {
+		objectURL.Host = s.bucket + "." + s.endpoint.Host
+		objectURL.Path = s.endpoint.Path + "/" + key
+	}
+	objectURL.RawPath = s3EscapePath(objectURL.Path)
+	return &objectURL
+}
+
+func s3Object(key string, resp *http.Response) *Object {
+	object := &Object{Key: key, Size: resp.ContentLength, ContentType: resp.Header.Get("Content-Type")}
+	if size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err == nil {
+		object.Size = size
+	}
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		object.ModTime = modified
+	}
+	return object
+}
+
+// canonicalQuery sorts and encodes query parameters the way SigV4 expects.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, s3Escape(key, true)+"="+s3Escape(value, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func s3EscapePath(path string) string {
+	return s3Escape(path, false)
+}
+
+// s3Escape percent-encodes everything but unreserved characters and, in
+// paths, slashes.
+func s3Escape(value string, encodeSlash bool) string {
+	var escaped strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case b >= 'A' && b <= 'Z', b >= 'a' && b <= 'z', b >= '0' && b <= '9',
+			b == '-', b == '_', b == '.', b == '~':
+			escaped.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			escaped.WriteByte(b)
+		default:
+			fmt.Fprintf(&escaped, "%%%02X", b)
+		}
+	}
+	return escaped.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
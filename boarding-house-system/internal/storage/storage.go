@@ -0,0 +1,84 @@
+// Package storage keeps uploaded files behind one interface so that they can
+// live on the local disk or in an S3-compatible bucket.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Kimox23/boarding-house-app/internal/config"
+)
+
+var (
+	ErrNotFound         = errors.New("file not found")
+	ErrInvalidKey       = errors.New("invalid file key")
+	ErrInvalidSignature = errors.New("invalid or expired file signature")
+)
+
+// Object describes a stored file.
+type Object struct {
+	Key         string    `json:"key"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	ModTime     time.Time `json:"modified_at"`
+}
+
+// Storage stores files under slash-separated keys. Keys are what the
+// database keeps in its file_path columns.
+type Storage interface {
+	// Put writes size bytes from body under key, replacing any file already
+	// there.
+	Put(key string, body io.Reader, size int64, contentType string) error
+	// Get opens a file for reading. The caller closes it.
+	Get(key string) (io.ReadCloser, *Object, error)
+	Delete(key string) error
+	Stat(key string) (*Object, error)
+	// SignedURL returns a URL that downloads the file without further
+	// authentication until it expires.
+	SignedURL(key string, expires time.Duration) (string, error)
+}
+
+// URLVerifier is implemented by backends whose signed URLs point back at
+// this server, so that the route serving them can check the signature.
+type URLVerifier interface {
+	Verify(key, expires, signature string) error
+}
+
+// New returns the backend selected by cfg.StorageDriver.
+func New(cfg *config.Config) (Storage, error) {
+	switch cfg.StorageDriver {
+	case "", "local":
+		secret := cfg.StorageURLSecret
+		if secret == "" {
+			secret = cfg.JWTSecret
+		}
+		return NewLocalStorage(cfg.UploadDir, cfg.AppURL+"/uploads", secret)
+	case "s3":
+		return NewS3Storage(S3Options{
+			Endpoint:     cfg.S3Endpoint,
+			Region:       cfg.S3Region,
+			Bucket:       cfg.S3Bucket,
+			AccessKey:    cfg.S3AccessKey,
+			SecretKey:    cfg.S3SecretKey,
+			UsePathStyle: cfg.S3UsePathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.StorageDriver)
+	}
+}
+
+// cleanKey rejects keys that are empty or would escape the storage root.
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned != key || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCleanKey(t *testing.T) {
+	valid := []string{
+		"receipt.pdf",
+		"2026/10/photo.jpg",
+		"a..b.txt",
+	}
+	for _, key := range valid {
+		if cleaned, err := cleanKey(key); err != nil || cleaned != key {
+			t.Errorf("cleanKey(%q) = %q, %v; want the key back", key, cleaned, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		".",
+		"..",
+		"../secret",
+		"../../etc/passwd",
+		"uploads/../../secret",
+		"uploads/../other.txt",
+		"/etc/passwd",
+		"/uploads/file.txt",
+		"uploads//file.txt",
+		"uploads/./file.txt",
+		"uploads/",
+		`..\secret`,
+		`uploads\file.txt`,
+	}
+	for _, key := range invalid {
+		if _, err := cleanKey(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("cleanKey(%q) error = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
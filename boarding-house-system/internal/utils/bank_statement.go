@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatementLine is a single transaction read from a bank statement file.
+type StatementLine struct {
+	Date        time.Time
+	Amount      float64
+	Reference   string
+	Description string
+}
+
+// CSVColumnMapping describes where each field lives in a bank's CSV export.
+// Columns may be given either by header name or by zero-based index.
+type CSVColumnMapping struct {
+	Date        string
+	Amount      string
+	Reference   string
+	Description string
+	DateFormat  string
+	Delimiter   rune
+	HasHeader   bool
+}
+
+var ErrEmptyStatement = errors.New("statement contains no transactions")
+
+// ParseCSVStatement reads transactions from a CSV export using the given mapping.
+func ParseCSVStatement(r io.Reader, mapping CSVColumnMapping) ([]StatementLine, error) {
+	reader := csv.NewReader(r)
+	if mapping.Delimiter != 0 {
+		reader.Comma = mapping.Delimiter
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	if mapping.DateFormat == "" {
+		mapping.DateFormat = "2006-01-02"
+	}
+
+	var header []string
+	if mapping.HasHeader {
+		record, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read header: %w", err)
+		}
+		header = record
+	}
+
+	dateCol, err := resolveColumn(mapping.Date, header)
+	if err != nil {
+		return nil, err
+	}
+	amountCol, err := resolveColumn(mapping.Amount, header)
+	if err != nil {
+		return nil, err
+	}
+	referenceCol, _ := resolveColumn(mapping.Reference, header)
+	descriptionCol, _ := resolveColumn(mapping.Description, header)
+
+	var lines []StatementLine
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row, err)
+		}
+
+		date, err := time.Parse(mapping.DateFormat, field(record, dateCol))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid date: %w", row, err)
+		}
+		amount, err := parseAmount(field(record, amountCol))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid amount: %w", row, err)
+		}
+
+		lines = append(lines, StatementLine{
+			Date:        date,
+			Amount:      amount,
+			Reference:   field(record, referenceCol),
+			Description: field(record, descriptionCol),
+		})
+	}
+
+	if len(lines) == 0 {
+		return nil, ErrEmptyStatement
+	}
+	return lines, nil
+}
+
+var ofxTag = regexp.MustCompile(`<(/?[A-Z.]+)>([^<\r\n]*)`)
+
+// ParseOFXStatement reads STMTTRN records from an OFX (SGML or XML) file.
+func ParseOFXStatement(r io.Reader) ([]StatementLine, error) {
+	var lines []StatementLine
+	var current *StatementLine
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		for _, match := range ofxTag.FindAllStringSubmatch(scanner.Text(), -1) {
+			tag, value := match[1], strings.TrimSpace(match[2])
+			switch tag {
+			case "STMTTRN":
+				current = &StatementLine{}
+			case "/STMTTRN":
+				if current != nil {
+					lines = append(lines, *current)
+					current = nil
+				}
+			case "DTPOSTED":
+				if current != nil && len(value) >= 8 {
+					date, err := time.Parse("20060102", value[:8])
+					if err != nil {
+						return nil, fmt.Errorf("invalid DTPOSTED %q: %w", value, err)
+					}
+					current.Date = date
+				}
+			case "TRNAMT":
+				if current != nil {
+					amount, err := parseAmount(value)
+					if err != nil {
+						return nil, fmt.Errorf("invalid TRNAMT %q: %w", value, err)
+					}
+					current.Amount = amount
+				}
+			case "FITID", "REFNUM":
+				if current != nil && current.Reference == "" {
+					current.Reference = value
+				}
+			case "NAME", "MEMO":
+				if current != nil && value != "" {
+					current.Description = strings.TrimSpace(current.Description + " " + value)
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(lines) == 0 {
+		return nil, ErrEmptyStatement
+	}
+	return lines, nil
+}
+
+func resolveColumn(spec string, header []string) (int, error) {
+	if spec == "" {
+		return -1, errors.New("column mapping is required")
+	}
+	if index, err := strconv.Atoi(spec); err == nil {
+		return index, nil
+	}
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), spec) {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("column %q not found in header", spec)
+}
+
+func field(record []string, index int) string {
+	if index < 0 || index >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[index])
+}
+
+func parseAmount(s string) (float64, error) {
+	s = strings.NewReplacer(",", "", " ", "").Replace(s)
+	return strconv.ParseFloat(s, 64)
+}
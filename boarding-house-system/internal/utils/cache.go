@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// TTLCache is a small in-process cache whose entries expire after a fixed
+// interval. A zero TTL disables caching.
+type TTLCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+func NewTTLCache(ttl time.Duration) *TTLCache {
+	return &TTLCache{ttl: ttl, entries: make(map[string]cacheEntry)}
+}
+
+// GetOrLoad returns the cached value for key, calling load to compute and
+// store it when missing or expired.
+func (c *TTLCache) GetOrLoad(key string, load func() (interface{}, error)) (interface{}, error) {
+	if c.ttl <= 0 {
+		return load()
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.value, nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{value: value, expiresAt: time.Now().Add(c.ttl)}
+	for k, e := range c.entries {
+		if time.Now().After(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.mu.Unlock()
+
+	return value, nil
+}
@@ -0,0 +1,15 @@
+package utils
+
+import "github.com/gofiber/fiber/v3"
+
+// GetUserID returns the authenticated user's ID stored by the auth middleware.
+func GetUserID(ctx fiber.Ctx) (int, bool) {
+	userID, ok := ctx.Locals("userID").(int)
+	return userID, ok
+}
+
+// GetUserRole returns the authenticated user's role stored by the auth middleware.
+func GetUserRole(ctx fiber.Ctx) string {
+	role, _ := ctx.Locals("userRole").(string)
+	return role
+}
@@ -1,25 +1,27 @@
 package utils
 
 import (
-	"io"
+	"crypto/rand"
+	"encoding/hex"
 	"mime/multipart"
-	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
-	"github.com/gofiber/fiber/v3"
+	"github.com/Kimox23/boarding-house-app/internal/storage"
 )
 
-func SaveUploadedFile(ctx fiber.Ctx, fileHeader *multipart.FileHeader, uploadDir string) (string, error) {
-	// Create upload directory if it doesn't exist
-	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
+// SaveUploadedFile stores an uploaded file under a new unique key and
+// returns the key.
+func SaveUploadedFile(store storage.Storage, fileHeader *multipart.FileHeader) (string, error) {
+	// Generate unique filename; the random suffix keeps several files
+	// uploaded in the same second apart
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
 		return "", err
 	}
-
-	// Generate unique filename
-	ext := filepath.Ext(fileHeader.Filename)
-	filename := time.Now().Format("20060102150405") + ext
-	filePath := filepath.Join(uploadDir, filename)
+	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
+	filename := time.Now().Format("20060102150405") + "-" + hex.EncodeToString(suffix) + ext
 
 	// Save the file
 	file, err := fileHeader.Open()
@@ -28,15 +30,63 @@ func SaveUploadedFile(ctx fiber.Ctx, fileHeader *multipart.FileHeader, uploadDir
 	}
 	defer file.Close()
 
-	out, err := os.Create(filePath)
-	if err != nil {
+	if err := store.Put(filename, file, fileHeader.Size, UploadContentType(filename)); err != nil {
 		return "", err
 	}
-	defer out.Close()
 
-	if _, err := io.Copy(out, file); err != nil {
-		return "", err
+	return filename, nil
+}
+
+// uploadContentTypes are the content types uploads are stored with, by
+// extension. The type the client sends is never used, and anything not
+// listed here, such as HTML or SVG, is stored as application/octet-stream.
+var uploadContentTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".heic": "image/heic",
+	".pdf":  "application/pdf",
+	".mp4":  "video/mp4",
+	".mov":  "video/quicktime",
+	".webm": "video/webm",
+	".3gp":  "video/3gpp",
+}
+
+// inlineContentTypes are the types a browser may display in place. Every
+// other file is served as a download.
+var inlineContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+// UploadContentType returns the content type to store a file with.
+func UploadContentType(filename string) string {
+	if contentType, ok := uploadContentTypes[strings.ToLower(filepath.Ext(filename))]; ok {
+		return contentType
 	}
+	return "application/octet-stream"
+}
 
-	return filename, nil
+// IsInlineContentType reports whether a stored file may be displayed by the
+// browser rather than downloaded.
+func IsInlineContentType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return inlineContentTypes[strings.ToLower(strings.TrimSpace(mediaType))]
+}
+
+// MediaTypeForFile classifies an upload as an image or video by extension.
+func MediaTypeForFile(filename string) (string, bool) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp", ".heic":
+		return "image", true
+	case ".mp4", ".mov", ".webm", ".3gp":
+		return "video", true
+	default:
+		return "", false
+	}
 }
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pdfPageWidth    = 595 // A4 in points
+	pdfPageHeight   = 842
+	pdfMargin       = 40
+	pdfFontSize     = 9
+	pdfLineHeight   = 12
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+)
+
+// PDFDocument builds a plain text report as a PDF using the built-in
+// Courier font, so fixed-width columns line up without font embedding.
+type PDFDocument struct {
+	lines []string
+}
+
+func NewPDFDocument(title string) *PDFDocument {
+	doc := &PDFDocument{}
+	doc.AddLine(title)
+	doc.AddLine(strings.Repeat("=", len(title)))
+	doc.AddLine("")
+	return doc
+}
+
+// AddLine appends a line of text. Pages are broken automatically when rendered.
+func (d *PDFDocument) AddLine(text string) {
+	d.lines = append(d.lines, text)
+}
+
+// AddRow appends columns padded to the given widths.
+func (d *PDFDocument) AddRow(widths []int, columns ...string) {
+	var b strings.Builder
+	for i, column := range columns {
+		width := 12
+		if i < len(widths) {
+			width = widths[i]
+		}
+		if len(column) > width {
+			column = column[:width]
+		}
+		fmt.Fprintf(&b, "%-*s ", width, column)
+	}
+	d.AddLine(strings.TrimRight(b.String(), " "))
+}
+
+// Bytes renders the document.
+func (d *PDFDocument) Bytes() []byte {
+	var pages [][]string
+	for start := 0; start < len(d.lines); start += pdfLinesPerPage {
+		end := start + pdfLinesPerPage
+		if end > len(d.lines) {
+			end = len(d.lines)
+		}
+		pages = append(pages, d.lines[start:end])
+	}
+	if len(pages) == 0 {
+		pages = [][]string{{""}}
+	}
+
+	// Objects: 1 catalog, 2 page tree, 3 font, then a page and content
+	// stream for every page.
+	var objects []string
+	objects = append(objects, "<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+i*2)
+	}
+	objects = append(objects, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>",
+		strings.Join(kids, " "), len(pages)))
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>")
+
+	for i, page := range pages {
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n",
+			pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) '\n", escapePDFText(line))
+		}
+		content.WriteString("ET")
+
+		objects = append(objects, fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 5+i*2))
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream",
+			content.Len(), content.String()))
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(objects)+1, xref)
+
+	return out.Bytes()
+}
+
+func escapePDFText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteRune('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -26,25 +26,7 @@ func RunMigrations(db *sql.DB) error {
 	if err != nil {
 		return fmt.Errorf("failed to check for existing tables: %w", err)
 	}
-
-	if tableExists > 0 {
-		log.Println("Database already initialized, skipping migrations")
-		return nil
-	}
-
-	tx, err := db.Begin()
-	if err != nil {
-		return fmt.Errorf("could not begin transaction: %w", err)
-	}
-
-	defer func() {
-		if err != nil {
-			log.Printf("Migration failed, rolling back: %v", err)
-			if rbErr := tx.Rollback(); rbErr != nil {
-				log.Printf("Rollback failed: %v", rbErr)
-			}
-		}
-	}()
+	initialized := tableExists > 0
 
 	tables := []struct {
 		name  string
@@ -122,6 +104,7 @@ func RunMigrations(db *sql.DB) error {
 				room_id INT,
 				move_in_date DATE NOT NULL,
 				move_out_date DATE,
+				lease_end_date DATE,
 				deposit_amount DECIMAL(10,2),
 				deposit_paid BOOLEAN DEFAULT FALSE,
 				contract_document VARCHAR(255),
@@ -140,11 +123,51 @@ func RunMigrations(db *sql.DB) error {
 				payment_method ENUM('cash', 'bank_transfer', 'credit_card', 'mobile_payment') NOT NULL,
 				payment_for_month DATE NOT NULL,
 				receipt_number VARCHAR(50) UNIQUE,
-				status ENUM('paid', 'pending', 'overdue', 'partial') NOT NULL,
+				status ENUM('paid', 'pending', 'overdue', 'partial', 'voided', 'refunded') NOT NULL,
 				notes TEXT,
 				recorded_by INT,
+				entry_type ENUM('payment', 'void', 'refund') NOT NULL DEFAULT 'payment',
+				reversal_of INT,
+				reason TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 				FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id),
-				FOREIGN KEY (recorded_by) REFERENCES users(user_id)
+				FOREIGN KEY (recorded_by) REFERENCES users(user_id),
+				FOREIGN KEY (reversal_of) REFERENCES payments(payment_id)
+			)`,
+		},
+		{
+			"assets",
+			`CREATE TABLE IF NOT EXISTS assets (
+				asset_id INT PRIMARY KEY AUTO_INCREMENT,
+				room_id INT NOT NULL,
+				category ENUM('bed', 'mattress', 'aircon', 'key', 'furniture', 'appliance', 'other') NOT NULL,
+				name VARCHAR(100) NOT NULL,
+				serial_number VARCHAR(100),
+				asset_condition ENUM('new', 'good', 'fair', 'poor', 'broken') DEFAULT 'good',
+				purchase_date DATE,
+				purchase_cost DECIMAL(10,2),
+				expected_life_months INT,
+				notes TEXT,
+				is_active BOOLEAN DEFAULT TRUE,
+				disposed_date TIMESTAMP NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (room_id) REFERENCES rooms(room_id) ON DELETE CASCADE
+			)`,
+		},
+		{
+			"vendors",
+			`CREATE TABLE IF NOT EXISTS vendors (
+				vendor_id INT PRIMARY KEY AUTO_INCREMENT,
+				name VARCHAR(100) NOT NULL,
+				trade VARCHAR(50) NOT NULL,
+				contact_name VARCHAR(100),
+				email VARCHAR(100),
+				phone VARCHAR(20),
+				hourly_rate DECIMAL(10,2),
+				callout_fee DECIMAL(10,2),
+				notes TEXT,
+				is_active BOOLEAN DEFAULT TRUE,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 			)`,
 		},
 		{
@@ -158,12 +181,18 @@ func RunMigrations(db *sql.DB) error {
 				priority ENUM('low', 'medium', 'high', 'emergency') NOT NULL,
 				status ENUM('pending', 'in_progress', 'completed', 'cancelled') DEFAULT 'pending',
 				reported_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-				completed_date TIMESTAMP,
+				started_date TIMESTAMP NULL,
+				completed_date TIMESTAMP NULL,
+				cancelled_date TIMESTAMP NULL,
 				assigned_to INT,
+				vendor_id INT,
+				asset_id INT,
 				cost DECIMAL(10,2),
 				FOREIGN KEY (room_id) REFERENCES rooms(room_id),
 				FOREIGN KEY (reported_by) REFERENCES users(user_id),
-				FOREIGN KEY (assigned_to) REFERENCES users(user_id)
+				FOREIGN KEY (assigned_to) REFERENCES users(user_id),
+				FOREIGN KEY (vendor_id) REFERENCES vendors(vendor_id),
+				FOREIGN KEY (asset_id) REFERENCES assets(asset_id) ON DELETE SET NULL
 			)`,
 		},
 		{
@@ -176,7 +205,9 @@ func RunMigrations(db *sql.DB) error {
 				is_read BOOLEAN DEFAULT FALSE,
 				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 				link VARCHAR(255),
-				FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+				category VARCHAR(30) NOT NULL DEFAULT 'general',
+				FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+				INDEX idx_notification_inbox (user_id, is_read, created_at)
 			)`,
 		},
 		{
@@ -194,9 +225,675 @@ func RunMigrations(db *sql.DB) error {
 				FOREIGN KEY (verified_by) REFERENCES users(user_id)
 			)`,
 		},
+		{
+			"invoices",
+			`CREATE TABLE IF NOT EXISTS invoices (
+				invoice_id INT PRIMARY KEY AUTO_INCREMENT,
+				tenant_id INT NOT NULL,
+				period_month DATE NOT NULL,
+				amount DECIMAL(10,2) NOT NULL,
+				due_date DATE NOT NULL,
+				issued_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE KEY uq_invoice_period (tenant_id, period_month),
+				FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+			)`,
+		},
+		{
+			"bank_statement_imports",
+			`CREATE TABLE IF NOT EXISTS bank_statement_imports (
+				import_id INT PRIMARY KEY AUTO_INCREMENT,
+				file_name VARCHAR(255) NOT NULL,
+				format ENUM('csv', 'ofx') NOT NULL,
+				uploaded_by INT,
+				uploaded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (uploaded_by) REFERENCES users(user_id)
+			)`,
+		},
+		{
+			"bank_statement_lines",
+			`CREATE TABLE IF NOT EXISTS bank_statement_lines (
+				line_id INT PRIMARY KEY AUTO_INCREMENT,
+				import_id INT NOT NULL,
+				transaction_date DATE NOT NULL,
+				amount DECIMAL(10,2) NOT NULL,
+				reference VARCHAR(255),
+				description TEXT,
+				matched_tenant_id INT,
+				confidence DECIMAL(3,2) DEFAULT 0,
+				status ENUM('unmatched', 'suggested', 'confirmed', 'ignored') DEFAULT 'unmatched',
+				payment_id INT,
+				FOREIGN KEY (import_id) REFERENCES bank_statement_imports(import_id) ON DELETE CASCADE,
+				FOREIGN KEY (matched_tenant_id) REFERENCES tenants(tenant_id),
+				FOREIGN KEY (payment_id) REFERENCES payments(payment_id)
+			)`,
+		},
+		{
+			"recurring_expenses",
+			`CREATE TABLE IF NOT EXISTS recurring_expenses (
+				recurring_id INT PRIMARY KEY AUTO_INCREMENT,
+				house_id INT NOT NULL,
+				category ENUM('utilities', 'salaries', 'supplies', 'taxes', 'insurance', 'repairs', 'other') NOT NULL,
+				vendor VARCHAR(100),
+				description TEXT,
+				amount DECIMAL(10,2) NOT NULL,
+				day_of_month INT NOT NULL DEFAULT 1,
+				start_date DATE NOT NULL,
+				end_date DATE,
+				last_posted DATE,
+				is_active BOOLEAN DEFAULT TRUE,
+				FOREIGN KEY (house_id) REFERENCES boarding_houses(house_id) ON DELETE CASCADE
+			)`,
+		},
+		{
+			"expenses",
+			`CREATE TABLE IF NOT EXISTS expenses (
+				expense_id INT PRIMARY KEY AUTO_INCREMENT,
+				house_id INT NOT NULL,
+				category ENUM('utilities', 'salaries', 'supplies', 'taxes', 'insurance', 'repairs', 'other') NOT NULL,
+				vendor VARCHAR(100),
+				description TEXT,
+				amount DECIMAL(10,2) NOT NULL,
+				expense_date DATE NOT NULL,
+				receipt VARCHAR(255),
+				recurring_id INT,
+				recorded_by INT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (house_id) REFERENCES boarding_houses(house_id) ON DELETE CASCADE,
+				FOREIGN KEY (recurring_id) REFERENCES recurring_expenses(recurring_id) ON DELETE SET NULL,
+				FOREIGN KEY (recorded_by) REFERENCES users(user_id)
+			)`,
+		},
+		{
+			"owners",
+			`CREATE TABLE IF NOT EXISTS owners (
+				owner_id INT PRIMARY KEY AUTO_INCREMENT,
+				name VARCHAR(100) NOT NULL,
+				email VARCHAR(100),
+				phone VARCHAR(20),
+				management_fee_percent DECIMAL(5,2) NOT NULL DEFAULT 0,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			)`,
+		},
+		{
+			"owner_houses",
+			`CREATE TABLE IF NOT EXISTS owner_houses (
+				owner_id INT NOT NULL,
+				house_id INT NOT NULL UNIQUE,
+				PRIMARY KEY (owner_id, house_id),
+				FOREIGN KEY (owner_id) REFERENCES owners(owner_id) ON DELETE CASCADE,
+				FOREIGN KEY (house_id) REFERENCES boarding_houses(house_id) ON DELETE CASCADE
+			)`,
+		},
+		{
+			"owner_statements",
+			`CREATE TABLE IF NOT EXISTS owner_statements (
+				statement_id INT PRIMARY KEY AUTO_INCREMENT,
+				owner_id INT NOT NULL,
+				period_month DATE NOT NULL,
+				income DECIMAL(12,2) NOT NULL,
+				expenses DECIMAL(12,2) NOT NULL,
+				maintenance_cost DECIMAL(12,2) NOT NULL,
+				management_fee DECIMAL(12,2) NOT NULL,
+				net_payout DECIMAL(12,2) NOT NULL,
+				generated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE KEY uq_owner_period (owner_id, period_month),
+				FOREIGN KEY (owner_id) REFERENCES owners(owner_id) ON DELETE CASCADE
+			)`,
+		},
+		{
+			"owner_statement_lines",
+			`CREATE TABLE IF NOT EXISTS owner_statement_lines (
+				statement_id INT NOT NULL,
+				house_id INT NOT NULL,
+				house_name VARCHAR(100) NOT NULL,
+				income DECIMAL(12,2) NOT NULL,
+				expenses DECIMAL(12,2) NOT NULL,
+				maintenance_cost DECIMAL(12,2) NOT NULL,
+				PRIMARY KEY (statement_id, house_id),
+				FOREIGN KEY (statement_id) REFERENCES owner_statements(statement_id) ON DELETE CASCADE
+			)`,
+		},
+		{
+			"owner_payouts",
+			`CREATE TABLE IF NOT EXISTS owner_payouts (
+				payout_id INT PRIMARY KEY AUTO_INCREMENT,
+				statement_id INT NOT NULL,
+				amount DECIMAL(12,2) NOT NULL,
+				method VARCHAR(50) NOT NULL,
+				reference VARCHAR(100),
+				paid_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				recorded_by INT,
+				FOREIGN KEY (statement_id) REFERENCES owner_statements(statement_id),
+				FOREIGN KEY (recorded_by) REFERENCES users(user_id)
+			)`,
+		},
+		{
+			"reservations",
+			`CREATE TABLE IF NOT EXISTS reservations (
+				reservation_id INT PRIMARY KEY AUTO_INCREMENT,
+				room_id INT NOT NULL,
+				guest_name VARCHAR(100) NOT NULL,
+				guest_email VARCHAR(100),
+				guest_phone VARCHAR(20),
+				expected_move_in DATE NOT NULL,
+				expected_move_out DATE,
+				monthly_rate DECIMAL(10,2) NOT NULL,
+				status ENUM('pending', 'confirmed', 'cancelled', 'converted') DEFAULT 'pending',
+				created_by INT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (room_id) REFERENCES rooms(room_id) ON DELETE CASCADE,
+				FOREIGN KEY (created_by) REFERENCES users(user_id)
+			)`,
+		},
+		{
+			"maintenance_events",
+			`CREATE TABLE IF NOT EXISTS maintenance_events (
+				event_id INT PRIMARY KEY AUTO_INCREMENT,
+				request_id INT NOT NULL,
+				event_type ENUM('created', 'status_changed', 'assigned', 'updated', 'escalated') NOT NULL,
+				from_status VARCHAR(20),
+				to_status VARCHAR(20),
+				actor_id INT,
+				note TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (request_id) REFERENCES maintenance_requests(request_id) ON DELETE CASCADE,
+				FOREIGN KEY (actor_id) REFERENCES users(user_id),
+				INDEX idx_maintenance_events_request (request_id, created_at)
+			)`,
+		},
+		{
+			"maintenance_comments",
+			`CREATE TABLE IF NOT EXISTS maintenance_comments (
+				comment_id INT PRIMARY KEY AUTO_INCREMENT,
+				request_id INT NOT NULL,
+				parent_id INT,
+				author_id INT NOT NULL,
+				body TEXT NOT NULL,
+				is_internal BOOLEAN DEFAULT FALSE,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (request_id) REFERENCES maintenance_requests(request_id) ON DELETE CASCADE,
+				FOREIGN KEY (parent_id) REFERENCES maintenance_comments(comment_id) ON DELETE CASCADE,
+				FOREIGN KEY (author_id) REFERENCES users(user_id)
+			)`,
+		},
+		{
+			"maintenance_attachments",
+			`CREATE TABLE IF NOT EXISTS maintenance_attachments (
+				attachment_id INT PRIMARY KEY AUTO_INCREMENT,
+				comment_id INT NOT NULL,
+				file_path VARCHAR(255) NOT NULL,
+				original_name VARCHAR(255),
+				media_type ENUM('image', 'video') NOT NULL,
+				file_size BIGINT NOT NULL,
+				uploaded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (comment_id) REFERENCES maintenance_comments(comment_id) ON DELETE CASCADE
+			)`,
+		},
+		{
+			"maintenance_sla_policies",
+			`CREATE TABLE IF NOT EXISTS maintenance_sla_policies (
+				policy_id INT PRIMARY KEY AUTO_INCREMENT,
+				house_id INT NOT NULL,
+				priority ENUM('low', 'medium', 'high', 'emergency') NOT NULL,
+				response_minutes INT NOT NULL,
+				resolution_minutes INT NOT NULL,
+				FOREIGN KEY (house_id) REFERENCES boarding_houses(house_id) ON DELETE CASCADE,
+				UNIQUE KEY uq_sla_policy (house_id, priority)
+			)`,
+		},
+		{
+			"maintenance_escalations",
+			`CREATE TABLE IF NOT EXISTS maintenance_escalations (
+				escalation_id INT PRIMARY KEY AUTO_INCREMENT,
+				request_id INT NOT NULL,
+				breach_type ENUM('response', 'resolution') NOT NULL,
+				level TINYINT NOT NULL,
+				escalated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (request_id) REFERENCES maintenance_requests(request_id) ON DELETE CASCADE,
+				UNIQUE KEY uq_escalation (request_id, breach_type, level)
+			)`,
+		},
+		{
+			"vendor_documents",
+			`CREATE TABLE IF NOT EXISTS vendor_documents (
+				document_id INT PRIMARY KEY AUTO_INCREMENT,
+				vendor_id INT NOT NULL,
+				document_type ENUM('insurance', 'licence', 'other') NOT NULL,
+				file_path VARCHAR(255) NOT NULL,
+				expires_on DATE,
+				uploaded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (vendor_id) REFERENCES vendors(vendor_id) ON DELETE CASCADE
+			)`,
+		},
+		{
+			"vendor_quotes",
+			`CREATE TABLE IF NOT EXISTS vendor_quotes (
+				quote_id INT PRIMARY KEY AUTO_INCREMENT,
+				request_id INT NOT NULL,
+				vendor_id INT NOT NULL,
+				amount DECIMAL(10,2) NOT NULL,
+				description TEXT,
+				status ENUM('submitted', 'approved', 'rejected') DEFAULT 'submitted',
+				submitted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				decided_by INT,
+				decided_at TIMESTAMP NULL,
+				FOREIGN KEY (request_id) REFERENCES maintenance_requests(request_id) ON DELETE CASCADE,
+				FOREIGN KEY (vendor_id) REFERENCES vendors(vendor_id),
+				FOREIGN KEY (decided_by) REFERENCES users(user_id)
+			)`,
+		},
+		{
+			"vendor_invoices",
+			`CREATE TABLE IF NOT EXISTS vendor_invoices (
+				invoice_id INT PRIMARY KEY AUTO_INCREMENT,
+				request_id INT NOT NULL,
+				vendor_id INT NOT NULL,
+				quote_id INT,
+				invoice_number VARCHAR(50) NOT NULL,
+				amount DECIMAL(10,2) NOT NULL,
+				invoice_date DATE NOT NULL,
+				file_path VARCHAR(255),
+				status ENUM('unpaid', 'paid') DEFAULT 'unpaid',
+				paid_at TIMESTAMP NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (request_id) REFERENCES maintenance_requests(request_id) ON DELETE CASCADE,
+				FOREIGN KEY (vendor_id) REFERENCES vendors(vendor_id),
+				FOREIGN KEY (quote_id) REFERENCES vendor_quotes(quote_id),
+				UNIQUE KEY uq_vendor_invoice (vendor_id, invoice_number)
+			)`,
+		},
+		{
+			"maintenance_plans",
+			`CREATE TABLE IF NOT EXISTS maintenance_plans (
+				plan_id INT PRIMARY KEY AUTO_INCREMENT,
+				house_id INT NOT NULL,
+				room_id INT,
+				asset_id INT,
+				room_type ENUM('single', 'double', 'dormitory', 'suite'),
+				title VARCHAR(100) NOT NULL,
+				description TEXT,
+				issue_type VARCHAR(100) NOT NULL,
+				priority ENUM('low', 'medium', 'high', 'emergency') DEFAULT 'low',
+				interval_months INT NOT NULL,
+				lead_days INT DEFAULT 7,
+				next_due_date DATE NOT NULL,
+				assigned_to INT,
+				is_active BOOLEAN DEFAULT TRUE,
+				created_by INT NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (house_id) REFERENCES boarding_houses(house_id) ON DELETE CASCADE,
+				FOREIGN KEY (room_id) REFERENCES rooms(room_id) ON DELETE CASCADE,
+				FOREIGN KEY (asset_id) REFERENCES assets(asset_id) ON DELETE SET NULL,
+				FOREIGN KEY (assigned_to) REFERENCES users(user_id),
+				FOREIGN KEY (created_by) REFERENCES users(user_id)
+			)`,
+		},
+		{
+			"maintenance_plan_runs",
+			`CREATE TABLE IF NOT EXISTS maintenance_plan_runs (
+				run_id INT PRIMARY KEY AUTO_INCREMENT,
+				plan_id INT NOT NULL,
+				room_id INT NOT NULL,
+				asset_id INT,
+				due_date DATE NOT NULL,
+				request_id INT NOT NULL,
+				generated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (plan_id) REFERENCES maintenance_plans(plan_id) ON DELETE CASCADE,
+				FOREIGN KEY (room_id) REFERENCES rooms(room_id) ON DELETE CASCADE,
+				FOREIGN KEY (asset_id) REFERENCES assets(asset_id) ON DELETE SET NULL,
+				FOREIGN KEY (request_id) REFERENCES maintenance_requests(request_id) ON DELETE CASCADE,
+				UNIQUE KEY uq_plan_run (plan_id, room_id, due_date)
+			)`,
+		},
+		{
+			"inspection_checklist_items",
+			`CREATE TABLE IF NOT EXISTS inspection_checklist_items (
+				checklist_item_id INT PRIMARY KEY AUTO_INCREMENT,
+				room_type ENUM('single', 'double', 'dormitory', 'suite') NOT NULL,
+				area VARCHAR(50) NOT NULL,
+				label VARCHAR(100) NOT NULL,
+				sort_order INT DEFAULT 0,
+				is_active BOOLEAN DEFAULT TRUE,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			)`,
+		},
+		{
+			"inspections",
+			`CREATE TABLE IF NOT EXISTS inspections (
+				inspection_id INT PRIMARY KEY AUTO_INCREMENT,
+				tenant_id INT NOT NULL,
+				room_id INT NOT NULL,
+				inspection_type ENUM('move_in', 'move_out') NOT NULL,
+				status ENUM('draft', 'completed', 'acknowledged', 'disputed') DEFAULT 'draft',
+				inspected_by INT NOT NULL,
+				notes TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				completed_at TIMESTAMP NULL,
+				acknowledged_at TIMESTAMP NULL,
+				tenant_comment TEXT,
+				FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id) ON DELETE CASCADE,
+				FOREIGN KEY (room_id) REFERENCES rooms(room_id),
+				FOREIGN KEY (inspected_by) REFERENCES users(user_id),
+				UNIQUE KEY uq_tenant_inspection (tenant_id, inspection_type)
+			)`,
+		},
+		{
+			"inspection_items",
+			`CREATE TABLE IF NOT EXISTS inspection_items (
+				inspection_item_id INT PRIMARY KEY AUTO_INCREMENT,
+				inspection_id INT NOT NULL,
+				checklist_item_id INT,
+				area VARCHAR(50) NOT NULL,
+				label VARCHAR(100) NOT NULL,
+				item_condition ENUM('excellent', 'good', 'fair', 'poor', 'damaged', 'missing'),
+				notes TEXT,
+				estimated_cost DECIMAL(10,2),
+				FOREIGN KEY (inspection_id) REFERENCES inspections(inspection_id) ON DELETE CASCADE,
+				FOREIGN KEY (checklist_item_id) REFERENCES inspection_checklist_items(checklist_item_id) ON DELETE SET NULL
+			)`,
+		},
+		{
+			"inspection_photos",
+			`CREATE TABLE IF NOT EXISTS inspection_photos (
+				photo_id INT PRIMARY KEY AUTO_INCREMENT,
+				inspection_item_id INT NOT NULL,
+				file_path VARCHAR(255) NOT NULL,
+				original_name VARCHAR(255),
+				uploaded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (inspection_item_id) REFERENCES inspection_items(inspection_item_id) ON DELETE CASCADE
+			)`,
+		},
+		{
+			"staff_profiles",
+			`CREATE TABLE IF NOT EXISTS staff_profiles (
+				user_id INT PRIMARY KEY,
+				house_id INT,
+				skills VARCHAR(255),
+				on_shift BOOLEAN DEFAULT FALSE,
+				max_open_requests INT DEFAULT 10,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+				FOREIGN KEY (house_id) REFERENCES boarding_houses(house_id) ON DELETE SET NULL
+			)`,
+		},
+		{
+			"assignment_rules",
+			`CREATE TABLE IF NOT EXISTS assignment_rules (
+				rule_id INT PRIMARY KEY AUTO_INCREMENT,
+				name VARCHAR(100) NOT NULL,
+				house_id INT,
+				issue_type VARCHAR(100),
+				required_skill VARCHAR(50),
+				on_shift_only BOOLEAN DEFAULT TRUE,
+				rule_order INT DEFAULT 0,
+				is_active BOOLEAN DEFAULT TRUE,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (house_id) REFERENCES boarding_houses(house_id) ON DELETE CASCADE
+			)`,
+		},
+		{
+			"maintenance_ratings",
+			`CREATE TABLE IF NOT EXISTS maintenance_ratings (
+				rating_id INT PRIMARY KEY AUTO_INCREMENT,
+				request_id INT NOT NULL,
+				rated_by INT NOT NULL,
+				score TINYINT NOT NULL,
+				feedback TEXT,
+				reopened BOOLEAN DEFAULT FALSE,
+				completed_date TIMESTAMP NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (request_id) REFERENCES maintenance_requests(request_id) ON DELETE CASCADE,
+				FOREIGN KEY (rated_by) REFERENCES users(user_id),
+				UNIQUE KEY uq_rating_completion (request_id, completed_date)
+			)`,
+		},
+		{
+			"notification_preferences",
+			`CREATE TABLE IF NOT EXISTS notification_preferences (
+				user_id INT PRIMARY KEY,
+				email_enabled BOOLEAN DEFAULT TRUE,
+				sms_enabled BOOLEAN DEFAULT FALSE,
+				locale VARCHAR(10) DEFAULT 'en',
+				quiet_hours_start CHAR(5),
+				quiet_hours_end CHAR(5),
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+			)`,
+		},
+		{
+			"notification_deliveries",
+			`CREATE TABLE IF NOT EXISTS notification_deliveries (
+				delivery_id INT PRIMARY KEY AUTO_INCREMENT,
+				notification_id INT NOT NULL,
+				channel ENUM('in_app', 'email', 'sms') NOT NULL,
+				status ENUM('pending', 'sent', 'failed', 'skipped') DEFAULT 'pending',
+				attempts INT DEFAULT 0,
+				last_error VARCHAR(255),
+				next_attempt_at TIMESTAMP NULL,
+				sent_at TIMESTAMP NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (notification_id) REFERENCES notifications(notification_id) ON DELETE CASCADE,
+				UNIQUE KEY uq_delivery_channel (notification_id, channel),
+				INDEX idx_delivery_due (status, next_attempt_at)
+			)`,
+		},
+		{
+			"notification_templates",
+			`CREATE TABLE IF NOT EXISTS notification_templates (
+				template_id INT PRIMARY KEY AUTO_INCREMENT,
+				name VARCHAR(50) NOT NULL,
+				locale VARCHAR(10) NOT NULL,
+				title VARCHAR(255) NOT NULL,
+				message TEXT NOT NULL,
+				link VARCHAR(255),
+				updated_by INT,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				FOREIGN KEY (updated_by) REFERENCES users(user_id) ON DELETE SET NULL,
+				UNIQUE KEY uq_template_locale (name, locale)
+			)`,
+		},
+		{
+			"outbox_events",
+			`CREATE TABLE IF NOT EXISTS outbox_events (
+				event_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+				event_type VARCHAR(100) NOT NULL,
+				subject_type VARCHAR(50) NOT NULL,
+				subject_id INT NOT NULL,
+				payload JSON NOT NULL,
+				status ENUM('pending', 'processed', 'failed') DEFAULT 'pending',
+				attempts INT DEFAULT 0,
+				last_error VARCHAR(255),
+				next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				occurred_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				processed_at TIMESTAMP NULL,
+				INDEX idx_outbox_due (status, next_attempt_at)
+			)`,
+		},
+		{
+			"outbox_handled",
+			`CREATE TABLE IF NOT EXISTS outbox_handled (
+				event_id BIGINT NOT NULL,
+				subscriber VARCHAR(100) NOT NULL,
+				handled_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (event_id, subscriber),
+				FOREIGN KEY (event_id) REFERENCES outbox_events(event_id) ON DELETE CASCADE
+			)`,
+		},
+		{
+			"audit_log",
+			`CREATE TABLE IF NOT EXISTS audit_log (
+				audit_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+				event_id BIGINT UNIQUE NOT NULL,
+				event_type VARCHAR(100) NOT NULL,
+				subject_type VARCHAR(50) NOT NULL,
+				subject_id INT NOT NULL,
+				payload JSON NOT NULL,
+				occurred_at TIMESTAMP NOT NULL,
+				recorded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				INDEX idx_audit_subject (subject_type, subject_id)
+			)`,
+		},
+		{
+			"webhooks",
+			`CREATE TABLE IF NOT EXISTS webhooks (
+				webhook_id INT PRIMARY KEY AUTO_INCREMENT,
+				url VARCHAR(500) NOT NULL,
+				secret VARCHAR(64) NOT NULL,
+				event_types VARCHAR(500) NOT NULL,
+				description VARCHAR(255),
+				is_active BOOLEAN DEFAULT TRUE,
+				consecutive_failures INT DEFAULT 0,
+				disabled_reason VARCHAR(255),
+				created_by INT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				FOREIGN KEY (created_by) REFERENCES users(user_id) ON DELETE SET NULL
+			)`,
+		},
+		{
+			"webhook_deliveries",
+			`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+				delivery_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+				webhook_id INT NOT NULL,
+				event_id BIGINT NOT NULL,
+				event_type VARCHAR(100) NOT NULL,
+				redelivery INT DEFAULT 0,
+				payload JSON NOT NULL,
+				status ENUM('pending', 'succeeded', 'failed') DEFAULT 'pending',
+				attempts INT DEFAULT 0,
+				response_code INT,
+				response_body VARCHAR(500),
+				last_error VARCHAR(255),
+				next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				delivered_at TIMESTAMP NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (webhook_id) REFERENCES webhooks(webhook_id) ON DELETE CASCADE,
+				UNIQUE KEY uq_webhook_event (webhook_id, event_id, redelivery),
+				INDEX idx_webhook_delivery_due (status, next_attempt_at)
+			)`,
+		},
+		{
+			"announcements",
+			`CREATE TABLE IF NOT EXISTS announcements (
+				announcement_id INT PRIMARY KEY AUTO_INCREMENT,
+				title VARCHAR(100) NOT NULL,
+				body TEXT NOT NULL,
+				audience ENUM('house', 'rooms', 'staff', 'everyone') NOT NULL,
+				house_id INT,
+				is_pinned BOOLEAN DEFAULT FALSE,
+				publish_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				expires_at TIMESTAMP NULL,
+				published_at TIMESTAMP NULL,
+				created_by INT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				FOREIGN KEY (house_id) REFERENCES boarding_houses(house_id) ON DELETE CASCADE,
+				FOREIGN KEY (created_by) REFERENCES users(user_id) ON DELETE SET NULL,
+				INDEX idx_announcement_due (published_at, publish_at)
+			)`,
+		},
+		{
+			"announcement_rooms",
+			`CREATE TABLE IF NOT EXISTS announcement_rooms (
+				announcement_id INT NOT NULL,
+				room_id INT NOT NULL,
+				PRIMARY KEY (announcement_id, room_id),
+				FOREIGN KEY (announcement_id) REFERENCES announcements(announcement_id) ON DELETE CASCADE,
+				FOREIGN KEY (room_id) REFERENCES rooms(room_id) ON DELETE CASCADE
+			)`,
+		},
+		{
+			"announcement_recipients",
+			`CREATE TABLE IF NOT EXISTS announcement_recipients (
+				announcement_id INT NOT NULL,
+				user_id INT NOT NULL,
+				notification_id INT,
+				read_at TIMESTAMP NULL,
+				PRIMARY KEY (announcement_id, user_id),
+				FOREIGN KEY (announcement_id) REFERENCES announcements(announcement_id) ON DELETE CASCADE,
+				FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+				FOREIGN KEY (notification_id) REFERENCES notifications(notification_id) ON DELETE SET NULL
+			)`,
+		},
+		{
+			"conversations",
+			`CREATE TABLE IF NOT EXISTS conversations (
+				conversation_id INT PRIMARY KEY AUTO_INCREMENT,
+				house_id INT NOT NULL,
+				tenant_user_id INT NOT NULL,
+				subject VARCHAR(150) NOT NULL,
+				status ENUM('open', 'closed') DEFAULT 'open',
+				started_by INT NOT NULL,
+				last_message_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (house_id) REFERENCES boarding_houses(house_id) ON DELETE CASCADE,
+				FOREIGN KEY (tenant_user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+				FOREIGN KEY (started_by) REFERENCES users(user_id),
+				INDEX idx_conversation_house (house_id, status, last_message_at),
+				INDEX idx_conversation_tenant (tenant_user_id, last_message_at)
+			)`,
+		},
+		{
+			"messages",
+			`CREATE TABLE IF NOT EXISTS messages (
+				message_id INT PRIMARY KEY AUTO_INCREMENT,
+				conversation_id INT NOT NULL,
+				sender_id INT NOT NULL,
+				body TEXT NOT NULL,
+				read_at TIMESTAMP NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (conversation_id) REFERENCES conversations(conversation_id) ON DELETE CASCADE,
+				FOREIGN KEY (sender_id) REFERENCES users(user_id)
+			)`,
+		},
+		{
+			"message_attachments",
+			`CREATE TABLE IF NOT EXISTS message_attachments (
+				attachment_id INT PRIMARY KEY AUTO_INCREMENT,
+				message_id INT NOT NULL,
+				file_path VARCHAR(255) NOT NULL,
+				original_name VARCHAR(255),
+				file_size BIGINT NOT NULL,
+				uploaded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (message_id) REFERENCES messages(message_id) ON DELETE CASCADE
+			)`,
+		},
+		{
+			"realtime_events",
+			`CREATE TABLE IF NOT EXISTS realtime_events (
+				event_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+				user_id INT NOT NULL,
+				event_type VARCHAR(50) NOT NULL,
+				payload JSON NOT NULL,
+				created_at TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3),
+				INDEX idx_realtime_events_created (created_at)
+			)`,
+		},
 		// Add other tables here in proper foreign key dependency order
 	}
 
+	// A database created by an earlier release only gets the schema changes
+	// it has not had yet. Changes to existing tables above also need a step
+	// in schemaMigrations.
+	if initialized {
+		log.Println("Database already initialized, applying schema migrations")
+		return applySchemaMigrations(db, tables)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			log.Printf("Migration failed, rolling back: %v", err)
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("Rollback failed: %v", rbErr)
+			}
+		}
+	}()
+
 	for _, table := range tables {
 		log.Printf("Creating table %s...", table.name)
 		if _, err := tx.Exec(table.query); err != nil {
@@ -224,6 +921,11 @@ func RunMigrations(db *sql.DB) error {
 		return fmt.Errorf("commit failed: %w", err)
 	}
 
+	// The new database already has every schema change
+	if err := markSchemaMigrations(db); err != nil {
+		return err
+	}
+
 	log.Println("Migrations completed successfully")
 	return nil
 }
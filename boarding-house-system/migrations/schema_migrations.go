@@ -0,0 +1,296 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// schemaMigration brings a database created by an earlier release up to
+// date. Versions are applied in order and recorded in schema_migrations.
+type schemaMigration struct {
+	version int
+	name    string
+	steps   []schemaStep
+}
+
+// schemaStep is one DDL statement. MySQL commits DDL implicitly, so a
+// migration that fails halfway is simply run again: steps with an exists
+// query are skipped when it finds that the change is already there, and the
+// others are safe to repeat. A step with a table creates that table from its
+// definition in RunMigrations.
+type schemaStep struct {
+	table  string
+	exists string
+	args   []interface{}
+	query  string
+}
+
+var schemaMigrations = []schemaMigration{
+	{1, "payment reversals", []schemaStep{
+		modifyColumn("payments", "status", "ENUM('paid', 'pending', 'overdue', 'partial', 'voided', 'refunded') NOT NULL"),
+		addColumn("payments", "entry_type", "ENUM('payment', 'void', 'refund') NOT NULL DEFAULT 'payment'"),
+		addColumn("payments", "reversal_of", "INT"),
+		addColumn("payments", "reason", "TEXT"),
+		addColumn("payments", "created_at", "TIMESTAMP DEFAULT CURRENT_TIMESTAMP"),
+		addForeignKey("payments", "reversal_of", "payments(payment_id)"),
+	}},
+	{2, "bank statement reconciliation", []schemaStep{
+		createTable("bank_statement_imports"),
+		createTable("bank_statement_lines"),
+	}},
+	{3, "rent invoices", []schemaStep{
+		createTable("invoices"),
+	}},
+	{4, "house expenses", []schemaStep{
+		createTable("recurring_expenses"),
+		createTable("expenses"),
+	}},
+	{5, "property owners", []schemaStep{
+		createTable("owners"),
+		createTable("owner_houses"),
+		createTable("owner_statements"),
+		createTable("owner_statement_lines"),
+		createTable("owner_payouts"),
+	}},
+	{6, "occupancy forecast", []schemaStep{
+		addColumn("tenants", "lease_end_date", "DATE"),
+		createTable("reservations"),
+	}},
+	{7, "maintenance timeline", []schemaStep{
+		addColumn("maintenance_requests", "started_date", "TIMESTAMP NULL"),
+		modifyColumn("maintenance_requests", "completed_date", "TIMESTAMP NULL"),
+		addColumn("maintenance_requests", "cancelled_date", "TIMESTAMP NULL"),
+		createTable("maintenance_events"),
+	}},
+	{8, "maintenance comments", []schemaStep{
+		createTable("maintenance_comments"),
+		createTable("maintenance_attachments"),
+	}},
+	{9, "maintenance SLAs", []schemaStep{
+		modifyColumn("maintenance_events", "event_type",
+			"ENUM('created', 'status_changed', 'assigned', 'updated', 'escalated') NOT NULL"),
+		createTable("maintenance_sla_policies"),
+		createTable("maintenance_escalations"),
+	}},
+	{10, "vendors", []schemaStep{
+		createTable("vendors"),
+		addColumn("maintenance_requests", "vendor_id", "INT"),
+		addForeignKey("maintenance_requests", "vendor_id", "vendors(vendor_id)"),
+		createTable("vendor_documents"),
+		createTable("vendor_quotes"),
+		createTable("vendor_invoices"),
+	}},
+	{11, "preventive maintenance plans", []schemaStep{
+		createTable("maintenance_plans"),
+		createTable("maintenance_plan_runs"),
+	}},
+	{12, "room assets", []schemaStep{
+		createTable("assets"),
+		addColumn("maintenance_requests", "asset_id", "INT"),
+		addForeignKey("maintenance_requests", "asset_id", "assets(asset_id) ON DELETE SET NULL"),
+	}},
+	{13, "inspections", []schemaStep{
+		createTable("inspection_checklist_items"),
+		createTable("inspections"),
+		createTable("inspection_items"),
+		createTable("inspection_photos"),
+	}},
+	{14, "maintenance auto-assignment", []schemaStep{
+		createTable("staff_profiles"),
+		createTable("assignment_rules"),
+	}},
+	{15, "maintenance ratings", []schemaStep{
+		createTable("maintenance_ratings"),
+	}},
+	{16, "notification channels", []schemaStep{
+		createTable("notification_preferences"),
+		createTable("notification_deliveries"),
+	}},
+	{17, "notification templates", []schemaStep{
+		addColumn("notification_preferences", "locale", "VARCHAR(10) DEFAULT 'en'"),
+		createTable("notification_templates"),
+	}},
+	{18, "event outbox", []schemaStep{
+		createTable("outbox_events"),
+		createTable("outbox_handled"),
+		createTable("audit_log"),
+	}},
+	{19, "webhooks", []schemaStep{
+		createTable("webhooks"),
+		createTable("webhook_deliveries"),
+	}},
+	{20, "announcements", []schemaStep{
+		createTable("announcements"),
+		createTable("announcement_rooms"),
+		createTable("announcement_recipients"),
+	}},
+	{21, "notification inbox", []schemaStep{
+		addColumn("notifications", "category", "VARCHAR(30) NOT NULL DEFAULT 'general'"),
+		addIndex("notifications", "idx_notification_inbox", "user_id, is_read, created_at"),
+	}},
+	{22, "messaging", []schemaStep{
+		createTable("conversations"),
+		createTable("messages"),
+		createTable("message_attachments"),
+	}},
+	{23, "realtime events", []schemaStep{
+		createTable("realtime_events"),
+	}},
+	{24, "maintenance plan assets", []schemaStep{
+		addColumn("maintenance_plans", "asset_id", "INT"),
+		addForeignKey("maintenance_plans", "asset_id", "assets(asset_id) ON DELETE SET NULL"),
+		addColumn("maintenance_plan_runs", "asset_id", "INT"),
+		addForeignKey("maintenance_plan_runs", "asset_id", "assets(asset_id) ON DELETE SET NULL"),
+	}},
+}
+
+// applySchemaMigrations runs the migrations a database has not had yet.
+// The tables they add are created first, in the dependency order of tables,
+// since a table added by one migration may reference one added by a later
+// migration.
+func applySchemaMigrations(db *sql.DB, tables []struct{ name, query string }) error {
+	applied, err := appliedSchemaMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	var pending []schemaMigration
+	missing := make(map[string]bool)
+	for _, migration := range schemaMigrations {
+		if applied[migration.version] {
+			continue
+		}
+		pending = append(pending, migration)
+		for _, step := range migration.steps {
+			if step.table != "" {
+				missing[step.table] = true
+			}
+		}
+	}
+
+	for _, table := range tables {
+		if !missing[table.name] {
+			continue
+		}
+		log.Printf("Creating table %s...", table.name)
+		if _, err := db.Exec(table.query); err != nil {
+			return fmt.Errorf("failed to create %s: %w", table.name, err)
+		}
+		delete(missing, table.name)
+	}
+	for name := range missing {
+		return fmt.Errorf("schema migrations need table %s, which has no definition", name)
+	}
+
+	for _, migration := range pending {
+		log.Printf("Applying schema migration %d (%s)...", migration.version, migration.name)
+		for _, step := range migration.steps {
+			if err := step.apply(db); err != nil {
+				return fmt.Errorf("schema migration %d (%s) failed: %w", migration.version, migration.name, err)
+			}
+		}
+		if _, err := db.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`,
+			migration.version, migration.name); err != nil {
+			return fmt.Errorf("failed to record schema migration %d: %w", migration.version, err)
+		}
+	}
+	return nil
+}
+
+// markSchemaMigrations records every migration as applied, for a database
+// that was just created with the current schema.
+func markSchemaMigrations(db *sql.DB) error {
+	if _, err := appliedSchemaMigrations(db); err != nil {
+		return err
+	}
+	for _, migration := range schemaMigrations {
+		if _, err := db.Exec(`INSERT IGNORE INTO schema_migrations (version, name) VALUES (?, ?)`,
+			migration.version, migration.name); err != nil {
+			return fmt.Errorf("failed to record schema migration %d: %w", migration.version, err)
+		}
+	}
+	return nil
+}
+
+func appliedSchemaMigrations(db *sql.DB) (map[int]bool, error) {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	rows, err := db.Query(`SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// apply runs a step. Table steps have already been handled by
+// applySchemaMigrations.
+func (s schemaStep) apply(db *sql.DB) error {
+	if s.table != "" {
+		return nil
+	}
+	if s.exists != "" {
+		var count int
+		if err := db.QueryRow(s.exists, s.args...).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+	}
+	_, err := db.Exec(s.query)
+	return err
+}
+
+// createTable creates a table from its definition in RunMigrations.
+func createTable(name string) schemaStep {
+	return schemaStep{table: name}
+}
+
+func addColumn(table, column, definition string) schemaStep {
+	return schemaStep{
+		exists: `SELECT COUNT(*) FROM information_schema.columns
+		         WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?`,
+		args:  []interface{}{table, column},
+		query: fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition),
+	}
+}
+
+func modifyColumn(table, column, definition string) schemaStep {
+	return schemaStep{query: fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s", table, column, definition)}
+}
+
+func addIndex(table, index, columns string) schemaStep {
+	return schemaStep{
+		exists: `SELECT COUNT(*) FROM information_schema.statistics
+		         WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?`,
+		args:  []interface{}{table, index},
+		query: fmt.Sprintf("ALTER TABLE %s ADD INDEX %s (%s)", table, index, columns),
+	}
+}
+
+func addForeignKey(table, column, references string) schemaStep {
+	return schemaStep{
+		exists: `SELECT COUNT(*) FROM information_schema.key_column_usage
+		         WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?
+		         AND referenced_table_name IS NOT NULL`,
+		args:  []interface{}{table, column},
+		query: fmt.Sprintf("ALTER TABLE %s ADD FOREIGN KEY (%s) REFERENCES %s", table, column, references),
+	}
+}